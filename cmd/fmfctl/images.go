@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/storage_provider"
+)
+
+// runPurgeImages busca en el storage las imágenes que ninguna mascota usa y, con -delete, las
+// borra. Los archivos se comparan por la clave del proveedor (public ID o nombre de archivo), no
+// por la URL completa, para que un cambio de dominio o de UPLOAD_BASE_URL no los deje huérfanos.
+// Las más nuevas que -older-than se conservan porque la foto se sube antes de guardar la mascota
+func runPurgeImages(config *config.Config, args []string) error {
+	flags := flag.NewFlagSet("purge-images", flag.ExitOnError)
+	olderThan := flags.Duration("older-than", 24*time.Hour, "only purge images uploaded before this long ago")
+	deleteFiles := flags.Bool("delete", false, "delete the orphaned images; without it they are only listed")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	db := connect(config)
+
+	storageProvider, err := storage_provider.NewStorageProvider(config)
+	if err != nil {
+		return err
+	}
+
+	var pictureURLs []string
+	err = db.Model(&models.Pet{}).Where("picture_url <> ''").Pluck("picture_url", &pictureURLs).Error
+	if err != nil {
+		return err
+	}
+
+	referenced := make(map[string]bool, len(pictureURLs))
+	unknown := 0
+	for _, pictureURL := range pictureURLs {
+		key, err := storageProvider.Key(pictureURL)
+		if err != nil {
+			log.Printf("Cannot map picture URL %s to a stored file: %v", pictureURL, err)
+			unknown++
+			continue
+		}
+		referenced[key] = true
+	}
+
+	// Si alguna URL no se puede identificar no hay forma de saber qué archivo usa, así que no se borra nada
+	if unknown > 0 && *deleteFiles {
+		return fmt.Errorf("%d picture URLs could not be mapped to stored files; nothing was deleted", unknown)
+	}
+
+	files, err := storageProvider.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-*olderThan)
+	purged, failed := 0, 0
+	for _, file := range files {
+		if file.Key == "" || referenced[file.Key] || file.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		if !*deleteFiles {
+			fmt.Println(file.URL)
+			purged++
+			continue
+		}
+
+		if err := storageProvider.Delete(ctx, file.URL); err != nil {
+			log.Printf("Failed to delete %s: %v", file.URL, err)
+			failed++
+			continue
+		}
+		purged++
+	}
+
+	if !*deleteFiles {
+		log.Printf("%d of %d images are orphaned; run with -delete to delete them", purged, len(files))
+		return nil
+	}
+
+	services.NewAuditService(repositories.NewAuditRepository(db, config.Database.QueryTimeout)).Record(ctx, &models.AuditEvent{
+		Action:  models.AuditActionImagesPurged,
+		Details: fmt.Sprintf("fmfctl: %d deleted, %d failed", purged, failed),
+	})
+
+	log.Printf("Deleted %d orphaned images (%d failed) of %d stored", purged, failed, len(files))
+	if failed > 0 {
+		return fmt.Errorf("%d images could not be deleted", failed)
+	}
+	return nil
+}
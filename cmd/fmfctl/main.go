@@ -0,0 +1,138 @@
+// fmfctl es la herramienta de operación de Find My Friend: usa la misma configuración (.env y
+// variables de entorno) y la misma base que el servidor
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go-api-find-my-friend/internal/cli"
+	"go-api-find-my-friend/internal/seeder"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/database"
+	_ "go-api-find-my-friend/pkg/database/migrations"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/storage_provider"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const usage = `Usage: fmfctl <command> [options]
+
+Commands:
+  migrate up|down|status|create   manage schema migrations
+  seed                            generate demo users and pets in an empty database
+  create-admin                    create an administrator account
+  reset-password                  set a new password for a user
+  reindex [TABLE...]              rebuild the indexes used by search (default: pets)
+  purge-images                    list (or with -delete, delete) stored images that no pet references
+  export-pets                     export pets as CSV or JSON
+
+Run "fmfctl <command> -h" to see the options of each command.
+`
+
+type command func(config *config.Config, args []string) error
+
+var commands = map[string]command{
+	"seed":           runSeed,
+	"create-admin":   runCreateAdmin,
+	"reset-password": runResetPassword,
+	"reindex":        runReindex,
+	"purge-images":   runPurgeImages,
+	"export-pets":    runExportPets,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Error loading config:", err)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+	if name == "migrate" {
+		cli.RunMigrate("fmfctl", config, args)
+		return
+	}
+
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := run(config, args); err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+}
+
+// connect abre la base y solo registra consultas lentas o fallidas. fmfctl no reemplaza el logger
+// default de slog, que escribe en stderr, así el log no se mezcla con la salida de los comandos
+func connect(config *config.Config) *gorm.DB {
+	return database.Connect(config).Session(&gorm.Session{
+		Logger: logger.NewGormLogger(time.Second).LogMode(gormlogger.Warn),
+	})
+}
+
+func runSeed(config *config.Config, args []string) error {
+	defaults := seeder.DefaultOptions()
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	seed := flags.Int64("seed", defaults.Seed, "seed of the generated data; the same seed and date give the same data")
+	users := flags.Int("users", defaults.Users, "number of regular users, besides the admin and moderator accounts")
+	pets := flags.Int("pets", defaults.Pets, "number of pets")
+	images := flags.Bool("images", false, "upload a placeholder image per pet with the configured storage")
+	date := flags.String("date", "", "reference date of the generated times (YYYY-MM-DD); today if empty")
+	flags.Parse(args)
+
+	opts := seeder.Options{Seed: *seed, Users: *users, Pets: *pets, Images: *images}
+	if *date != "" {
+		now, err := time.ParseInLocation("2006-01-02", *date, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -date: %w", err)
+		}
+		opts.Now = now
+	}
+
+	db := connect(config)
+	if err := database.Migrate(db); err != nil {
+		return err
+	}
+
+	storageProvider, err := storage_provider.NewStorageProvider(config)
+	if err != nil {
+		return err
+	}
+
+	result, err := seeder.New(db, storageProvider).Run(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Seeded %d users and %d pets (regular users password %q)", len(result.Users), len(result.Pets), seeder.Password)
+	result.WriteStaffPasswords(os.Stdout)
+	return nil
+}
+
+func runReindex(config *config.Config, args []string) error {
+	tables := args
+	if len(tables) == 0 {
+		tables = []string{"pets"}
+	}
+
+	db := connect(config)
+	if err := database.Reindex(db, tables...); err != nil {
+		return err
+	}
+
+	log.Printf("Reindexed %v", tables)
+	return nil
+}
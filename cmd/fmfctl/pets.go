@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+const exportBatchSize = 500
+
+// petExport es la fila exportada; no incluye datos de contacto del dueño
+type petExport struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	Breed         string     `json:"breed"`
+	Status        string     `json:"status"`
+	LastSeenPlace string     `json:"last_seen_place"`
+	LastSeenTime  time.Time  `json:"last_seen_time"`
+	OwnerID       int        `json:"owner_id"`
+	PictureURL    string     `json:"picture_url"`
+	Hidden        bool       `json:"hidden"`
+	FoundAt       *time.Time `json:"found_at"`
+	ArchivedAt    *time.Time `json:"archived_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+var petExportHeader = []string{"id", "name", "type", "breed", "status", "last_seen_place", "last_seen_time", "owner_id", "picture_url", "hidden", "found_at", "archived_at", "created_at"}
+
+func (p *petExport) record() []string {
+	return []string{
+		strconv.Itoa(p.ID),
+		p.Name,
+		p.Type,
+		p.Breed,
+		p.Status,
+		p.LastSeenPlace,
+		p.LastSeenTime.Format(time.RFC3339),
+		strconv.Itoa(p.OwnerID),
+		p.PictureURL,
+		strconv.FormatBool(p.Hidden),
+		formatOptionalTime(p.FoundAt),
+		formatOptionalTime(p.ArchivedAt),
+		p.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func runExportPets(config *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export-pets", flag.ExitOnError)
+	format := flags.String("format", "csv", "output format: csv or json")
+	output := flags.String("output", "-", "output file; - writes to stdout")
+	status := flags.String("status", "", "only export pets in this status")
+	flags.Parse(args)
+
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+	if *status != "" && !isPetStatus(*status) {
+		return fmt.Errorf("unknown status %q", *status)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	db := connect(config)
+	query := db.Model(&models.Pet{}).Order("id ASC")
+	if *status != "" {
+		query = query.Where("status = ?", *status)
+	}
+
+	if *format == "csv" {
+		return exportCSV(w, query)
+	}
+	return exportJSON(w, query)
+}
+
+func exportCSV(w io.Writer, query *gorm.DB) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(petExportHeader); err != nil {
+		return err
+	}
+
+	err := eachPet(query, func(row *petExport) error {
+		return writer.Write(row.record())
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportJSON escribe un array JSON de a un elemento para no cargar todas las mascotas en memoria
+func exportJSON(w io.Writer, query *gorm.DB) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := eachPet(query, func(row *petExport) error {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			if _, err := io.WriteString(w, ",\n"); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
+
+func eachPet(query *gorm.DB, write func(row *petExport) error) error {
+	var pets []models.Pet
+	result := query.FindInBatches(&pets, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range pets {
+			row := newPetExport(&pets[i])
+			if err := write(&row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result.Error
+}
+
+func newPetExport(pet *models.Pet) petExport {
+	return petExport{
+		ID:            pet.ID,
+		Name:          pet.Name,
+		Type:          pet.Type,
+		Breed:         pet.Breed,
+		Status:        pet.Status,
+		LastSeenPlace: pet.LastSeenPlace,
+		LastSeenTime:  pet.LastSeenTime,
+		OwnerID:       pet.UserID,
+		PictureURL:    pet.PictureURL,
+		Hidden:        pet.IsHidden(),
+		FoundAt:       pet.FoundAt,
+		ArchivedAt:    pet.ArchivedAt,
+		CreatedAt:     pet.CreatedAt,
+	}
+}
+
+func isPetStatus(status string) bool {
+	for _, candidate := range models.PetStatuses {
+		if candidate == status {
+			return true
+		}
+	}
+	return false
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/config"
+)
+
+func runCreateAdmin(config *config.Config, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := flags.String("email", "", "email of the new administrator (required)")
+	name := flags.String("name", "Admin", "first name")
+	lastName := flags.String("last-name", "Find My Friend", "last name")
+	phone := flags.String("phone", "", "phone number (required)")
+	password := flags.String("password", "", "password; a random one is generated and printed if empty")
+	flags.Parse(args)
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+
+	dto := services.UserCreateDTO{
+		Name:            *name,
+		LastName:        *lastName,
+		Email:           strings.TrimSpace(*email),
+		Password:        *password,
+		ConfirmPassword: *password,
+		Phone:           *phone,
+	}
+
+	errs := map[string]string{}
+	if !dto.Validate(&errs) {
+		return fmt.Errorf("invalid arguments: %v", errs)
+	}
+
+	ctx := context.Background()
+	db := connect(config)
+	user, err := services.NewUserService(repositories.NewUserRepository(db, config.Database.QueryTimeout, nil), nil).CreateUserWithRole(ctx, &dto, models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	services.NewAuditService(repositories.NewAuditRepository(db, config.Database.QueryTimeout)).Record(ctx, &models.AuditEvent{
+		Action:  models.AuditActionAdminCreated,
+		Subject: fmt.Sprintf("user:%d", user.ID),
+		Details: "fmfctl",
+	})
+
+	log.Printf("Created administrator %s (id %d)", user.Email, user.ID)
+	if generated {
+		fmt.Println(*password)
+	}
+	return nil
+}
+
+func runResetPassword(config *config.Config, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	email := flags.String("email", "", "email of the user (required)")
+	password := flags.String("password", "", "new password; a random one is generated and printed if empty")
+	flags.Parse(args)
+
+	if *email == "" {
+		return fmt.Errorf("-email is required")
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+
+	ctx := context.Background()
+	db := connect(config)
+	userService := services.NewUserService(repositories.NewUserRepository(db, config.Database.QueryTimeout, nil), nil)
+
+	user, err := userService.GetByEmail(ctx, strings.TrimSpace(*email))
+	if err != nil {
+		return err
+	}
+
+	if err := userService.ResetPassword(ctx, user.ID, *password); err != nil {
+		return err
+	}
+
+	services.NewAuditService(repositories.NewAuditRepository(db, config.Database.QueryTimeout)).Record(ctx, &models.AuditEvent{
+		Action:  models.AuditActionPasswordReset,
+		Subject: fmt.Sprintf("user:%d", user.ID),
+		Details: "fmfctl",
+	})
+
+	log.Printf("Password of %s (id %d) was reset", user.Email, user.ID)
+	if generated {
+		fmt.Println(*password)
+	}
+	return nil
+}
+
+func randomPassword() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatal("Failed to generate password: ", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
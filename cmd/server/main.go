@@ -1,12 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"go-api-find-my-friend/internal/app"
+	"go-api-find-my-friend/internal/cli"
+	"go-api-find-my-friend/internal/middleware"
 	"go-api-find-my-friend/internal/routes"
+	"go-api-find-my-friend/internal/seeder"
 	"go-api-find-my-friend/pkg/config"
 	"go-api-find-my-friend/pkg/database"
+	_ "go-api-find-my-friend/pkg/database/migrations"
+	"go-api-find-my-friend/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,29 +27,56 @@ import (
 func main() {
 	config, err := config.LoadConfig()
 	if err != nil {
-		log.Fatal("Error loading config:", err)
+		logger.Fatal("failed to load config", logger.Err(err))
 	}
 
-	if config.IsProduction() {
-		log.Printf("🚀 Running in PRODUCTION mode")
-		database.CreateDB(config)
-		database.Connect(config)
-		database.AutoMigrate()
-	} else {
-		log.Printf("🔧 Running in DEVELOPMENT mode")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cli.RunMigrate("server", config, os.Args[2:])
+		return
 	}
 
+	baseLogger := logger.Setup(config.Log)
+	baseLogger.Info("starting server", "environment", config.Server.Environment, "log_level", config.Log.Level)
+
+	database.CreateDB(config)
+	db := database.Connect(config)
+	if err := database.Migrate(db); err != nil {
+		logger.Fatal("failed to migrate database", logger.Err(err))
+	}
+
+	application, err := app.New(config, db)
+	if err != nil {
+		logger.Fatal("failed to build application", logger.Err(err))
+	}
+
+	// Solo se siembra la base embebida del modo desarrollo. Con otro ENVIRONMENT (staging, un valor
+	// mal escrito) o con una base externa vacía no se crean cuentas
+	if config.IsDevelopment() && config.Database.Driver == database.DriverSQLite {
+		seedDevelopmentData(application)
+	}
+
+	application.Start()
+
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
+	router := gin.New()
 
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	// Sin esto Gin confía en el X-Forwarded-For de cualquiera y la IP del cliente, que usan el
+	// bloqueo de login y la auditoría, se puede falsificar
+	if err := router.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		logger.Fatal("invalid TRUSTED_PROXIES", logger.Err(err))
+	}
+
+	router.Use(middleware.RequestID(baseLogger))
+	router.Use(middleware.RequestLogger())
+	router.Use(middleware.Metrics(application.Metrics))
+	router.Use(middleware.Recovery())
 
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -47,16 +87,74 @@ func main() {
 	})
 
 	// Servir archivos estáticos
-	router.Static("/uploads", "./uploads")
+	router.Static("/uploads", config.Upload.Path)
 
-	routes.SetupRoutes(router)
+	routes.SetupRoutes(router, application.RouteDependencies())
 
 	port := fmt.Sprintf(":%s", config.Server.Port)
-	log.Printf("Server starting on port %s", port)
-	log.Printf("API available at http://localhost%s", port)
-	log.Printf("Health check at http://localhost%s/api/v1/health", port)
+	server := &http.Server{
+		Addr:    port,
+		Handler: router,
+	}
+	// Los streams SSE no terminan solos; se cierran para que Shutdown no espere hasta el deadline
+	server.RegisterOnShutdown(application.Services.Realtime.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		baseLogger.Info("server listening", "addr", port, "liveness", "/healthz", "readiness", "/readyz")
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("failed to start server", logger.Err(err))
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
 
-	if err := router.Run(port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	baseLogger.Info("shutting down, waiting for in-flight requests and workers", "delay", config.Server.ShutdownDelay.String(), "timeout", config.Server.ShutdownTimeout.String())
+	shutdown(server, application, config.Server.ShutdownDelay, config.Server.ShutdownTimeout)
+}
+
+// shutdown deja de reportarse como listo y sigue atendiendo durante delay, para que el balanceador
+// lo saque de rotación antes de cerrar las conexiones. Después espera a que terminen los requests
+// en curso y detiene los workers, todo dentro de timeout. Una segunda señal durante delay corta el
+// proceso, porque el handler de señales ya se liberó
+func shutdown(server *http.Server, application *app.App, delay time.Duration, timeout time.Duration) {
+	application.Health.Drain()
+	time.Sleep(delay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP server did not shut down cleanly", logger.Err(err))
+	}
+
+	if err := application.Stop(shutdownCtx); err != nil {
+		slog.Error("background workers did not stop cleanly", logger.Err(err))
+	}
+
+	if err := database.Close(application.DB); err != nil {
+		slog.Error("failed to close database", logger.Err(err))
 	}
+
+	slog.Info("server stopped")
+}
+
+// seedDevelopmentData carga los datos de prueba la primera vez que se levanta el modo desarrollo
+func seedDevelopmentData(application *app.App) {
+	result, err := seeder.New(application.DB, application.StorageProvider).Run(context.Background(), seeder.DefaultOptions())
+	if err == seeder.ErrNotEmpty {
+		return
+	}
+	if err != nil {
+		logger.Fatal("failed to seed database", logger.Err(err))
+	}
+
+	slog.Info("database seeded", "users", len(result.Users), "pets", len(result.Pets))
+
+	fmt.Fprintln(os.Stderr, "Staff accounts of the seeded database (shown only once):")
+	result.WriteStaffPasswords(os.Stderr)
 }
@@ -0,0 +1,272 @@
+// Package app arma la aplicación: crea el storage, los repositorios, los servicios y los
+// controladores a partir de la configuración y la conexión que recibe, sin estado global.
+// Dos App con configuraciones distintas pueden convivir en el mismo proceso.
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+
+	"go-api-find-my-friend/internal/controllers"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/internal/routes"
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/database"
+	"go-api-find-my-friend/pkg/eventbus"
+	"go-api-find-my-friend/pkg/health"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/metrics"
+	"go-api-find-my-friend/pkg/pubsub"
+	"go-api-find-my-friend/pkg/storage_provider"
+
+	"gorm.io/gorm"
+)
+
+type Repositories struct {
+	Pet                    repositories.PetRepository
+	PetReport              repositories.PetReportRepository
+	User                   repositories.UserRepository
+	Audit                  repositories.AuditRepository
+	RecoveryCode           repositories.RecoveryCodeRepository
+	Conversation           repositories.ConversationRepository
+	Sighting               repositories.SightingRepository
+	NotificationPreference repositories.NotificationPreferenceRepository
+	Webhook                repositories.WebhookRepository
+	Outbox                 repositories.OutboxRepository
+	Job                    repositories.JobRepository
+}
+
+type Services struct {
+	Audit        *services.AuditService
+	User         *services.UserService
+	Job          *services.JobService
+	MFA          *services.MFAService
+	Auth         *services.AuthService
+	Realtime     *services.RealtimeService
+	Notification *services.NotificationService
+	Pet          *services.PetService
+	PetExpiry    *services.PetExpiryService
+	Account      *services.AccountService
+	Messaging    *services.MessagingService
+	Sighting     *services.SightingService
+	Moderation   *services.ModerationService
+	Webhook      *services.WebhookService
+}
+
+type Controllers struct {
+	User         *controllers.UserController
+	Pet          *controllers.PetController
+	Auth         *controllers.AuthController
+	Admin        *controllers.AdminController
+	Conversation *controllers.ConversationController
+	Sighting     *controllers.SightingController
+	Realtime     *controllers.RealtimeController
+	Webhook      *controllers.WebhookController
+	Moderation   *controllers.ModerationController
+	Health       *controllers.HealthController
+	// Metrics es nil si METRICS_ENABLED es false
+	Metrics *controllers.MetricsController
+}
+
+type App struct {
+	Config          *config.Config
+	DB              *gorm.DB
+	StorageProvider storage_provider.StorageProvider
+	Repositories    Repositories
+	Services        Services
+	Controllers     Controllers
+	// Health son las dependencias que verifica /readyz
+	Health *health.Checker
+	// Metrics son las métricas de Prometheus de esta App; el middleware HTTP las recibe de acá
+	Metrics *metrics.Metrics
+
+	bus              *eventbus.Bus
+	outboxDispatcher *services.OutboxDispatcher
+	jobRunner        *services.JobRunner
+}
+
+// New arma la aplicación sobre db con el storage configurado. No arranca ningún proceso en
+// segundo plano; eso lo hace Start
+func New(cfg *config.Config, db *gorm.DB) (*App, error) {
+	storageProvider, err := storage_provider.NewStorageProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithStorage(cfg, db, storageProvider), nil
+}
+
+// NewWithStorage arma la aplicación con un StorageProvider dado, por ejemplo uno en memoria en las pruebas
+func NewWithStorage(cfg *config.Config, db *gorm.DB, storageProvider storage_provider.StorageProvider) *App {
+	appMetrics := metrics.New()
+	storageProvider = metrics.InstrumentStorage(storageProvider, appMetrics)
+
+	// Los repositorios usan la sesión instrumentada, así las consultas se cuentan en las
+	// métricas de esta App aunque otra comparta la conexión
+	instrumented, err := metrics.InstrumentDB(db, appMetrics)
+	if err != nil {
+		slog.Warn("failed to instrument database queries", logger.Err(err))
+	}
+	db = instrumented
+	signals := repositories.NewSignals()
+
+	a := &App{
+		Config:          cfg,
+		DB:              db,
+		StorageProvider: storageProvider,
+		Metrics:         appMetrics,
+		bus:             eventbus.NewBus(),
+	}
+
+	a.Repositories = Repositories{
+		Pet:                    repositories.NewPetRepository(db, storageProvider, cfg.Database.QueryTimeout, appMetrics, signals),
+		PetReport:              repositories.NewPetReportRepository(db, cfg.Database.QueryTimeout),
+		User:                   repositories.NewUserRepository(db, cfg.Database.QueryTimeout, signals),
+		Audit:                  repositories.NewAuditRepository(db, cfg.Database.QueryTimeout),
+		RecoveryCode:           repositories.NewRecoveryCodeRepository(db, cfg.Database.QueryTimeout),
+		Conversation:           repositories.NewConversationRepository(db, cfg.Database.QueryTimeout),
+		Sighting:               repositories.NewSightingRepository(db, cfg.Database.QueryTimeout),
+		NotificationPreference: repositories.NewNotificationPreferenceRepository(db, cfg.Database.QueryTimeout),
+		Webhook:                repositories.NewWebhookRepository(db, cfg.Database.QueryTimeout),
+		Outbox:                 repositories.NewOutboxRepository(db, cfg.Database.QueryTimeout),
+		Job:                    repositories.NewJobRepository(db, cfg.Database.QueryTimeout, signals),
+	}
+	a.Services = newServices(cfg, &a.Repositories, storageProvider)
+	a.Health = newHealthChecker(cfg, db, storageProvider)
+	a.Controllers = newControllers(cfg, &a.Services, a.Health, appMetrics)
+	registerBusinessGauges(appMetrics, &a.Repositories)
+
+	a.outboxDispatcher = services.NewOutboxDispatcher(a.Repositories.Outbox, a.bus, cfg.Outbox, signals.Outbox())
+	services.RegisterEventHandlers(a.bus, a.Services.Realtime, a.Services.Notification, a.Services.Webhook)
+
+	a.jobRunner = services.NewJobRunner(a.Repositories.Job, a.Services.Job, cfg.Jobs, signals.Jobs())
+	services.RegisterJobHandlers(a.jobRunner, services.JobHandlerDependencies{
+		StorageProvider:     storageProvider,
+		NotificationService: a.Services.Notification,
+		PetExpiryService:    a.Services.PetExpiry,
+		JobRepository:       a.Repositories.Job,
+		OutboxRepository:    a.Repositories.Outbox,
+		WebhookRepository:   a.Repositories.Webhook,
+		Retention:           cfg.Jobs.Retention,
+		DeadOutboxRetention: cfg.Outbox.DeadRetention,
+	})
+
+	return a
+}
+
+// newServices crea los servicios en orden de dependencias
+func newServices(cfg *config.Config, repos *Repositories, storageProvider storage_provider.StorageProvider) Services {
+	var s Services
+
+	s.Audit = services.NewAuditService(repos.Audit)
+	loginGuard := services.NewLoginGuard(s.Audit, cfg.Login)
+	s.User = services.NewUserService(repos.User, loginGuard)
+	s.Job = services.NewJobService(repos.Job, cfg.Jobs)
+	s.MFA = services.NewMFAService(repos.User, repos.RecoveryCode, s.Audit, loginGuard)
+	s.Auth = services.NewAuthService(s.User, s.MFA, loginGuard, cfg.JWT)
+	s.Notification = services.NewNotificationService(cfg.Email, repos.User, repos.NotificationPreference, repos.Sighting, repos.Conversation, s.Job)
+	s.Pet = services.NewPetService(repos.Pet, repos.User, s.Audit, cfg.ContactReveal)
+	s.Realtime = services.NewRealtimeService(pubsub.NewMemoryHub(), s.Pet)
+	s.PetExpiry = services.NewPetExpiryService(repos.Pet, s.Pet, s.Notification, cfg.PostExpiry)
+	s.Account = services.NewAccountService(repos.User, repos.Pet, storageProvider, loginGuard)
+	s.Messaging = services.NewMessagingService(repos.Conversation, repos.User, s.Pet, s.Realtime, s.Notification)
+	s.Sighting = services.NewSightingService(repos.Sighting, s.Pet, repos.User, s.Realtime, s.Notification)
+	s.Moderation = services.NewModerationService(repos.PetReport, repos.Pet, repos.User, s.Pet, s.Audit, s.Notification)
+	s.Webhook = services.NewWebhookService(repos.Webhook, cfg.Webhook)
+
+	return s
+}
+
+// newHealthChecker verifica la base, el storage y, si está configurado, Redis
+func newHealthChecker(cfg *config.Config, db *gorm.DB, storageProvider storage_provider.StorageProvider) *health.Checker {
+	checker := health.NewChecker(cfg.Server.HealthCheckTimeout)
+	checker.Add("database", func(ctx context.Context) error {
+		return database.Ping(ctx, db)
+	})
+	checker.Add("storage", storageProvider.Ping)
+	if cfg.Redis.Host != "" {
+		checker.Add("redis", health.RedisPing(net.JoinHostPort(cfg.Redis.Host, cfg.Redis.Port), cfg.Redis.Password))
+	}
+	return checker
+}
+
+// registerBusinessGauges expone gauges que se calculan consultando la base en cada scrape
+func registerBusinessGauges(m *metrics.Metrics, repos *Repositories) {
+	m.RegisterGauge("active_lost_pets", "Published lost or sighted pets by type and province.", []string{"type", "province"},
+		func(ctx context.Context) ([]metrics.GaugeSample, error) {
+			counts, err := repos.Pet.CountActiveLost(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			samples := make([]metrics.GaugeSample, 0, len(counts))
+			for _, count := range counts {
+				samples = append(samples, metrics.GaugeSample{
+					Labels: []string{count.Type, count.Province},
+					Value:  float64(count.Count),
+				})
+			}
+			return samples, nil
+		})
+}
+
+func newControllers(cfg *config.Config, s *Services, checker *health.Checker, m *metrics.Metrics) Controllers {
+	var metricsController *controllers.MetricsController
+	if cfg.Metrics.Enabled {
+		metricsController = controllers.NewMetricsController(m, cfg.Metrics.Token)
+	}
+
+	return Controllers{
+		User:         controllers.NewUserController(s.User, s.Auth, s.Pet, s.Account, s.Notification),
+		Pet:          controllers.NewPetController(s.Pet, s.User),
+		Auth:         controllers.NewAuthController(s.Auth, s.MFA),
+		Admin:        controllers.NewAdminController(s.Audit, s.Job),
+		Conversation: controllers.NewConversationController(s.Messaging),
+		Sighting:     controllers.NewSightingController(s.Sighting),
+		Realtime:     controllers.NewRealtimeController(s.Realtime),
+		Webhook:      controllers.NewWebhookController(s.Webhook),
+		Moderation:   controllers.NewModerationController(s.Moderation),
+		Health:       controllers.NewHealthController(checker),
+		Metrics:      metricsController,
+	}
+}
+
+// RouteDependencies devuelve lo que necesita routes.SetupRoutes
+func (a *App) RouteDependencies() routes.Dependencies {
+	return routes.Dependencies{
+		UserController:         a.Controllers.User,
+		PetController:          a.Controllers.Pet,
+		AuthController:         a.Controllers.Auth,
+		AdminController:        a.Controllers.Admin,
+		ConversationController: a.Controllers.Conversation,
+		SightingController:     a.Controllers.Sighting,
+		RealtimeController:     a.Controllers.Realtime,
+		WebhookController:      a.Controllers.Webhook,
+		ModerationController:   a.Controllers.Moderation,
+		HealthController:       a.Controllers.Health,
+		MetricsController:      a.Controllers.Metrics,
+		JWTSecret:              a.Config.JWT.Secret,
+		Users:                  a.Repositories.User,
+	}
+}
+
+// Start lanza el dispatcher del outbox, los workers de trabajos y el envío de webhooks.
+// Llamarlo más de una vez no tiene efecto.
+func (a *App) Start() {
+	a.outboxDispatcher.Start()
+	a.jobRunner.Start()
+	a.Services.Webhook.Start()
+}
+
+// Stop detiene los procesos que lanzó Start y espera a que terminen el trabajo en curso, como
+// mucho hasta que venza ctx. Lo pendiente queda en la base y se retoma en el próximo arranque
+func (a *App) Stop(ctx context.Context) error {
+	return errors.Join(
+		a.outboxDispatcher.Stop(ctx),
+		a.jobRunner.Stop(ctx),
+		a.Services.Webhook.Stop(ctx),
+	)
+}
@@ -0,0 +1,90 @@
+// Package cli reúne los subcomandos que comparten el servidor y fmfctl
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/database"
+	"go-api-find-my-friend/pkg/logger"
+)
+
+const migrateUsage = `Usage: %s migrate <command> [options]
+
+Commands:
+  up                 apply all pending migrations
+  down [-steps N]    roll back the last N migrations (default 1)
+  status             list migrations and whether they are applied
+  create [-dir DIR] NAME
+                     create an empty migration in DIR (default pkg/database/migrations)
+`
+
+// RunMigrate ejecuta el subcomando migrate; program es el nombre del binario que se muestra en la ayuda
+func RunMigrate(program string, config *config.Config, args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, migrateUsage, program)
+		os.Exit(2)
+	}
+
+	if len(args) == 0 {
+		usage()
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "up":
+		database.CreateDB(config)
+		db := database.Connect(config)
+		if err := database.Migrate(db); err != nil {
+			logger.Fatal("failed to migrate database", logger.Err(err))
+		}
+
+	case "down":
+		flags := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "number of migrations to roll back")
+		flags.Parse(args)
+
+		db := database.Connect(config)
+		if err := database.Rollback(db, *steps); err != nil {
+			logger.Fatal("failed to roll back migrations", logger.Err(err))
+		}
+
+	case "status":
+		db := database.Connect(config)
+		statuses, err := database.Status(db)
+		if err != nil {
+			logger.Fatal("failed to read migration status", logger.Err(err))
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+
+	case "create":
+		flags := flag.NewFlagSet("create", flag.ExitOnError)
+		dir := flags.String("dir", "pkg/database/migrations", "directory of the migrations package")
+		flags.Parse(args)
+		if flags.NArg() != 1 {
+			usage()
+		}
+
+		path, err := database.CreateMigration(*dir, flags.Arg(0))
+		if err != nil {
+			logger.Fatal("failed to create migration", logger.Err(err))
+		}
+		fmt.Println("Created", path)
+
+	default:
+		usage()
+	}
+}
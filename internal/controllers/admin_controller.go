@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInvalidJobID = errors.NewBadRequestError("invalid job ID")
+)
+
+type AdminController struct {
+	auditService *services.AuditService
+	jobService   *services.JobService
+}
+
+func NewAdminController(auditService *services.AuditService, jobService *services.JobService) *AdminController {
+	return &AdminController{
+		auditService: auditService,
+		jobService:   jobService,
+	}
+}
+
+func (c *AdminController) ListAuditEvents(ctx *gin.Context) {
+	var dto AuditEventsQueryDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidQueryParams)
+		return
+	}
+
+	searchParams := pagination.PaginationParams{
+		Page:    dto.Page,
+		Size:    dto.Size,
+		SortDir: dto.SortDir,
+	}
+
+	result, err := c.auditService.Search(ctx.Request.Context(), dto.Action, &searchParams)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *AdminController) ListJobs(ctx *gin.Context) {
+	var dto JobsQueryDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidQueryParams)
+		return
+	}
+
+	searchParams := pagination.PaginationParams{
+		Page:    dto.Page,
+		Size:    dto.Size,
+		SortDir: dto.SortDir,
+	}
+
+	filter := models.Job{
+		Status: dto.Status,
+		Queue:  dto.Queue,
+		Type:   dto.Type,
+	}
+
+	result, err := c.jobService.SearchJobs(ctx.Request.Context(), &filter, &searchParams)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *AdminController) GetJob(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidJobID)
+		return
+	}
+
+	job, err := c.jobService.GetJob(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job)
+}
+
+func (c *AdminController) RetryJob(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidJobID)
+		return
+	}
+
+	job, err := c.jobService.RetryJob(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job)
+}
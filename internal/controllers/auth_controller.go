@@ -15,10 +15,14 @@ var (
 
 type AuthController struct {
 	authService *services.AuthService
+	mfaService  *services.MFAService
 }
 
-func NewAuthController() *AuthController {
-	return &AuthController{authService: services.NewAuthService()}
+func NewAuthController(authService *services.AuthService, mfaService *services.MFAService) *AuthController {
+	return &AuthController{
+		authService: authService,
+		mfaService:  mfaService,
+	}
 }
 
 func (c *AuthController) Login(ctx *gin.Context) {
@@ -29,13 +33,93 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	token, err := c.authService.AuthenticateUser(dto.Email, dto.Password)
-	if err == services.ErrInvalidCredentials {
+	result, err := c.authService.AuthenticateUser(ctx.Request.Context(), dto.Email, dto.Password, ctx.ClientIP())
+	if err != nil {
+		setRetryAfter(ctx, err)
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *AuthController) VerifyMFA(ctx *gin.Context) {
+	var dto services.MFAVerifyDTO
+
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidBody)
+		return
+	}
+
+	result, err := c.authService.VerifyMFA(ctx.Request.Context(), &dto, ctx.ClientIP())
+	if err != nil {
+		setRetryAfter(ctx, err)
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// StreamToken devuelve un token para abrir GET /events/stream con ?access_token=, donde no se
+// acepta el JWT de la sesión
+func (c *AuthController) StreamToken(ctx *gin.Context) {
+	token, err := c.authService.GenerateStreamToken(ctx.GetInt("user_id"), ctx.GetString("email"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errors.NewInternalServerError("Failed to generate token"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"expires_in": int(services.StreamTokenTTL.Seconds()),
+	})
+}
+
+func (c *AuthController) EnrollMFA(ctx *gin.Context) {
+	enrollment, err := c.mfaService.Enroll(ctx.Request.Context(), ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+func (c *AuthController) ConfirmMFA(ctx *gin.Context) {
+	var dto services.MFACodeDTO
+
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidBody)
+		return
+	}
+
+	recoveryCodes, err := c.mfaService.Confirm(ctx.Request.Context(), ctx.GetInt("user_id"), dto.Code, ctx.ClientIP())
+	if err != nil {
+		setRetryAfter(ctx, err)
 		ctx.JSON(getErrStatusCode(err), err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"recovery_codes": recoveryCodes,
 	})
 }
+
+func (c *AuthController) DisableMFA(ctx *gin.Context) {
+	var dto services.MFADisableDTO
+
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidBody)
+		return
+	}
+
+	err := c.mfaService.Disable(ctx.Request.Context(), ctx.GetInt("user_id"), &dto, ctx.ClientIP())
+	if err != nil {
+		setRetryAfter(ctx, err)
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"go-api-find-my-friend/internal/policies"
 	"go-api-find-my-friend/pkg/errors"
 	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
 )
 
 func getErrStatusCode(error error) int {
@@ -12,3 +16,15 @@ func getErrStatusCode(error error) int {
 	}
 	return http.StatusInternalServerError
 }
+
+// setRetryAfter agrega el header Retry-After cuando el error lo informa
+func setRetryAfter(ctx *gin.Context, err error) {
+	appErr, ok := err.(*errors.AppError)
+	if ok && appErr.RetryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(appErr.RetryAfter))
+	}
+}
+
+func getActor(ctx *gin.Context) policies.Actor {
+	return policies.NewActor(ctx.GetInt("user_id"), ctx.GetString("role"))
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInvalidConversationID    = errors.NewBadRequestError("invalid conversation ID")
+	ErrCreateMessageInvalidBody = errors.NewBadRequestError("invalid body")
+)
+
+type ConversationController struct {
+	messagingService *services.MessagingService
+}
+
+func NewConversationController(messagingService *services.MessagingService) *ConversationController {
+	return &ConversationController{
+		messagingService: messagingService,
+	}
+}
+
+func (c *ConversationController) StartConversation(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	// El cuerpo es opcional. Con transfer-encoding chunked ContentLength es -1, así que solo se
+	// saltea el bind cuando no hay cuerpo
+	var dto services.ConversationCreateDTO
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&dto); err != nil && err != io.EOF {
+			ctx.JSON(http.StatusBadRequest, ErrCreateMessageInvalidBody)
+			return
+		}
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	conversation, err := c.messagingService.StartConversation(ctx.Request.Context(), getActor(ctx), petID, &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, conversation)
+}
+
+func (c *ConversationController) ListConversations(ctx *gin.Context) {
+	conversations, err := c.messagingService.ListConversations(ctx.Request.Context(), getActor(ctx))
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, conversations)
+}
+
+func (c *ConversationController) GetConversation(ctx *gin.Context) {
+	conversationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidConversationID)
+		return
+	}
+
+	conversation, err := c.messagingService.GetConversation(ctx.Request.Context(), getActor(ctx), conversationID)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, conversation)
+}
+
+func (c *ConversationController) ListMessages(ctx *gin.Context) {
+	conversationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidConversationID)
+		return
+	}
+
+	var dto PaginationQueryDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidQueryParams)
+		return
+	}
+
+	searchParams := pagination.PaginationParams{
+		Page:    dto.Page,
+		Size:    dto.Size,
+		SortDir: dto.SortDir,
+	}
+
+	result, err := c.messagingService.ListMessages(ctx.Request.Context(), getActor(ctx), conversationID, &searchParams)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *ConversationController) SendMessage(ctx *gin.Context) {
+	conversationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidConversationID)
+		return
+	}
+
+	var dto services.MessageCreateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrCreateMessageInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	message, err := c.messagingService.SendMessage(ctx.Request.Context(), getActor(ctx), conversationID, &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, message)
+}
+
+func (c *ConversationController) Block(ctx *gin.Context) {
+	conversationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidConversationID)
+		return
+	}
+
+	err = c.messagingService.Block(ctx.Request.Context(), getActor(ctx), conversationID)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *ConversationController) Unblock(ctx *gin.Context) {
+	conversationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidConversationID)
+		return
+	}
+
+	err = c.messagingService.Unblock(ctx.Request.Context(), getActor(ctx), conversationID)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *ConversationController) ShareContact(ctx *gin.Context) {
+	conversationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidConversationID)
+		return
+	}
+
+	conversation, err := c.messagingService.ShareContact(ctx.Request.Context(), getActor(ctx), conversationID)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, conversation)
+}
@@ -17,8 +17,6 @@ type PetDetailDTO struct {
 	OwnerID       int       `json:"owner_id"`
 	OwnerName     string    `json:"owner_name"`
 	OwnerLastName string    `json:"owner_last_name"`
-	OwnerEmail    string    `json:"owner_email"`
-	OwnerPhone    string    `json:"owner_phone"`
 	Name          string    `json:"name"`
 	Description   string    `json:"description"`
 	Type          string    `json:"type"`
@@ -26,9 +24,15 @@ type PetDetailDTO struct {
 	LastSeenTime  time.Time `json:"last_seen_time"`
 	LastSeenPlace string    `json:"last_seen_place"`
 	PictureURL    string    `json:"picture_url"`
+	Status        string    `json:"status"`
 	IsFound       bool      `json:"is_found"`
-	CanEdit       bool      `json:"can_edit"`
-	CanDelete     bool      `json:"can_delete"`
+	// ContactPrivacy indica si el contacto se obtiene con contact-reveal o solo por mensajes
+	ContactPrivacy string     `json:"contact_privacy"`
+	FoundAt        *time.Time `json:"found_at,omitempty"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty"`
+	HiddenAt       *time.Time `json:"hidden_at,omitempty"`
+	CanEdit        bool       `json:"can_edit"`
+	CanDelete      bool       `json:"can_delete"`
 }
 
 type SearchPetsPaginationDTO struct {
@@ -38,6 +42,54 @@ type SearchPetsPaginationDTO struct {
 	Type          string `json:"type" form:"type"`
 	Breed         string `json:"breed" form:"breed"`
 	LastSeenPlace string `json:"last_seen_place" form:"last_seen_place"`
+	Status        string `json:"status" form:"status"`
+	// IncludeArchived incluye las publicaciones vencidas y las encontradas hace tiempo
+	IncludeArchived bool `json:"include_archived" form:"include_archived"`
+}
+
+type EventStreamQueryDTO struct {
+	PetIDs        []int  `form:"pet_id"`
+	Type          string `form:"type"`
+	Breed         string `form:"breed"`
+	LastSeenPlace string `form:"last_seen_place"`
+}
+
+type PaginationQueryDTO struct {
+	Page    int    `json:"page" form:"page"`
+	Size    int    `json:"size" form:"size"`
+	SortDir string `json:"sort_dir" form:"sort_dir"`
+}
+
+type WebhookDeliveriesQueryDTO struct {
+	Page    int    `json:"page" form:"page"`
+	Size    int    `json:"size" form:"size"`
+	SortDir string `json:"sort_dir" form:"sort_dir"`
+	Status  string `json:"status" form:"status"`
+}
+
+type JobsQueryDTO struct {
+	Page    int    `json:"page" form:"page"`
+	Size    int    `json:"size" form:"size"`
+	SortDir string `json:"sort_dir" form:"sort_dir"`
+	Status  string `json:"status" form:"status"`
+	Queue   string `json:"queue" form:"queue"`
+	Type    string `json:"type" form:"type"`
+}
+
+type AuditEventsQueryDTO struct {
+	Page    int    `json:"page" form:"page"`
+	Size    int    `json:"size" form:"size"`
+	SortDir string `json:"sort_dir" form:"sort_dir"`
+	Action  string `json:"action" form:"action"`
+}
+
+type ReportsQueryDTO struct {
+	Page    int    `json:"page" form:"page"`
+	Size    int    `json:"size" form:"size"`
+	SortDir string `json:"sort_dir" form:"sort_dir"`
+	Status  string `json:"status" form:"status"`
+	Reason  string `json:"reason" form:"reason"`
+	PetID   int    `json:"pet_id" form:"pet_id"`
 }
 
 type UserCreateResponse struct {
@@ -45,6 +97,16 @@ type UserCreateResponse struct {
 	LastName string `json:"last_name" binding:"required"`
 }
 
+type UserProfileDTO struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type UserLoginDTO struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
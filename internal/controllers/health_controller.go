@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"go-api-find-my-friend/pkg/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{
+		checker: checker,
+	}
+}
+
+// Liveness solo indica que el proceso responde; no verifica dependencias para que una caída
+// de la base no haga reiniciar el contenedor
+func (c *HealthController) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readiness responde 503 si alguna dependencia falla o si el servidor se está apagando
+func (c *HealthController) Readiness(ctx *gin.Context) {
+	report := c.checker.Run(ctx.Request.Context())
+	if !report.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsController struct {
+	handler http.Handler
+	token   string
+}
+
+// NewMetricsController sirve las métricas de m; si token no está vacío exige Authorization: Bearer <token>
+func NewMetricsController(m *metrics.Metrics, token string) *MetricsController {
+	return &MetricsController{
+		handler: m.Handler(),
+		token:   token,
+	}
+}
+
+func (c *MetricsController) Metrics(ctx *gin.Context) {
+	if c.token != "" {
+		token, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(c.token)) != 1 {
+			ctx.JSON(http.StatusUnauthorized, errors.NewUnauthorizedError("Invalid metrics token"))
+			return
+		}
+	}
+
+	c.handler.ServeHTTP(ctx.Writer, ctx.Request)
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInvalidReportID         = errors.NewBadRequestError("invalid report ID")
+	ErrReportInvalidBody       = errors.NewBadRequestError("invalid body")
+	ErrModerationInvalidBody   = errors.NewBadRequestError("invalid body")
+	ErrModerationInvalidUserID = errors.NewBadRequestError("invalid user ID")
+)
+
+type ModerationController struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationController(moderationService *services.ModerationService) *ModerationController {
+	return &ModerationController{
+		moderationService: moderationService,
+	}
+}
+
+func (c *ModerationController) ReportPet(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	var dto services.PetReportCreateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrReportInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	report, err := c.moderationService.ReportPet(ctx.Request.Context(), getActor(ctx), petID, &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, report)
+}
+
+func (c *ModerationController) ListReports(ctx *gin.Context) {
+	var dto ReportsQueryDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidQueryParams)
+		return
+	}
+
+	searchParams := pagination.PaginationParams{
+		Page:    dto.Page,
+		Size:    dto.Size,
+		SortDir: dto.SortDir,
+	}
+
+	status := dto.Status
+	if status == "" {
+		status = models.ReportOpen
+	}
+
+	filter := models.PetReport{
+		Status: status,
+		Reason: dto.Reason,
+		PetID:  dto.PetID,
+	}
+
+	result, err := c.moderationService.ListReports(ctx.Request.Context(), &filter, &searchParams)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *ModerationController) DismissReport(ctx *gin.Context) {
+	reportID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidReportID)
+		return
+	}
+
+	dto, ok := bindModerationAction(ctx, false)
+	if !ok {
+		return
+	}
+
+	err = c.moderationService.DismissReport(ctx.Request.Context(), getActor(ctx), reportID, dto.Reason)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *ModerationController) HidePet(ctx *gin.Context) {
+	c.petAction(ctx, true, c.moderationService.HidePet)
+}
+
+func (c *ModerationController) RestorePet(ctx *gin.Context) {
+	c.petAction(ctx, false, c.moderationService.RestorePet)
+}
+
+func (c *ModerationController) RemovePet(ctx *gin.Context) {
+	c.petAction(ctx, true, c.moderationService.RemovePet)
+}
+
+func (c *ModerationController) WarnUser(ctx *gin.Context) {
+	c.userAction(ctx, true, c.moderationService.WarnUser)
+}
+
+func (c *ModerationController) BanUser(ctx *gin.Context) {
+	c.userAction(ctx, true, c.moderationService.BanUser)
+}
+
+func (c *ModerationController) UnbanUser(ctx *gin.Context) {
+	c.userAction(ctx, false, c.moderationService.UnbanUser)
+}
+
+type moderationAction func(ctx context.Context, actor policies.Actor, id int, reason string) error
+
+func (c *ModerationController) petAction(ctx *gin.Context, reasonRequired bool, action moderationAction) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	c.runAction(ctx, petID, reasonRequired, action)
+}
+
+func (c *ModerationController) userAction(ctx *gin.Context, reasonRequired bool, action moderationAction) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrModerationInvalidUserID)
+		return
+	}
+
+	c.runAction(ctx, userID, reasonRequired, action)
+}
+
+func (c *ModerationController) runAction(ctx *gin.Context, id int, reasonRequired bool, action moderationAction) {
+	dto, ok := bindModerationAction(ctx, reasonRequired)
+	if !ok {
+		return
+	}
+
+	err := action(ctx.Request.Context(), getActor(ctx), id, dto.Reason)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// bindModerationAction lee el motivo de la acción; cuando no es obligatorio el cuerpo puede omitirse
+func bindModerationAction(ctx *gin.Context, reasonRequired bool) (*services.ModerationActionDTO, bool) {
+	var dto services.ModerationActionDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, ErrModerationInvalidBody)
+		return nil, false
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs, reasonRequired)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return nil, false
+	}
+
+	return &dto, true
+}
@@ -3,8 +3,8 @@ package controllers
 import (
 	"net/http"
 	"strconv"
-	"sync"
 
+	"go-api-find-my-friend/internal/policies"
 	"go-api-find-my-friend/internal/services"
 	"go-api-find-my-friend/pkg/errors"
 	"go-api-find-my-friend/pkg/pagination"
@@ -12,16 +12,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-var (
-	petControllerInstance *PetController
-	petControllerOnce     sync.Once
-)
-
 var (
 	ErrInvalidPetID         = errors.NewBadRequestError("invalid pet ID")
 	ErrCreatePetInvalidBody = errors.NewBadRequestError("invalid body")
 	ErrInvalidQueryParams   = errors.NewBadRequestError("invalid query params")
 	ErrUpdatePetInvalidBody = errors.NewBadRequestError("invalid body")
+	ErrPetStatusInvalidBody = errors.NewBadRequestError("invalid body")
 )
 
 type PetController struct {
@@ -29,14 +25,11 @@ type PetController struct {
 	userService *services.UserService
 }
 
-func NewPetController() *PetController {
-	petControllerOnce.Do(func() {
-		petControllerInstance = &PetController{
-			petService:  services.NewPetService(),
-			userService: services.NewUserService(),
-		}
-	})
-	return petControllerInstance
+func NewPetController(petService *services.PetService, userService *services.UserService) *PetController {
+	return &PetController{
+		petService:  petService,
+		userService: userService,
+	}
 }
 
 func (c *PetController) CreatePet(ctx *gin.Context) {
@@ -55,7 +48,7 @@ func (c *PetController) CreatePet(ctx *gin.Context) {
 	}
 
 	userID, _ := ctx.Get("user_id")
-	pet, err := c.petService.CreatePet(&dto, userID.(int))
+	pet, err := c.petService.CreatePet(ctx.Request.Context(), &dto, userID.(int))
 	if err != nil {
 		ctx.JSON(getErrStatusCode(err), err)
 		return
@@ -99,8 +92,19 @@ func (c *PetController) SearchPets(ctx *gin.Context) {
 	if dto.LastSeenPlace != "" {
 		filterParams.LastSeenPlace = &dto.LastSeenPlace
 	}
+	if dto.Status != "" {
+		filterParams.Status = &dto.Status
+	}
+	filterParams.IncludeArchived = dto.IncludeArchived
+
+	actor := getActor(ctx)
+	if actor.IsModerator() {
+		filterParams.IncludeHidden = true
+	} else {
+		filterParams.ViewerID = &actor.UserID
+	}
 
-	pagination, err := c.petService.SearchPets(&filterParams, &searchParams)
+	pagination, err := c.petService.SearchPets(ctx.Request.Context(), &filterParams, &searchParams)
 	if err != nil {
 		ctx.JSON(getErrStatusCode(err), err)
 		return
@@ -116,33 +120,35 @@ func (c *PetController) GetPet(ctx *gin.Context) {
 		return
 	}
 
-	pet, err := c.petService.GetPetByID(petID)
+	actor := getActor(ctx)
+
+	pet, err := c.petService.GetVisiblePet(ctx.Request.Context(), actor, petID)
 	if err != nil {
 		ctx.JSON(getErrStatusCode(err), err)
 		return
 	}
 
-	userID, _ := ctx.Get("user_id")
-	isOwner := pet.UserID == userID
-
 	ctx.JSON(http.StatusOK,
 		PetDetailDTO{
-			PetID:         pet.ID,
-			OwnerID:       pet.UserID,
-			OwnerName:     pet.User.Name,
-			OwnerLastName: pet.User.LastName,
-			OwnerEmail:    pet.User.Email,
-			OwnerPhone:    pet.User.Phone,
-			Name:          pet.Name,
-			Description:   pet.Description,
-			Type:          pet.Type,
-			Breed:         pet.Breed,
-			LastSeenTime:  pet.LastSeenTime,
-			LastSeenPlace: pet.LastSeenPlace,
-			PictureURL:    pet.PictureURL,
-			IsFound:       pet.IsFound,
-			CanEdit:       isOwner,
-			CanDelete:     isOwner,
+			PetID:          pet.ID,
+			OwnerID:        pet.UserID,
+			OwnerName:      pet.User.Name,
+			OwnerLastName:  pet.User.LastName,
+			Name:           pet.Name,
+			Description:    pet.Description,
+			Type:           pet.Type,
+			Breed:          pet.Breed,
+			LastSeenTime:   pet.LastSeenTime,
+			LastSeenPlace:  pet.LastSeenPlace,
+			PictureURL:     pet.PictureURL,
+			Status:         pet.Status,
+			IsFound:        pet.IsFound,
+			ContactPrivacy: pet.ContactPrivacy,
+			FoundAt:        pet.FoundAt,
+			ArchivedAt:     pet.ArchivedAt,
+			HiddenAt:       pet.HiddenAt,
+			CanEdit:        policies.CanEditPet(actor, pet),
+			CanDelete:      policies.CanDeletePet(actor, pet),
 		},
 	)
 }
@@ -167,8 +173,7 @@ func (c *PetController) UpdatePet(ctx *gin.Context) {
 		return
 	}
 
-	userID, _ := ctx.Get("user_id")
-	err = c.petService.UpdatePet(userID.(int), petID, &dto)
+	err = c.petService.UpdatePet(ctx.Request.Context(), getActor(ctx), petID, &dto)
 	if err != nil {
 		ctx.JSON(getErrStatusCode(err), err)
 		return
@@ -184,8 +189,68 @@ func (c *PetController) UpdatePetMarkAsFound(ctx *gin.Context) {
 		return
 	}
 
-	userID, _ := ctx.Get("user_id")
-	err = c.petService.UpdatePetAsFound(userID.(int), petID)
+	err = c.petService.UpdatePetAsFound(ctx.Request.Context(), getActor(ctx), petID)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *PetController) ChangeStatus(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	var dto services.PetStatusUpdateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrPetStatusInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	err = c.petService.ChangeStatus(ctx.Request.Context(), getActor(ctx), petID, &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *PetController) GetStatusHistory(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	history, err := c.petService.GetStatusHistory(ctx.Request.Context(), getActor(ctx), petID)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+func (c *PetController) ReactivatePet(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	err = c.petService.ReactivatePet(ctx.Request.Context(), getActor(ctx), petID)
 	if err != nil {
 		ctx.JSON(getErrStatusCode(err), err)
 		return
@@ -201,8 +266,7 @@ func (c *PetController) DeletePet(ctx *gin.Context) {
 		return
 	}
 
-	userID, _ := ctx.Get("user_id")
-	err = c.petService.DeletePet(userID.(int), petID)
+	err = c.petService.DeletePet(ctx.Request.Context(), getActor(ctx), petID)
 	if err != nil {
 		ctx.JSON(getErrStatusCode(err), err)
 		return
@@ -210,3 +274,20 @@ func (c *PetController) DeletePet(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+func (c *PetController) RevealContact(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	contact, err := c.petService.RevealContact(ctx.Request.Context(), getActor(ctx), petID, ctx.ClientIP())
+	if err != nil {
+		setRetryAfter(ctx, err)
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, contact)
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+const streamHeartbeatInterval = 25 * time.Second
+
+type RealtimeController struct {
+	realtimeService *services.RealtimeService
+}
+
+func NewRealtimeController(realtimeService *services.RealtimeService) *RealtimeController {
+	return &RealtimeController{
+		realtimeService: realtimeService,
+	}
+}
+
+// Stream mantiene abierta una conexión Server-Sent Events con los eventos del usuario
+func (c *RealtimeController) Stream(ctx *gin.Context) {
+	var dto EventStreamQueryDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidQueryParams)
+		return
+	}
+
+	filter := pagination.FilterPet{}
+	if dto.Type != "" {
+		filter.Type = &dto.Type
+	}
+	if dto.Breed != "" {
+		filter.Breed = &dto.Breed
+	}
+	if dto.LastSeenPlace != "" {
+		filter.LastSeenPlace = &dto.LastSeenPlace
+	}
+
+	subscription, err := c.realtimeService.Subscribe(ctx.Request.Context(), getActor(ctx), dto.PetIDs, &filter)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+	defer subscription.Close()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			ctx.SSEvent("heartbeat", gin.H{"time": time.Now()})
+			return true
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			if c.realtimeService.Matches(event, &filter) {
+				ctx.SSEvent(event.Type, event)
+			}
+			return true
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrCreateSightingInvalidBody = errors.NewBadRequestError("invalid body")
+)
+
+type SightingController struct {
+	sightingService *services.SightingService
+}
+
+func NewSightingController(sightingService *services.SightingService) *SightingController {
+	return &SightingController{
+		sightingService: sightingService,
+	}
+}
+
+func (c *SightingController) CreateSighting(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	var dto services.SightingCreateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrCreateSightingInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	sighting, err := c.sightingService.CreateSighting(ctx.Request.Context(), getActor(ctx), petID, &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, sighting)
+}
+
+func (c *SightingController) ListSightings(ctx *gin.Context) {
+	petID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidPetID)
+		return
+	}
+
+	sightings, err := c.sightingService.ListSightings(ctx.Request.Context(), getActor(ctx), petID)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sightings)
+}
@@ -1,37 +1,51 @@
 package controllers
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
-	"sync"
+	"strconv"
 
+	"go-api-find-my-friend/internal/models"
 	"go-api-find-my-friend/internal/services"
 	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
 
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	ErrCreateUserInvalidBody = errors.NewBadRequestError("invalid user body")
-)
-
-var (
-	userControllerInstance *UserController
-	userControllerOnce     sync.Once
+	ErrCreateUserInvalidBody    = errors.NewBadRequestError("invalid user body")
+	ErrInvalidUserID            = errors.NewBadRequestError("invalid user ID")
+	ErrUpdateRoleInvalidBody    = errors.NewBadRequestError("invalid body")
+	ErrUpdateUserInvalidBody    = errors.NewBadRequestError("invalid body")
+	ErrPasswordInvalidBody      = errors.NewBadRequestError("invalid body")
+	ErrDeleteUserInvalidBody    = errors.NewBadRequestError("password confirmation is required")
+	ErrNotificationsInvalidBody = errors.NewBadRequestError("invalid body")
 )
 
 type UserController struct {
-	userService *services.UserService
-	authService *services.AuthService
+	userService         *services.UserService
+	authService         *services.AuthService
+	petService          *services.PetService
+	accountService      *services.AccountService
+	notificationService *services.NotificationService
 }
 
-func NewUserController() *UserController {
-	userControllerOnce.Do(func() {
-		userControllerInstance = &UserController{
-			userService: services.NewUserService(),
-			authService: services.NewAuthService(),
-		}
-	})
-	return userControllerInstance
+func NewUserController(
+	userService *services.UserService,
+	authService *services.AuthService,
+	petService *services.PetService,
+	accountService *services.AccountService,
+	notificationService *services.NotificationService,
+) *UserController {
+	return &UserController{
+		userService:         userService,
+		authService:         authService,
+		petService:          petService,
+		accountService:      accountService,
+		notificationService: notificationService,
+	}
 }
 
 func (c *UserController) Register(ctx *gin.Context) {
@@ -42,13 +56,20 @@ func (c *UserController) Register(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.userService.CreateUser(&dto)
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	user, err := c.userService.CreateUser(ctx.Request.Context(), &dto)
 	if err != nil {
 		ctx.JSON(getErrStatusCode(err), err)
 		return
 	}
 
-	token, _ := c.authService.GenerateToken(user.ID, user.Email)
+	token, _ := c.authService.GenerateToken(user.ID, user.Email, user.Role)
 
 	ctx.JSON(http.StatusCreated, gin.H{
 		"user": UserCreateResponse{
@@ -58,3 +79,201 @@ func (c *UserController) Register(ctx *gin.Context) {
 		"auth_token": token,
 	})
 }
+
+func (c *UserController) UpdateRole(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidUserID)
+		return
+	}
+
+	var dto services.UserRoleUpdateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrUpdateRoleInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	err = c.userService.UpdateRole(ctx.Request.Context(), getActor(ctx), userID, dto.Role)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *UserController) GetMe(ctx *gin.Context) {
+	user, err := c.userService.GetByID(ctx.Request.Context(), ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserProfileDTO(user))
+}
+
+func (c *UserController) UpdateMe(ctx *gin.Context) {
+	var dto services.UserUpdateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrUpdateUserInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	user, err := c.userService.UpdateProfile(ctx.Request.Context(), ctx.GetInt("user_id"), &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserProfileDTO(user))
+}
+
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	var dto services.UserPasswordUpdateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrPasswordInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	err := c.userService.ChangePassword(ctx.Request.Context(), ctx.GetInt("user_id"), &dto, ctx.ClientIP())
+	if err != nil {
+		setRetryAfter(ctx, err)
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	// El token con el que se hizo el cambio ya no vale; se devuelve uno nuevo para seguir en sesión
+	token, err := c.authService.GenerateToken(ctx.GetInt("user_id"), ctx.GetString("email"), ctx.GetString("role"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errors.NewInternalServerError("Failed to generate token"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+func (c *UserController) GetNotificationPreferences(ctx *gin.Context) {
+	preferences, err := c.notificationService.GetPreferences(ctx.Request.Context(), ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, preferences)
+}
+
+func (c *UserController) UpdateNotificationPreferences(ctx *gin.Context) {
+	var dto services.NotificationPreferenceUpdateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrNotificationsInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	preferences, err := c.notificationService.UpdatePreferences(ctx.Request.Context(), ctx.GetInt("user_id"), &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, preferences)
+}
+
+func (c *UserController) GetMyPets(ctx *gin.Context) {
+	var dto SearchPetsPaginationDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidQueryParams)
+		return
+	}
+
+	searchParams := pagination.PaginationParams{
+		Page:    dto.Page,
+		Size:    dto.Size,
+		SortDir: dto.SortDir,
+	}
+
+	userID := ctx.GetInt("user_id")
+	filterParams := pagination.FilterPet{
+		UserID:          &userID,
+		IncludeArchived: true,
+	}
+
+	result, err := c.petService.SearchPets(ctx.Request.Context(), &filterParams, &searchParams)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *UserController) DeleteMe(ctx *gin.Context) {
+	var dto services.UserDeleteDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrDeleteUserInvalidBody)
+		return
+	}
+
+	err := c.accountService.DeleteAccount(ctx.Request.Context(), ctx.GetInt("user_id"), &dto, ctx.ClientIP())
+	if err != nil {
+		setRetryAfter(ctx, err)
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *UserController) ExportMe(ctx *gin.Context) {
+	userID := ctx.GetInt("user_id")
+
+	var buffer bytes.Buffer
+	err := c.accountService.ExportData(ctx.Request.Context(), userID, &buffer)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	filename := fmt.Sprintf("find-my-friend-export-%d.zip", userID)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, "application/zip", buffer.Bytes())
+}
+
+func newUserProfileDTO(user *models.User) UserProfileDTO {
+	return UserProfileDTO{
+		ID:        user.ID,
+		Name:      user.Name,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Phone:     user.Phone,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
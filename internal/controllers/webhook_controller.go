@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-api-find-my-friend/internal/services"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInvalidWebhookID         = errors.NewBadRequestError("invalid webhook ID")
+	ErrInvalidWebhookDeliveryID = errors.NewBadRequestError("invalid webhook delivery ID")
+	ErrWebhookInvalidBody       = errors.NewBadRequestError("invalid webhook body")
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	var dto services.WebhookCreateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrWebhookInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	webhook, err := c.webhookService.CreateWebhook(ctx.Request.Context(), ctx.GetInt("user_id"), &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, webhook)
+}
+
+func (c *WebhookController) ListWebhooks(ctx *gin.Context) {
+	webhooks, err := c.webhookService.ListWebhooks(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidWebhookID)
+		return
+	}
+
+	webhook, err := c.webhookService.GetWebhook(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidWebhookID)
+		return
+	}
+
+	var dto services.WebhookUpdateDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrWebhookInvalidBody)
+		return
+	}
+
+	errs := map[string]string{}
+	passed := dto.Validate(&errs)
+	if !passed {
+		ctx.JSON(http.StatusBadRequest, errs)
+		return
+	}
+
+	webhook, err := c.webhookService.UpdateWebhook(ctx.Request.Context(), id, &dto)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidWebhookID)
+		return
+	}
+
+	if err := c.webhookService.DeleteWebhook(ctx.Request.Context(), id); err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (c *WebhookController) ListDeliveries(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidWebhookID)
+		return
+	}
+
+	var dto WebhookDeliveriesQueryDTO
+	if err := ctx.ShouldBindQuery(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidQueryParams)
+		return
+	}
+
+	searchParams := pagination.PaginationParams{
+		Page:    dto.Page,
+		Size:    dto.Size,
+		SortDir: dto.SortDir,
+	}
+
+	result, err := c.webhookService.ListDeliveries(ctx.Request.Context(), id, dto.Status, &searchParams)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidWebhookDeliveryID)
+		return
+	}
+
+	delivery, err := c.webhookService.Redeliver(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+func (c *WebhookController) TestWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrInvalidWebhookID)
+		return
+	}
+
+	delivery, err := c.webhookService.Test(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(getErrStatusCode(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
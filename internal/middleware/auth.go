@@ -1,25 +1,56 @@
 package middleware
 
 import (
-	"go-api-find-my-friend/pkg/config"
+	"context"
+	"go-api-find-my-friend/internal/models"
 	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/logger"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// streamTokenPurpose es el Purpose de los tokens de services.AuthService.GenerateStreamToken
+const streamTokenPurpose = "stream"
+
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
+	UserID  int    `json:"user_id"`
+	Email   string `json:"email"`
+	Role    string `json:"role"`
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// UserLookup busca al usuario del token en cada request. El rol y la suspensión salen de la base
+// y no del token, así un cambio de rol o un ban tienen efecto inmediato y no cuando el token vence
+type UserLookup interface {
+	GetByID(ctx context.Context, id int) (*models.User, error)
+}
+
+// AuthMiddleware valida el token JWT del header Authorization firmado con secret
+func AuthMiddleware(secret string, users UserLookup) gin.HandlerFunc {
+	return authMiddleware(secret, users, false)
+}
+
+// QueryTokenAuthMiddleware también acepta en ?access_token= un token de stream, para clientes
+// como EventSource que no pueden enviar el header Authorization. El JWT de la sesión no se acepta
+// en la URL, que queda en los logs del proxy
+func QueryTokenAuthMiddleware(secret string, users UserLookup) gin.HandlerFunc {
+	return authMiddleware(secret, users, true)
+}
+
+func authMiddleware(secret string, users UserLookup, allowQueryToken bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		JWTSecret := config.ConfigInstance.JWT.Secret
 		authHeader := c.GetHeader("Authorization")
+		purpose := ""
+		if authHeader == "" && allowQueryToken && c.Query("access_token") != "" {
+			authHeader = "Bearer " + c.Query("access_token")
+			purpose = streamTokenPurpose
+		}
+
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, errors.NewUnauthorizedError("Authorization header is required"))
 			c.Abort()
@@ -38,7 +69,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, jwt.ErrSignatureInvalid
 			}
-			return []byte(JWTSecret), nil
+			return []byte(secret), nil
 		})
 
 		if err != nil {
@@ -54,14 +85,42 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		claims, ok := token.Claims.(*Claims)
-		if !ok {
+		if !ok || claims.Purpose != purpose {
 			c.JSON(http.StatusUnauthorized, errors.NewUnauthorizedError("Invalid token claims"))
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
+		user, err := users.GetByID(c.Request.Context(), claims.UserID)
+		if err != nil {
+			if appErr, ok := err.(*errors.AppError); ok && appErr.Code == http.StatusNotFound {
+				c.JSON(http.StatusUnauthorized, errors.NewUnauthorizedError("Invalid token"))
+			} else {
+				c.JSON(http.StatusInternalServerError, errors.NewInternalServerError("Failed to validate session"))
+			}
+			c.Abort()
+			return
+		}
+
+		// Cambiar la contraseña cierra las sesiones anteriores. iat tiene precisión de segundos, así
+		// que se compara contra el segundo del cambio para no rechazar el token emitido junto con él
+		if user.PasswordChangedAt != nil && (claims.IssuedAt == nil || claims.IssuedAt.Time.Before(user.PasswordChangedAt.Truncate(time.Second))) {
+			c.JSON(http.StatusUnauthorized, errors.NewUnauthorizedError("Session expired, log in again"))
+			c.Abort()
+			return
+		}
+
+		// La suspensión también se consulta en cada request: cierra las sesiones que ya estaban abiertas
+		if user.IsBanned() {
+			c.JSON(http.StatusForbidden, errors.NewForbiddenError("Your account has been suspended"))
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", user.ID)
+		c.Set("email", user.Email)
+		c.Set("role", user.Role)
+		c.Request = c.Request.WithContext(logger.With(c.Request.Context(), "user_id", user.ID))
 
 		c.Next()
 	}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+type testUsers map[int]*models.User
+
+func (u testUsers) GetByID(_ context.Context, id int) (*models.User, error) {
+	user, ok := u[id]
+	if !ok {
+		return nil, errors.NewNotFoundError("user not found")
+	}
+	return user, nil
+}
+
+func signTestToken(t *testing.T, userID int, purpose string, issuedAt time.Time) string {
+	claims := &Claims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now()
+	changedAt := now.Add(-time.Hour)
+	bannedAt := now.Add(-time.Hour)
+	users := testUsers{
+		1: {ID: 1, Email: "ana@example.com", Role: models.RoleUser},
+		2: {ID: 2, Email: "luis@example.com", Role: models.RoleUser, PasswordChangedAt: &changedAt},
+		3: {ID: 3, Email: "eva@example.com", Role: models.RoleUser, BannedAt: &bannedAt},
+	}
+
+	router := gin.New()
+	router.GET("/protected", AuthMiddleware(testSecret, users), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/stream", QueryTokenAuthMiddleware(testSecret, users), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	session := signTestToken(t, 1, "", now)
+	stream := signTestToken(t, 1, streamTokenPurpose, now)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"session token in the header", "/protected", session, http.StatusOK},
+		{"missing token", "/protected", "", http.StatusUnauthorized},
+		{"token signed with another secret", "/protected", "invalid.token.value", http.StatusUnauthorized},
+		{"mfa_pending token", "/protected", signTestToken(t, 1, "mfa_pending", now), http.StatusUnauthorized},
+		{"stream token in the header", "/protected", stream, http.StatusUnauthorized},
+		{"unknown user", "/protected", signTestToken(t, 99, "", now), http.StatusUnauthorized},
+		{"token issued before the password change", "/protected", signTestToken(t, 2, "", changedAt.Add(-time.Minute)), http.StatusUnauthorized},
+		{"token issued after the password change", "/protected", signTestToken(t, 2, "", now), http.StatusOK},
+		{"banned user", "/protected", signTestToken(t, 3, "", now), http.StatusForbidden},
+		{"session token in the header of the stream", "/stream", session, http.StatusOK},
+		{"stream token in the query", "/stream?access_token=" + stream, "", http.StatusOK},
+		{"session token in the query", "/stream?access_token=" + session, "", http.StatusUnauthorized},
+		{"mfa_pending token in the query", "/stream?access_token=" + signTestToken(t, 1, "mfa_pending", now), "", http.StatusUnauthorized},
+		{"query token on a route that does not accept it", "/protected?access_token=" + stream, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				request.Header.Set("Authorization", "Bearer "+tt.header)
+			}
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+		})
+	}
+}
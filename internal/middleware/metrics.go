@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"time"
+
+	"go-api-find-my-friend/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics cuenta los requests por ruta y status y mide su latencia. Como RequestLogger, usa la
+// ruta de Gin y no la URL para que cada ID no genere una serie nueva
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.RequestStarted()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.RequestFinished(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
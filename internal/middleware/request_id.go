@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"go-api-find-my-friend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID toma el X-Request-ID que manda el cliente o el proxy, o genera uno, lo devuelve en
+// la respuesta y deja en el contexto del request un logger derivado de base con ese request_id
+func RequestID(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		requestLogger := base.With("request_id", requestID)
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), requestLogger))
+
+		c.Next()
+	}
+}
+
+// validRequestID descarta IDs vacíos, demasiado largos o con caracteres que ensucien los logs
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, char := range requestID {
+		if char < 0x21 || char > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger reemplaza a gin.Logger: registra cada request con el logger del contexto, así
+// queda con su request_id. Se registra la ruta y no la URL completa porque el query string puede
+// llevar el JWT (?access_token=)
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		ctx := c.Request.Context()
+		logger.FromContext(ctx).Log(ctx, level, "request",
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", status,
+			logger.Duration(time.Since(start)),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// Recovery reemplaza a gin.Recovery: registra el panic con el stack en el logger del request
+// y responde 500 con el formato de error de la API
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.FromContext(c.Request.Context()).Error("panic recovered",
+					"panic", fmt.Sprint(recovered),
+					"stack", string(debug.Stack()),
+				)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, errors.NewInternalServerError("Internal server error"))
+			}
+		}()
+
+		c.Next()
+	}
+}
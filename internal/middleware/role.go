@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"go-api-find-my-friend/pkg/errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole debe usarse después de AuthMiddleware
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if !slices.Contains(roles, role) {
+			c.JSON(http.StatusForbidden, errors.NewForbiddenError("You don't have permission to access this resource"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
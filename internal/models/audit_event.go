@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	AuditActionLoginLockout  = "auth.lockout"
+	AuditActionMFAEnabled    = "auth.mfa_enabled"
+	AuditActionMFADisabled   = "auth.mfa_disabled"
+	AuditActionRecoveryUsed  = "auth.recovery_code_used"
+	AuditActionContactReveal = "pet.contact_reveal"
+	AuditActionPetHidden     = "moderation.pet_hidden"
+	AuditActionPetRestored   = "moderation.pet_restored"
+	AuditActionPetRemoved    = "moderation.pet_removed"
+	AuditActionReportDismiss = "moderation.report_dismissed"
+	AuditActionUserWarned    = "moderation.user_warned"
+	AuditActionUserBanned    = "moderation.user_banned"
+	AuditActionUserUnbanned  = "moderation.user_unbanned"
+	AuditActionAdminCreated  = "ops.admin_created"
+	AuditActionPasswordReset = "ops.password_reset"
+	AuditActionImagesPurged  = "ops.images_purged"
+)
+
+type AuditEvent struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Action    string    `json:"action" gorm:"not null;index"`
+	ActorID   *int      `json:"actor_id" gorm:"index"`
+	Subject   string    `json:"subject"`
+	IP        string    `json:"ip"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
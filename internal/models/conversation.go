@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// Conversation es el hilo entre el dueño de una mascota y quien la encontró o la vio.
+// Owner y Finder no generan FK para evitar múltiples caminos de cascada con pets.
+type Conversation struct {
+	ID                  int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	PetID               int        `json:"pet_id" gorm:"type:int;not null;uniqueIndex:idx_conversation_pet_finder"`
+	Pet                 Pet        `json:"-" gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	OwnerID             int        `json:"owner_id" gorm:"type:int;not null;index"`
+	Owner               User       `json:"-" gorm:"foreignKey:OwnerID;constraint:-"`
+	FinderID            int        `json:"finder_id" gorm:"type:int;not null;uniqueIndex:idx_conversation_pet_finder"`
+	Finder              User       `json:"-" gorm:"foreignKey:FinderID;constraint:-"`
+	OwnerSharesContact  bool       `json:"owner_shares_contact" gorm:"default:false"`
+	FinderSharesContact bool       `json:"finder_shares_contact" gorm:"default:false"`
+	OwnerLastReadAt     *time.Time `json:"owner_last_read_at"`
+	FinderLastReadAt    *time.Time `json:"finder_last_read_at"`
+	BlockedByID         *int       `json:"blocked_by_id"`
+	LastMessageAt       *time.Time `json:"last_message_at"`
+	Messages            []Message  `json:"-" gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type Message struct {
+	ID             int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ConversationID int       `json:"conversation_id" gorm:"type:int;not null;index"`
+	SenderID       int       `json:"sender_id" gorm:"type:int;not null"`
+	Body           string    `json:"body" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (c *Conversation) IsParticipant(userID int) bool {
+	return c.OwnerID == userID || c.FinderID == userID
+}
+
+func (c *Conversation) IsBlocked() bool {
+	return c.BlockedByID != nil
+}
+
+// ContactShared es verdadero cuando ambos participantes aceptaron compartir sus datos
+func (c *Conversation) ContactShared() bool {
+	return c.OwnerSharesContact && c.FinderSharesContact
+}
@@ -1,6 +1,29 @@
 package models
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+const (
+	ContactShowPhone = "show_phone"
+	ContactShowEmail = "show_email"
+	ContactRelayOnly = "relay_only"
+)
+
+const (
+	LanguageES = "es"
+	LanguageEN = "en"
+)
+
 var (
+	UserRoles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+	ContactPrivacyOptions = []string{ContactShowPhone, ContactShowEmail, ContactRelayOnly}
+
+	Languages = []string{LanguageES, LanguageEN}
+
 	PetTypes = []string{"perro", "gato", "otro"}
 
 	PetBreeds = map[string][]string{
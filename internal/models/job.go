@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+const (
+	JobQueueDefault = "default"
+	JobQueueEmails  = "emails"
+	JobQueueImages  = "images"
+)
+
+const (
+	JobTypeDeletePicture            = "storage.delete_picture"
+	JobTypeSendEmail                = "email.send"
+	JobTypeCleanupOutbox            = "cleanup.outbox"
+	JobTypeCleanupJobs              = "cleanup.jobs"
+	JobTypeCleanupWebhookDeliveries = "cleanup.webhook_deliveries"
+	JobTypeExpirePets               = "pets.expire"
+)
+
+var JobStatuses = []string{JobPending, JobRunning, JobSucceeded, JobDead}
+
+// Job es una tarea en segundo plano. Los trabajos que agotan sus intentos pasan a
+// estado dead y quedan ahí hasta que un administrador los reintente.
+type Job struct {
+	ID          int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Queue       string     `json:"queue" gorm:"not null;index:idx_job_due"`
+	Type        string     `json:"type" gorm:"not null;index"`
+	Payload     string     `json:"payload" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null;index:idx_job_due"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null"`
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index:idx_job_due"`
+	LockedUntil *time.Time `json:"locked_until"`
+	LockedBy    string     `json:"locked_by"`
+	LastError   string     `json:"last_error"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// EmailJobUsers son los campos del payload de email.send que identifican a los usuarios que aparecen
+// en el mensaje: el destinatario y, en los avisos de mensajes y avistamientos, quien los originó
+type EmailJobUsers struct {
+	RecipientID int `json:"recipient_id"`
+	SenderID    int `json:"sender_id,omitempty"`
+}
+
+// DeletePicturePayload es el payload del trabajo que borra una foto del storage
+type DeletePicturePayload struct {
+	PictureURL string `json:"picture_url"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationPreference guarda qué emails quiere recibir el usuario y en qué idioma
+type NotificationPreference struct {
+	UserID           int       `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	User             User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Language         string    `json:"language" gorm:"type:varchar(5);not null"`
+	PetCreated       bool      `json:"pet_created" gorm:"not null"`
+	SightingReceived bool      `json:"sighting_received" gorm:"not null"`
+	MessageReceived  bool      `json:"message_received" gorm:"not null"`
+	PetFound         bool      `json:"pet_found" gorm:"not null"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationPreference son las preferencias de quien todavía no las configuró
+func DefaultNotificationPreference(userID int) NotificationPreference {
+	return NotificationPreference{
+		UserID:           userID,
+		Language:         LanguageES,
+		PetCreated:       true,
+		SightingReceived: true,
+		MessageReceived:  true,
+		PetFound:         true,
+	}
+}
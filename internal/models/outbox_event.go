@@ -0,0 +1,140 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EventPetCreated     = "PetCreated"
+	EventPetUpdated     = "PetUpdated"
+	EventPetFound       = "PetFound"
+	EventPetDeleted     = "PetDeleted"
+	EventUserRegistered = "UserRegistered"
+
+	AggregatePet  = "pet"
+	AggregateUser = "user"
+)
+
+const (
+	OutboxPending    = "pending"
+	OutboxDispatched = "dispatched"
+	OutboxDead       = "dead"
+)
+
+// OutboxEvent es un evento de dominio guardado en la misma transacción que el cambio
+// que lo origina. El dispatcher lo toma (locked_by/locked_until), lo publica en el bus y luego
+// lo marca como despachado. CompletedHandlers guarda, separados por coma, los handlers que ya
+// lo procesaron, para que un reintento no vuelva a ejecutarlos. Los eventos que agotan sus
+// intentos pasan a dead y dejan de bloquear a los siguientes de su agregado.
+type OutboxEvent struct {
+	ID                int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	AggregateType     string     `json:"aggregate_type" gorm:"not null;index:idx_outbox_aggregate"`
+	AggregateID       int        `json:"aggregate_id" gorm:"not null;index:idx_outbox_aggregate"`
+	EventType         string     `json:"event_type" gorm:"not null"`
+	Payload           string     `json:"payload" gorm:"not null"`
+	Status            string     `json:"status" gorm:"not null;default:pending;index"`
+	Attempts          int        `json:"attempts" gorm:"not null;default:0"`
+	CompletedHandlers string     `json:"completed_handlers"`
+	LastError         string     `json:"last_error"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at"`
+	LockedBy          string     `json:"locked_by"`
+	LockedUntil       *time.Time `json:"locked_until"`
+	DispatchedAt      *time.Time `json:"dispatched_at" gorm:"index"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// PetEventData es la foto de la mascota que viaja en los eventos. No incluye los datos del dueño.
+// Si la publicación está moderada solo lleva el ID, el dueño y el estado, para que los webhooks
+// se enteren de que ya no es pública sin recibir el contenido que se ocultó.
+type PetEventData struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Type           string     `json:"type"`
+	Breed          string     `json:"breed"`
+	LastSeenTime   time.Time  `json:"last_seen_time"`
+	LastSeenPlace  string     `json:"last_seen_place"`
+	Status         string     `json:"status"`
+	IsFound        bool       `json:"is_found"`
+	PictureURL     string     `json:"picture_url"`
+	ContactPrivacy string     `json:"contact_privacy"`
+	FoundAt        *time.Time `json:"found_at,omitempty"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Moderated      bool       `json:"moderated,omitempty"`
+}
+
+func NewPetEventData(pet *Pet) PetEventData {
+	if pet.IsModerated() {
+		return PetEventData{
+			ID:        pet.ID,
+			UserID:    pet.UserID,
+			Status:    pet.Status,
+			CreatedAt: pet.CreatedAt,
+			UpdatedAt: pet.UpdatedAt,
+			Moderated: true,
+		}
+	}
+
+	return PetEventData{
+		ID:             pet.ID,
+		UserID:         pet.UserID,
+		Name:           pet.Name,
+		Description:    pet.Description,
+		Type:           pet.Type,
+		Breed:          pet.Breed,
+		LastSeenTime:   pet.LastSeenTime,
+		LastSeenPlace:  pet.LastSeenPlace,
+		Status:         pet.Status,
+		IsFound:        pet.IsFound,
+		PictureURL:     pet.PictureURL,
+		ContactPrivacy: pet.ContactPrivacy,
+		FoundAt:        pet.FoundAt,
+		ArchivedAt:     pet.ArchivedAt,
+		CreatedAt:      pet.CreatedAt,
+		UpdatedAt:      pet.UpdatedAt,
+	}
+}
+
+func (d PetEventData) Pet() Pet {
+	return Pet{
+		ID:             d.ID,
+		UserID:         d.UserID,
+		Name:           d.Name,
+		Description:    d.Description,
+		Type:           d.Type,
+		Breed:          d.Breed,
+		LastSeenTime:   d.LastSeenTime,
+		LastSeenPlace:  d.LastSeenPlace,
+		Status:         d.Status,
+		IsFound:        d.IsFound,
+		PictureURL:     d.PictureURL,
+		ContactPrivacy: d.ContactPrivacy,
+		FoundAt:        d.FoundAt,
+		ArchivedAt:     d.ArchivedAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+type UserEventData struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewUserEventData(user *User) UserEventData {
+	return UserEventData{
+		ID:        user.ID,
+		Name:      user.Name,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
@@ -14,27 +14,64 @@ type Pet struct {
 	User          User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 	LastSeenTime  time.Time `json:"last_seen_time" gorm:"not null"`
 	LastSeenPlace string    `json:"last_seen_place" gorm:"not null"`
-	IsFound       bool      `json:"is_found" gorm:"default:false"`
-	PictureURL    string    `json:"picture_url"`
-	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	// Status es el estado de la publicación; IsFound se mantiene sincronizado con él
+	Status     string `json:"status" gorm:"not null;default:'lost';index"`
+	IsFound    bool   `json:"is_found" gorm:"default:false"`
+	PictureURL string `json:"picture_url"`
+	// ContactPrivacy define qué dato de contacto del dueño puede revelarse
+	ContactPrivacy string `json:"contact_privacy" gorm:"not null;default:'show_phone'"`
+	// RenewedAt es la última vez que el dueño confirmó que la mascota sigue perdida;
+	// si es nulo la antigüedad se cuenta desde CreatedAt
+	RenewedAt      *time.Time `json:"renewed_at"`
+	ReminderSentAt *time.Time `json:"reminder_sent_at"`
+	FoundAt        *time.Time `json:"found_at"`
+	// ArchivedAt marca las publicaciones archivadas, que no aparecen en las búsquedas
+	ArchivedAt *time.Time `json:"archived_at" gorm:"index"`
+	// HiddenAt marca las publicaciones ocultadas por moderación, visibles solo para el dueño y los moderadores
+	HiddenAt     *time.Time `json:"hidden_at" gorm:"index"`
+	HiddenReason string     `json:"hidden_reason"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (p *Pet) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
+func (p *Pet) IsHidden() bool {
+	return p.HiddenAt != nil
+}
+
+// IsModerated indica que la publicación fue ocultada o dada de baja y no debe mostrarse al público
+func (p *Pet) IsModerated() bool {
+	return p.IsHidden() || p.Status == PetStatusRemoved
 }
 
 type PetSearchResult struct {
-	ID            int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name          string    `json:"name" gorm:"not null"`
-	Description   string    `json:"description" gorm:"not null;default:''"`
-	Type          string    `json:"type" gorm:"not null"`
-	Breed         string    `json:"breed"`
-	UserID        int       `json:"user_id" gorm:"type:int;not null"`
-	LastSeenTime  time.Time `json:"last_seen_time" gorm:"not null"`
-	LastSeenPlace string    `json:"last_seen_place" gorm:"not null"`
-	IsFound       bool      `json:"is_found" gorm:"default:false"`
-	PictureURL    string    `json:"picture_url"`
-	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID            int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name          string     `json:"name" gorm:"not null"`
+	Description   string     `json:"description" gorm:"not null;default:''"`
+	Type          string     `json:"type" gorm:"not null"`
+	Breed         string     `json:"breed"`
+	UserID        int        `json:"user_id" gorm:"type:int;not null"`
+	LastSeenTime  time.Time  `json:"last_seen_time" gorm:"not null"`
+	LastSeenPlace string     `json:"last_seen_place" gorm:"not null"`
+	Status        string     `json:"status"`
+	IsFound       bool       `json:"is_found" gorm:"default:false"`
+	PictureURL    string     `json:"picture_url"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
+	HiddenAt      *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 func (PetSearchResult) TableName() string {
 	return "pets"
 }
+
+// LostPetCount es la cantidad de publicaciones activas de mascotas perdidas de un tipo en una provincia
+type LostPetCount struct {
+	Type     string
+	Province string
+	Count    int64
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReportReasonFake       = "fake"
+	ReportReasonOffensive  = "offensive"
+	ReportReasonCommercial = "commercial"
+	ReportReasonSpam       = "spam"
+	ReportReasonDuplicate  = "duplicate"
+	ReportReasonOther      = "other"
+)
+
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+var (
+	ReportReasons  = []string{ReportReasonFake, ReportReasonOffensive, ReportReasonCommercial, ReportReasonSpam, ReportReasonDuplicate, ReportReasonOther}
+	ReportStatuses = []string{ReportOpen, ReportResolved, ReportDismissed}
+)
+
+// PetReport es la denuncia de un usuario sobre una publicación. Queda abierta en la cola de
+// moderación hasta que un moderador actúa sobre la publicación o la descarta.
+type PetReport struct {
+	ID           int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	PetID        int        `json:"pet_id" gorm:"type:int;not null;index"`
+	Pet          Pet        `json:"-" gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	ReporterID   int        `json:"reporter_id" gorm:"type:int;not null;index"`
+	Reporter     User       `json:"-" gorm:"foreignKey:ReporterID;constraint:-"`
+	Reason       string     `json:"reason" gorm:"not null"`
+	Details      string     `json:"details"`
+	Status       string     `json:"status" gorm:"not null;index"`
+	Resolution   string     `json:"resolution"`
+	ResolvedByID *int       `json:"resolved_by_id" gorm:"type:int"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+}
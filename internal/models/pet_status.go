@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	PetStatusLost     = "lost"
+	PetStatusSighted  = "sighted"
+	PetStatusFound    = "found"
+	PetStatusReunited = "reunited"
+	PetStatusArchived = "archived"
+	PetStatusRemoved  = "removed"
+)
+
+var PetStatuses = []string{PetStatusLost, PetStatusSighted, PetStatusFound, PetStatusReunited, PetStatusArchived, PetStatusRemoved}
+
+// petStatusTransitions indica a qué estados se puede pasar desde cada uno. Una publicación
+// dada de baja (removed) no vuelve a cambiar de estado.
+var petStatusTransitions = map[string][]string{
+	PetStatusLost:     {PetStatusSighted, PetStatusFound, PetStatusArchived, PetStatusRemoved},
+	PetStatusSighted:  {PetStatusLost, PetStatusFound, PetStatusArchived, PetStatusRemoved},
+	PetStatusFound:    {PetStatusLost, PetStatusReunited, PetStatusArchived, PetStatusRemoved},
+	PetStatusReunited: {PetStatusArchived, PetStatusRemoved},
+	PetStatusArchived: {PetStatusLost, PetStatusRemoved},
+	PetStatusRemoved:  {},
+}
+
+func CanTransitionPetStatus(from string, to string) bool {
+	for _, status := range petStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFoundStatus indica si el estado corresponde a una mascota que ya apareció
+func IsFoundStatus(status string) bool {
+	return status == PetStatusFound || status == PetStatusReunited
+}
+
+// IsActiveStatus indica si la mascota sigue buscándose
+func IsActiveStatus(status string) bool {
+	return status == PetStatusLost || status == PetStatusSighted
+}
+
+// PetStatusChange registra cada cambio de estado de una mascota. ChangedByID es nulo
+// cuando el cambio lo hizo el sistema, por ejemplo al archivar publicaciones vencidas.
+type PetStatusChange struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	PetID       int       `json:"pet_id" gorm:"type:int;not null;index"`
+	Pet         Pet       `json:"-" gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	FromStatus  string    `json:"from_status" gorm:"not null"`
+	ToStatus    string    `json:"to_status" gorm:"not null"`
+	ChangedByID *int      `json:"changed_by_id" gorm:"type:int;index"`
+	ChangedBy   *User     `json:"-" gorm:"foreignKey:ChangedByID;constraint:-"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "testing"
+
+func TestCanTransitionPetStatus(t *testing.T) {
+	allowed := map[string][]string{
+		PetStatusLost:     {PetStatusSighted, PetStatusFound, PetStatusArchived, PetStatusRemoved},
+		PetStatusSighted:  {PetStatusLost, PetStatusFound, PetStatusArchived, PetStatusRemoved},
+		PetStatusFound:    {PetStatusLost, PetStatusReunited, PetStatusArchived, PetStatusRemoved},
+		PetStatusReunited: {PetStatusArchived, PetStatusRemoved},
+		PetStatusArchived: {PetStatusLost, PetStatusRemoved},
+		PetStatusRemoved:  {},
+	}
+
+	// Se recorren todos los pares, así un cambio en petStatusTransitions que no esté en esta
+	// tabla hace fallar la prueba
+	for _, from := range PetStatuses {
+		for _, to := range PetStatuses {
+			want := false
+			for _, status := range allowed[from] {
+				if status == to {
+					want = true
+				}
+			}
+
+			t.Run(from+"->"+to, func(t *testing.T) {
+				if got := CanTransitionPetStatus(from, to); got != want {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			})
+		}
+	}
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{"unknown source", "missing", PetStatusLost},
+		{"unknown target", PetStatusLost, "missing"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if CanTransitionPetStatus(tt.from, tt.to) {
+				t.Errorf("%q -> %q is allowed", tt.from, tt.to)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+type RecoveryCode struct {
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    int        `json:"user_id" gorm:"type:int;not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
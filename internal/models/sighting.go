@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// Sighting es el aviso de alguien que vio a la mascota publicada
+type Sighting struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	PetID      int       `json:"pet_id" gorm:"type:int;not null;index"`
+	Pet        Pet       `json:"-" gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	ReporterID int       `json:"reporter_id" gorm:"type:int;not null;index"`
+	Reporter   User      `json:"-" gorm:"foreignKey:ReporterID;constraint:-"`
+	SeenAt     time.Time `json:"seen_at" gorm:"not null"`
+	SeenPlace  string    `json:"seen_place" gorm:"not null"`
+	Notes      string    `json:"notes"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -2,17 +2,33 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name      string     `json:"name" gorm:"not null"`
-	LastName  string     `json:"last_name" gorm:"not null"`
-	Email     string     `json:"email" gorm:"unique;not null"`
-	Password  string     `json:"-" gorm:"not null"`
-	Phone     string     `json:"phone"`
-	Pets      []Pet      `json:"pets,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+	ID           int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name         string `json:"name" gorm:"not null"`
+	LastName     string `json:"last_name" gorm:"not null"`
+	Email        string `json:"email" gorm:"unique;not null"`
+	Password     string `json:"-" gorm:"not null"`
+	Phone        string `json:"phone"`
+	Role         string `json:"role" gorm:"not null;default:'user'"`
+	TOTPSecret   string `json:"-"`
+	TOTPEnabled  bool   `json:"totp_enabled" gorm:"default:false"`
+	TOTPLastStep int64  `json:"-" gorm:"not null;default:0"`
+	// PasswordChangedAt invalida los tokens emitidos antes del último cambio de contraseña
+	PasswordChangedAt *time.Time     `json:"-"`
+	Warnings          int            `json:"warnings" gorm:"not null;default:0"`
+	BannedAt          *time.Time     `json:"banned_at,omitempty"`
+	BanReason         string         `json:"-"`
+	Pets              []Pet          `json:"pets,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// IsBanned indica si la moderación suspendió la cuenta: no puede iniciar sesión ni publicar
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
 }
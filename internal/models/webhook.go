@@ -0,0 +1,74 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	WebhookEventPetCreated = "pet.created"
+	WebhookEventPetUpdated = "pet.updated"
+	WebhookEventPetFound   = "pet.found"
+	WebhookEventPetDeleted = "pet.deleted"
+	WebhookEventPing       = "ping"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+var WebhookEventTypes = []string{WebhookEventPetCreated, WebhookEventPetUpdated, WebhookEventPetFound, WebhookEventPetDeleted}
+
+// WebhookSubscription es un endpoint externo (refugios, bot de Telegram) que recibe
+// los eventos de mascotas firmados con su secreto
+type WebhookSubscription struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	URL         string    `json:"url" gorm:"not null"`
+	Secret      string    `json:"-" gorm:"not null"`
+	Events      string    `json:"-" gorm:"not null"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active" gorm:"not null"`
+	CreatedByID int       `json:"created_by_id" gorm:"type:int;not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// EventList devuelve los tipos de evento suscriptos, que se guardan separados por coma
+func (s *WebhookSubscription) EventList() []string {
+	if s.Events == "" {
+		return []string{}
+	}
+	return strings.Split(s.Events, ",")
+}
+
+func (s *WebhookSubscription) SetEvents(events []string) {
+	s.Events = strings.Join(events, ",")
+}
+
+func (s *WebhookSubscription) Listens(eventType string) bool {
+	return slices.Contains(s.EventList(), eventType)
+}
+
+// WebhookDelivery registra cada envío de un evento a una suscripción y sus reintentos.
+// Redelivery es 0 para el envío original y cuenta los reenvíos manuales; el índice único
+// sobre suscripción, evento y Redelivery evita que un evento se encole dos veces
+type WebhookDelivery struct {
+	ID             int                 `json:"id" gorm:"primaryKey;autoIncrement"`
+	SubscriptionID int                 `json:"subscription_id" gorm:"type:int;not null;index;uniqueIndex:idx_webhook_delivery_event"`
+	Subscription   WebhookSubscription `json:"-" gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+	EventID        string              `json:"event_id" gorm:"not null;index;uniqueIndex:idx_webhook_delivery_event"`
+	Redelivery     int                 `json:"redelivery" gorm:"not null;default:0;uniqueIndex:idx_webhook_delivery_event"`
+	EventType      string              `json:"event_type" gorm:"not null"`
+	Payload        string              `json:"payload" gorm:"not null"`
+	Status         string              `json:"status" gorm:"not null;index:idx_webhook_delivery_due"`
+	Attempts       int                 `json:"attempts" gorm:"not null"`
+	ResponseStatus int                 `json:"response_status"`
+	Error          string              `json:"error"`
+	NextAttemptAt  *time.Time          `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due"`
+	DeliveredAt    *time.Time          `json:"delivered_at"`
+	CreatedAt      time.Time           `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt      time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+}
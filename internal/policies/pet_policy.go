@@ -0,0 +1,75 @@
+package policies
+
+import (
+	"go-api-find-my-friend/internal/models"
+)
+
+// Actor identifica a quien realiza una acción y con qué rol
+type Actor struct {
+	UserID int
+	Role   string
+}
+
+func NewActor(userID int, role string) Actor {
+	if role == "" {
+		role = models.RoleUser
+	}
+	return Actor{UserID: userID, Role: role}
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == models.RoleAdmin
+}
+
+// IsModerator es verdadero también para administradores
+func (a Actor) IsModerator() bool {
+	return a.Role == models.RoleModerator || a.IsAdmin()
+}
+
+func (a Actor) Owns(pet *models.Pet) bool {
+	return pet != nil && pet.UserID == a.UserID
+}
+
+func CanEditPet(actor Actor, pet *models.Pet) bool {
+	return actor.Owns(pet) || actor.IsModerator()
+}
+
+func CanDeletePet(actor Actor, pet *models.Pet) bool {
+	return actor.Owns(pet) || actor.IsModerator()
+}
+
+// CanChangePetStatus permite al dueño manejar el ciclo de vida de su publicación; darla de baja
+// (removed) queda reservado a la moderación
+func CanChangePetStatus(actor Actor, pet *models.Pet, status string) bool {
+	if status == models.PetStatusRemoved {
+		return CanHidePet(actor, pet)
+	}
+	return CanEditPet(actor, pet)
+}
+
+func CanHidePet(actor Actor, pet *models.Pet) bool {
+	return pet != nil && actor.IsModerator()
+}
+
+// CanViewPet oculta las publicaciones ocultas o dadas de baja a todos salvo al dueño y a la moderación
+func CanViewPet(actor Actor, pet *models.Pet) bool {
+	if pet == nil {
+		return false
+	}
+	if pet.IsModerated() {
+		return actor.Owns(pet) || actor.IsModerator()
+	}
+	return true
+}
+
+// CanModerateUser impide moderarse a uno mismo; solo un administrador puede sancionar a otro moderador
+func CanModerateUser(actor Actor, user *models.User) bool {
+	if user == nil || !actor.IsModerator() || user.ID == actor.UserID {
+		return false
+	}
+	return actor.IsAdmin() || user.Role == models.RoleUser
+}
+
+func CanChangeRoles(actor Actor) bool {
+	return actor.IsAdmin()
+}
@@ -0,0 +1,136 @@
+package policies
+
+import (
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+)
+
+var (
+	owner     = NewActor(1, models.RoleUser)
+	other     = NewActor(2, models.RoleUser)
+	moderator = NewActor(3, models.RoleModerator)
+	admin     = NewActor(4, models.RoleAdmin)
+)
+
+func testPet(status string, hidden bool) *models.Pet {
+	pet := &models.Pet{ID: 10, UserID: owner.UserID, Status: status}
+	if hidden {
+		hiddenAt := time.Now()
+		pet.HiddenAt = &hiddenAt
+	}
+	return pet
+}
+
+func TestCanEditPet(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor Actor
+		want  bool
+	}{
+		{"owner", owner, true},
+		{"another user", other, false},
+		{"moderator", moderator, true},
+		{"admin", admin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanEditPet(tt.actor, testPet(models.PetStatusLost, false)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanHidePet(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor Actor
+		pet   *models.Pet
+		want  bool
+	}{
+		{"owner", owner, testPet(models.PetStatusLost, false), false},
+		{"another user", other, testPet(models.PetStatusLost, false), false},
+		{"moderator", moderator, testPet(models.PetStatusLost, false), true},
+		{"admin", admin, testPet(models.PetStatusLost, false), true},
+		{"missing pet", admin, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanHidePet(tt.actor, tt.pet); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanViewPet(t *testing.T) {
+	visible := testPet(models.PetStatusLost, false)
+	hidden := testPet(models.PetStatusLost, true)
+	removed := testPet(models.PetStatusRemoved, false)
+
+	tests := []struct {
+		name  string
+		actor Actor
+		pet   *models.Pet
+		want  bool
+	}{
+		{"owner, visible", owner, visible, true},
+		{"another user, visible", other, visible, true},
+		{"moderator, visible", moderator, visible, true},
+		{"admin, visible", admin, visible, true},
+		{"owner, hidden", owner, hidden, true},
+		{"another user, hidden", other, hidden, false},
+		{"moderator, hidden", moderator, hidden, true},
+		{"admin, hidden", admin, hidden, true},
+		{"owner, removed", owner, removed, true},
+		{"another user, removed", other, removed, false},
+		{"moderator, removed", moderator, removed, true},
+		{"admin, removed", admin, removed, true},
+		{"missing pet", admin, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanViewPet(tt.actor, tt.pet); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanModerateUser(t *testing.T) {
+	target := func(actor Actor) *models.User {
+		return &models.User{ID: actor.UserID, Role: actor.Role}
+	}
+
+	tests := []struct {
+		name   string
+		actor  Actor
+		target *models.User
+		want   bool
+	}{
+		{"user on another user", owner, target(other), false},
+		{"user on a moderator", owner, target(moderator), false},
+		{"moderator on a user", moderator, target(owner), true},
+		{"moderator on itself", moderator, target(moderator), false},
+		{"moderator on another moderator", moderator, &models.User{ID: 5, Role: models.RoleModerator}, false},
+		{"moderator on an admin", moderator, target(admin), false},
+		{"admin on a user", admin, target(owner), true},
+		{"admin on a moderator", admin, target(moderator), true},
+		{"admin on itself", admin, target(admin), false},
+		{"admin on another admin", admin, &models.User{ID: 6, Role: models.RoleAdmin}, true},
+		{"missing user", admin, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanModerateUser(tt.actor, tt.target); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
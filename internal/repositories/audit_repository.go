@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewAuditRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *AuditRepositorySQLServer {
+	return &AuditRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *AuditRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+func (r *AuditRepositorySQLServer) Create(ctx context.Context, event *models.AuditEvent) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(event).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to create audit event")
+	}
+	return nil
+}
+
+func (r *AuditRepositorySQLServer) Search(ctx context.Context, action string, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	query := db.Model(&models.AuditEvent{})
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.NewInternalServerError("Failed to search audit events")
+	}
+
+	events := make([]models.AuditEvent, 0, search.Size)
+	err := query.Order("created_at " + search.SortDir).
+		Offset(pagination.CalculateOffset(search.Page, search.Size)).
+		Limit(search.Size).
+		Find(&events).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to search audit events")
+	}
+
+	result := pagination.CreatePaginationResult(&events, total, *search)
+	return &result, nil
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ConversationRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewConversationRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *ConversationRepositorySQLServer {
+	return &ConversationRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *ConversationRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+func (r *ConversationRepositorySQLServer) Create(ctx context.Context, conversation *models.Conversation) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(conversation).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to create conversation")
+	}
+	return nil
+}
+
+func (r *ConversationRepositorySQLServer) GetByID(ctx context.Context, id int) (*models.Conversation, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var conversation models.Conversation
+
+	err := withParticipants(db).Where("id = ?", id).First(&conversation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("conversation with id %d not found", id))
+		}
+		return nil, errors.NewInternalServerError("An error occurred while getting conversation from database")
+	}
+
+	return &conversation, nil
+}
+
+func (r *ConversationRepositorySQLServer) GetByPetAndFinder(ctx context.Context, petID int, finderID int) (*models.Conversation, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var conversation models.Conversation
+
+	err := withParticipants(db).Where("pet_id = ? AND finder_id = ?", petID, finderID).First(&conversation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("conversation not found")
+		}
+		return nil, errors.NewInternalServerError("An error occurred while getting conversation from database")
+	}
+
+	return &conversation, nil
+}
+
+func (r *ConversationRepositorySQLServer) ListByParticipant(ctx context.Context, userID int) ([]models.Conversation, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var conversations []models.Conversation
+
+	err := withParticipants(db).
+		Where("owner_id = ? OR finder_id = ?", userID, userID).
+		Order("COALESCE(last_message_at, created_at) DESC").
+		Find(&conversations).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list conversations")
+	}
+
+	return conversations, nil
+}
+
+func (r *ConversationRepositorySQLServer) ListByPetID(ctx context.Context, petID int) ([]models.Conversation, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var conversations []models.Conversation
+
+	err := withParticipants(db).Where("pet_id = ?", petID).Find(&conversations).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list conversations")
+	}
+
+	return conversations, nil
+}
+
+func (r *ConversationRepositorySQLServer) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.Conversation{}).Where("id = ?", id).Updates(updates).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to update conversation")
+	}
+	return nil
+}
+
+// CreateMessage guarda el mensaje y actualiza la fecha del último mensaje en la misma transacción
+func (r *ConversationRepositorySQLServer) CreateMessage(ctx context.Context, message *models.Message) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Conversation{}).
+			Where("id = ?", message.ConversationID).
+			Update("last_message_at", message.CreatedAt).Error
+	})
+	if err != nil {
+		return errors.NewInternalServerError("Failed to send message")
+	}
+	return nil
+}
+
+func (r *ConversationRepositorySQLServer) ListMessages(ctx context.Context, conversationID int, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	query := db.Model(&models.Message{}).Where("conversation_id = ?", conversationID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.NewInternalServerError("Failed to list messages")
+	}
+
+	messages := make([]models.Message, 0, search.Size)
+	err := query.Order("created_at " + search.SortDir).
+		Order("id " + search.SortDir).
+		Offset(pagination.CalculateOffset(search.Page, search.Size)).
+		Limit(search.Size).
+		Find(&messages).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list messages")
+	}
+
+	result := pagination.CreatePaginationResult(&messages, total, *search)
+	return &result, nil
+}
+
+// CountUnread cuenta los mensajes recibidos por userID posteriores a su última lectura
+func (r *ConversationRepositorySQLServer) CountUnread(ctx context.Context, conversationID int, userID int, lastReadAt *time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	query := db.Model(&models.Message{}).
+		Where("conversation_id = ? AND sender_id <> ?", conversationID, userID)
+	if lastReadAt != nil {
+		query = query.Where("created_at > ?", *lastReadAt)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, errors.NewInternalServerError("Failed to count unread messages")
+	}
+	return count, nil
+}
+
+func withParticipants(db *gorm.DB) *gorm.DB {
+	return db.Preload("Pet").Preload("Owner").Preload("Finder")
+}
@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const userJobsBatchSize = 500
+
+// enqueueJob agrega el trabajo dentro de la transacción tx, para que solo exista si el cambio se confirma
+func enqueueJob(tx *gorm.DB, queue string, jobType string, payload interface{}, maxAttempts int) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.Job{
+		Queue:       queue,
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.JobPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       time.Now(),
+	}).Error
+}
+
+// deleteUserEmailJobs borra dentro de tx los emails, en cualquier estado, dirigidos al usuario o
+// originados por él, porque su payload lleva su nombre y el texto de sus mensajes
+func deleteUserEmailJobs(tx *gorm.DB, userID int) error {
+	var ids []int64
+	var jobs []models.Job
+
+	err := tx.Select("id", "payload").Where("type = ?", models.JobTypeSendEmail).
+		FindInBatches(&jobs, userJobsBatchSize, func(batch *gorm.DB, _ int) error {
+			for _, job := range jobs {
+				var users models.EmailJobUsers
+				if err := json.Unmarshal([]byte(job.Payload), &users); err != nil {
+					continue
+				}
+				if users.RecipientID == userID || users.SenderID == userID {
+					ids = append(ids, job.ID)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	// SQL Server admite hasta 2100 parámetros por consulta
+	for start := 0; start < len(ids); start += userJobsBatchSize {
+		end := min(start+userJobsBatchSize, len(ids))
+		if err := tx.Where("id IN ?", ids[start:end]).Delete(&models.Job{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type JobRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+	signals      *Signals
+}
+
+func NewJobRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration, signals *Signals) *JobRepositorySQLServer {
+	return &JobRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+		signals:      signals,
+	}
+}
+
+func (r *JobRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+func (r *JobRepositorySQLServer) Create(ctx context.Context, job *models.Job) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(job).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to enqueue job")
+	}
+
+	r.signals.notifyJobs()
+	return nil
+}
+
+func (r *JobRepositorySQLServer) GetByID(ctx context.Context, id int64) (*models.Job, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var job models.Job
+
+	err := db.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("job with id %d not found", id))
+		}
+		return nil, errors.NewInternalServerError("Failed to get job")
+	}
+
+	return &job, nil
+}
+
+func (r *JobRepositorySQLServer) Search(ctx context.Context, filter *models.Job, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	query := db.Model(&models.Job{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Queue != "" {
+		query = query.Where("queue = ?", filter.Queue)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.NewInternalServerError("Failed to search jobs")
+	}
+
+	jobs := make([]models.Job, 0, search.Size)
+	err := query.Order("created_at " + search.SortDir).
+		Offset(pagination.CalculateOffset(search.Page, search.Size)).
+		Limit(search.Size).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to search jobs")
+	}
+
+	result := pagination.CreatePaginationResult(&jobs, total, *search)
+	return &result, nil
+}
+
+// ClaimNext toma el próximo trabajo vencido de la cola. Un trabajo en ejecución cuyo lock
+// expiró (por ejemplo, porque el proceso murió) se vuelve a tomar si le quedan intentos; si no,
+// pasa a dead, así un trabajo que tira el proceso abajo no se reintenta para siempre. La
+// cantidad de intentos funciona como versión para que dos workers no tomen el mismo trabajo.
+func (r *JobRepositorySQLServer) ClaimNext(ctx context.Context, queue string, workerID string, now time.Time, lockUntil time.Time) (*models.Job, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.Job{}).
+		Where("queue = ? AND status = ? AND locked_until < ? AND attempts >= max_attempts", queue, models.JobRunning, now).
+		Updates(map[string]interface{}{
+			"status":       models.JobDead,
+			"finished_at":  now,
+			"locked_until": nil,
+			"locked_by":    "",
+			"last_error":   "lock expired on the last attempt",
+		}).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to claim job")
+	}
+
+	for {
+		var job models.Job
+
+		err := db.Where("queue = ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ? AND attempts < max_attempts))",
+			queue, models.JobPending, now, models.JobRunning, now).
+			Order("run_at ASC").
+			First(&job).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil
+			}
+			return nil, errors.NewInternalServerError("Failed to claim job")
+		}
+
+		result := db.Model(&models.Job{}).
+			Where("id = ? AND attempts = ? AND status = ?", job.ID, job.Attempts, job.Status).
+			Updates(map[string]interface{}{
+				"status":       models.JobRunning,
+				"attempts":     job.Attempts + 1,
+				"locked_until": lockUntil,
+				"locked_by":    workerID,
+			})
+		if result.Error != nil {
+			return nil, errors.NewInternalServerError("Failed to claim job")
+		}
+
+		if result.RowsAffected == 1 {
+			job.Status = models.JobRunning
+			job.Attempts++
+			job.LockedUntil = &lockUntil
+			job.LockedBy = workerID
+			return &job, nil
+		}
+	}
+}
+
+// Finish guarda el resultado del trabajo si workerID todavía tiene el lock. Devuelve false si el
+// lock venció y otro worker lo tomó, para no pisar el resultado de ese intento
+func (r *JobRepositorySQLServer) Finish(ctx context.Context, id int64, workerID string, updates map[string]interface{}) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", id, models.JobRunning, workerID).
+		Updates(updates)
+	if result.Error != nil {
+		return false, errors.NewInternalServerError("Failed to update job")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Retry vuelve a encolar un trabajo terminado con los intentos en cero
+func (r *JobRepositorySQLServer) Retry(ctx context.Context, id int64) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	updates := map[string]interface{}{
+		"status":       models.JobPending,
+		"attempts":     0,
+		"run_at":       time.Now(),
+		"locked_until": nil,
+		"locked_by":    "",
+		"finished_at":  nil,
+	}
+
+	err := db.Model(&models.Job{}).Where("id = ?", id).Updates(updates).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to retry job")
+	}
+
+	r.signals.notifyJobs()
+	return nil
+}
+
+func (r *JobRepositorySQLServer) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Where("status = ? AND finished_at < ?", models.JobSucceeded, before).Delete(&models.Job{})
+	if result.Error != nil {
+		return 0, errors.NewInternalServerError("Failed to delete finished jobs")
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationPreferenceRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewNotificationPreferenceRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *NotificationPreferenceRepositorySQLServer {
+	return &NotificationPreferenceRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *NotificationPreferenceRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+func (r *NotificationPreferenceRepositorySQLServer) GetByUserID(ctx context.Context, userID int) (*models.NotificationPreference, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var preference models.NotificationPreference
+
+	err := db.Where("user_id = ?", userID).First(&preference).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("notification preferences not found")
+		}
+		return nil, errors.NewInternalServerError("Failed to get notification preferences")
+	}
+
+	return &preference, nil
+}
+
+// Save crea las preferencias del usuario o reemplaza las existentes
+func (r *NotificationPreferenceRepositorySQLServer) Save(ctx context.Context, preference *models.NotificationPreference) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.NotificationPreference{}).Where("user_id = ?", preference.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return tx.Create(preference).Error
+		}
+
+		return tx.Model(preference).Select("language", "pet_created", "sighting_received", "message_received", "pet_found", "updated_at").Updates(preference).Error
+	})
+	if err != nil {
+		return errors.NewInternalServerError("Failed to save notification preferences")
+	}
+	return nil
+}
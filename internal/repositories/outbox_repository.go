@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrOutboxLockLost = errors.NewConflictError("The outbox event lock was taken by another instance")
+
+// writeOutbox agrega el evento dentro de la transacción tx, junto con el cambio que lo origina
+func writeOutbox(tx *gorm.DB, aggregateType string, aggregateID int, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(payload),
+	}).Error
+}
+
+// scrubUserEvents reemplaza dentro de tx el payload de los eventos del usuario, despachados o no,
+// por los datos ya anonimizados de user
+func scrubUserEvents(tx *gorm.DB, user *models.User) error {
+	payload, err := json.Marshal(models.NewUserEventData(user))
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.OutboxEvent{}).
+		Where("aggregate_type = ? AND aggregate_id = ?", models.AggregateUser, user.ID).
+		Update("payload", string(payload)).Error
+}
+
+type OutboxRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewOutboxRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *OutboxRepositorySQLServer {
+	return &OutboxRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *OutboxRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+// ListPending devuelve, en el orden en que se escribieron, los eventos pendientes que ya se pueden
+// despachar: vencidos y sin un lock vigente. De cada agregado solo devuelve el primer evento
+// pendiente, así los siguientes esperan a que ese se despache o pase a dead y se conserva el orden
+func (r *OutboxRepositorySQLServer) ListPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var events []models.OutboxEvent
+
+	err := db.Where("status = ?", models.OutboxPending).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier WHERE earlier.aggregate_type = outbox_events.aggregate_type
+			AND earlier.aggregate_id = outbox_events.aggregate_id AND earlier.status = ? AND earlier.id < outbox_events.id)`, models.OutboxPending).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list outbox events")
+	}
+
+	return events, nil
+}
+
+// Claim toma el evento para workerID hasta lockUntil. Devuelve false si otra instancia lo tomó
+// primero; un lock vencido (por ejemplo, porque el proceso murió) se puede volver a tomar
+func (r *OutboxRepositorySQLServer) Claim(ctx context.Context, id int64, workerID string, now time.Time, lockUntil time.Time) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Model(&models.OutboxEvent{}).
+		Where("id = ? AND status = ? AND (locked_until IS NULL OR locked_until < ?)", id, models.OutboxPending, now).
+		Updates(map[string]interface{}{"locked_by": workerID, "locked_until": lockUntil})
+	if result.Error != nil {
+		return false, errors.NewInternalServerError("Failed to claim outbox event")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *OutboxRepositorySQLServer) MarkDispatched(ctx context.Context, id int64, workerID string) error {
+	updates := map[string]interface{}{"status": models.OutboxDispatched, "dispatched_at": time.Now()}
+	return r.release(ctx, id, workerID, updates, "Failed to mark outbox event as dispatched")
+}
+
+// MarkFailed libera el evento para reintentarlo en nextAttemptAt, guardando los handlers que ya lo procesaron
+func (r *OutboxRepositorySQLServer) MarkFailed(ctx context.Context, id int64, workerID string, attempts int, completedHandlers string, lastError string, nextAttemptAt time.Time) error {
+	updates := map[string]interface{}{
+		"attempts":           attempts,
+		"completed_handlers": completedHandlers,
+		"last_error":         lastError,
+		"next_attempt_at":    nextAttemptAt,
+	}
+	return r.release(ctx, id, workerID, updates, "Failed to update outbox event")
+}
+
+// MarkDead deja el evento en dead: no se reintenta más y no bloquea a los siguientes de su agregado
+func (r *OutboxRepositorySQLServer) MarkDead(ctx context.Context, id int64, workerID string, attempts int, completedHandlers string, lastError string) error {
+	updates := map[string]interface{}{
+		"status":             models.OutboxDead,
+		"attempts":           attempts,
+		"completed_handlers": completedHandlers,
+		"last_error":         lastError,
+	}
+	return r.release(ctx, id, workerID, updates, "Failed to mark outbox event as dead")
+}
+
+// release guarda el resultado y suelta el lock si workerID todavía lo tiene. Si el lock venció y
+// otra instancia tomó el evento devuelve ErrOutboxLockLost, para no pisar el resultado de esa otra
+func (r *OutboxRepositorySQLServer) release(ctx context.Context, id int64, workerID string, updates map[string]interface{}, message string) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	updates["locked_by"] = ""
+	updates["locked_until"] = nil
+
+	result := db.Model(&models.OutboxEvent{}).Where("id = ? AND locked_by = ?", id, workerID).Updates(updates)
+	if result.Error != nil {
+		return errors.NewInternalServerError(message)
+	}
+	if result.RowsAffected == 0 {
+		return ErrOutboxLockLost
+	}
+	return nil
+}
+
+func (r *OutboxRepositorySQLServer) DeleteDispatchedBefore(ctx context.Context, before time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Where("dispatched_at < ?", before).Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, errors.NewInternalServerError("Failed to delete dispatched outbox events")
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteDeadBefore borra los eventos en dead creados antes de before. Tienen su propia retención,
+// más larga que la de los despachados, para dar tiempo a revisarlos
+func (r *OutboxRepositorySQLServer) DeleteDeadBefore(ctx context.Context, before time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Where("status = ? AND created_at < ?", models.OutboxDead, before).Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, errors.NewInternalServerError("Failed to delete dead outbox events")
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PetReportRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewPetReportRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *PetReportRepositorySQLServer {
+	return &PetReportRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *PetReportRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+func (r *PetReportRepositorySQLServer) Create(ctx context.Context, report *models.PetReport) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(report).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to create report")
+	}
+	return nil
+}
+
+func (r *PetReportRepositorySQLServer) GetByID(ctx context.Context, id int) (*models.PetReport, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var report models.PetReport
+
+	err := db.Preload("Pet").Preload("Reporter").Where("id = ?", id).First(&report).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("report with id %d not found", id))
+		}
+		return nil, errors.NewInternalServerError("An error occurred while getting report from database")
+	}
+
+	return &report, nil
+}
+
+// ExistsOpen indica si el usuario ya tiene una denuncia abierta sobre la mascota
+func (r *PetReportRepositorySQLServer) ExistsOpen(ctx context.Context, petID int, reporterID int) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var count int64
+
+	err := db.Model(&models.PetReport{}).
+		Where("pet_id = ? AND reporter_id = ? AND status = ?", petID, reporterID, models.ReportOpen).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.NewInternalServerError("An error occurred while checking reports")
+	}
+
+	return count > 0, nil
+}
+
+func (r *PetReportRepositorySQLServer) Search(ctx context.Context, filter *models.PetReport, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	query := db.Model(&models.PetReport{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if filter.PetID != 0 {
+		query = query.Where("pet_id = ?", filter.PetID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.NewInternalServerError("Failed to search reports")
+	}
+
+	reports := make([]models.PetReport, 0, search.Size)
+	err := query.Preload("Pet").Preload("Reporter").
+		Order("created_at " + search.SortDir).
+		Offset(pagination.CalculateOffset(search.Page, search.Size)).
+		Limit(search.Size).
+		Find(&reports).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to search reports")
+	}
+
+	result := pagination.CreatePaginationResult(&reports, total, *search)
+	return &result, nil
+}
+
+// Resolve cierra una denuncia abierta con el estado y la resolución indicados
+func (r *PetReportRepositorySQLServer) Resolve(ctx context.Context, id int, status string, resolution string, resolvedByID int) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.PetReport{}).
+		Where("id = ? AND status = ?", id, models.ReportOpen).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"resolution":     resolution,
+			"resolved_by_id": resolvedByID,
+			"resolved_at":    time.Now(),
+		}).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to resolve report")
+	}
+	return nil
+}
+
+// ResolveOpenByPetID cierra todas las denuncias abiertas de la mascota cuando un moderador actúa sobre ella
+func (r *PetReportRepositorySQLServer) ResolveOpenByPetID(ctx context.Context, petID int, resolution string, resolvedByID int) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Model(&models.PetReport{}).
+		Where("pet_id = ? AND status = ?", petID, models.ReportOpen).
+		Updates(map[string]interface{}{
+			"status":         models.ReportResolved,
+			"resolution":     resolution,
+			"resolved_by_id": resolvedByID,
+			"resolved_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return 0, errors.NewInternalServerError("Failed to resolve reports")
+	}
+	return result.RowsAffected, nil
+}
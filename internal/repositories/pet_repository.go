@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
 	"go-api-find-my-friend/internal/models"
 	"go-api-find-my-friend/pkg/database"
@@ -8,50 +9,60 @@ import (
 	"go-api-find-my-friend/pkg/pagination"
 	"go-api-find-my-friend/pkg/storage_provider"
 	"mime/multipart"
-	"sync"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+const deletePictureMaxAttempts = 10
+
+var ErrPetStatusConflict = errors.NewConflictError("The pet status was changed by another request")
+
 type PetRepositorySQLServer struct {
 	db              *gorm.DB
 	storageProvider storage_provider.StorageProvider
+	queryTimeout    time.Duration
+	sagaObserver    SagaObserver
+	signals         *Signals
 }
 
-var (
-	petRepositoryInstance *PetRepositorySQLServer
-	petRepositoryOnce     sync.Once
-)
+func NewPetRepositorySQLServer(db *gorm.DB, storageProvider storage_provider.StorageProvider, queryTimeout time.Duration, sagaObserver SagaObserver, signals *Signals) *PetRepositorySQLServer {
+	return &PetRepositorySQLServer{
+		db:              db,
+		storageProvider: storageProvider,
+		queryTimeout:    queryTimeout,
+		sagaObserver:    sagaObserver,
+		signals:         signals,
+	}
+}
 
-func NewPetRepositorySQLServer() *PetRepositorySQLServer {
-	petRepositoryOnce.Do(func() {
-		petRepositoryInstance = &PetRepositorySQLServer{
-			db:              database.DB,
-			storageProvider: storage_provider.NewCloudinary(),
-		}
-	})
-	return petRepositoryInstance
+func (r *PetRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
 }
 
-func (r *PetRepositorySQLServer) Create(pet *models.Pet, picture *multipart.FileHeader) error {
-	orchestrator := NewSagaOrchestrator()
+func (r *PetRepositorySQLServer) Create(ctx context.Context, pet *models.Pet, picture *multipart.FileHeader) error {
+	orchestrator := NewSagaOrchestrator(r.sagaObserver)
 
 	uploadPictureStep := NewUploadPictureStep(pet, picture, r.storageProvider)
-	createStep := NewCreatePetStep(pet, r.db)
+	createStep := NewCreatePetStep(pet, r.db, r.queryTimeout, r.signals)
 
 	orchestrator.AddSteps(uploadPictureStep, createStep)
 
-	if err := orchestrator.Run(); err != nil {
+	if err := orchestrator.Run(ctx); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (r *PetRepositorySQLServer) GetByID(id int) (*models.Pet, error) {
+func (r *PetRepositorySQLServer) GetByID(ctx context.Context, id int) (*models.Pet, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
 	var pet models.Pet
 
-	err := r.db.Preload("User").Where("id = ?", id).First(&pet).Error
+	err := db.Preload("User").Where("id = ?", id).First(&pet).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.NewNotFoundError(fmt.Sprintf("pet with id %d not found", id))
@@ -62,10 +73,43 @@ func (r *PetRepositorySQLServer) GetByID(id int) (*models.Pet, error) {
 	return &pet, nil
 }
 
-func (r *PetRepositorySQLServer) Search(filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
-	query := r.db.Model(&models.PetSearchResult{})
+func (r *PetRepositorySQLServer) ListByUserID(ctx context.Context, userID int) ([]models.Pet, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var pets []models.Pet
+
+	err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&pets).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("An error occurred while getting user pets from database")
+	}
+
+	return pets, nil
+}
+
+func (r *PetRepositorySQLServer) Search(ctx context.Context, filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	query := db.Model(&models.PetSearchResult{})
+
+	// Las publicaciones dadas de baja no aparecen nunca en las búsquedas
+	query = query.Where("status <> ?", models.PetStatusRemoved)
+	if filter == nil || !filter.IncludeArchived {
+		query = query.Where("status <> ?", models.PetStatusArchived)
+	}
+	if filter == nil || !filter.IncludeHidden {
+		if filter != nil && filter.ViewerID != nil {
+			query = query.Where("hidden_at IS NULL OR user_id = ?", *filter.ViewerID)
+		} else {
+			query = query.Where("hidden_at IS NULL")
+		}
+	}
 
 	if filter != nil {
+		if filter.Status != nil {
+			query = query.Where("status = ?", *filter.Status)
+		}
 		if filter.UserID != nil {
 			query = query.Where("user_id = ?", *filter.UserID)
 		}
@@ -73,10 +117,10 @@ func (r *PetRepositorySQLServer) Search(filter *pagination.FilterPet, search *pa
 			query = query.Where("type = ?", *filter.Type)
 		}
 		if filter.Breed != nil {
-			query = query.Where("breed COLLATE SQL_Latin1_General_CP1_CI_AS LIKE ?", "%"+*filter.Breed+"%")
+			query = query.Where(database.LikeInsensitive(db, "breed"), "%"+*filter.Breed+"%")
 		}
 		if filter.LastSeenPlace != nil {
-			query = query.Where("last_seen_place COLLATE SQL_Latin1_General_CP1_CI_AS LIKE ?", "%"+*filter.LastSeenPlace+"%")
+			query = query.Where(database.LikeInsensitive(db, "last_seen_place"), "%"+*filter.LastSeenPlace+"%")
 		}
 	}
 
@@ -117,24 +161,234 @@ func (r *PetRepositorySQLServer) Search(filter *pagination.FilterPet, search *pa
 	}, nil
 }
 
-func (r *PetRepositorySQLServer) Update(id int, updates map[string]interface{}) error {
-	err := r.db.Model(&models.Pet{}).Where("id = ?", id).Updates(updates).Error
+func (r *PetRepositorySQLServer) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	err := r.updateWithEvent(ctx, id, updates, models.EventPetUpdated)
+	if err != nil {
+		return errors.NewInternalServerError("Failed to update pet")
+	}
+	return nil
+}
+
+// ChangeStatus aplica el cambio de estado junto con los updates que lo acompañan y lo registra
+// en el historial, en la misma transacción que el evento
+func (r *PetRepositorySQLServer) ChangeStatus(ctx context.Context, change *models.PetStatusChange, updates map[string]interface{}) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	eventType := models.EventPetUpdated
+	if change.ToStatus == models.PetStatusFound {
+		eventType = models.EventPetFound
+	}
+
+	updates["status"] = change.ToStatus
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Pet{}).
+			Where("id = ? AND status = ?", change.PetID, change.FromStatus).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPetStatusConflict
+		}
+
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+
+		var pet models.Pet
+		if err := tx.Where("id = ?", change.PetID).First(&pet).Error; err != nil {
+			return err
+		}
+
+		return writeOutbox(tx, models.AggregatePet, pet.ID, eventType, models.NewPetEventData(&pet))
+	})
+	if err != nil {
+		if err == ErrPetStatusConflict {
+			return err
+		}
+		return errors.NewInternalServerError("Failed to change pet status")
+	}
+
+	r.signals.notifyOutbox()
+	return nil
+}
+
+func (r *PetRepositorySQLServer) ListStatusChanges(ctx context.Context, petID int) ([]models.PetStatusChange, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var changes []models.PetStatusChange
+
+	err := db.Preload("ChangedBy").Where("pet_id = ?", petID).Order("created_at ASC, id ASC").Find(&changes).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list pet status history")
+	}
+
+	return changes, nil
+}
+
+// ListExpiring devuelve las mascotas perdidas sin confirmar desde before cuyos dueños
+// todavía no recibieron el recordatorio
+func (r *PetRepositorySQLServer) ListExpiring(ctx context.Context, before time.Time, limit int) ([]models.Pet, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var pets []models.Pet
+
+	err := db.
+		Where("status IN ? AND reminder_sent_at IS NULL", []string{models.PetStatusLost, models.PetStatusSighted}).
+		Where("COALESCE(renewed_at, created_at) < ?", before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&pets).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("An error occurred while getting expiring pets from database")
+	}
+
+	return pets, nil
+}
+
+func (r *PetRepositorySQLServer) MarkReminderSent(ctx context.Context, id int, sentAt time.Time) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.Pet{}).Where("id = ?", id).UpdateColumn("reminder_sent_at", sentAt).Error
 	if err != nil {
 		return errors.NewInternalServerError("Failed to update pet")
 	}
 	return nil
 }
 
-func (r *PetRepositorySQLServer) Delete(pet *models.Pet) error {
-	err := r.storageProvider.Delete(pet.PictureURL)
+// ListArchivable devuelve las mascotas perdidas cuyo recordatorio quedó sin respuesta desde
+// reminderBefore y las encontradas antes de foundBefore
+func (r *PetRepositorySQLServer) ListArchivable(ctx context.Context, reminderBefore time.Time, foundBefore time.Time, limit int) ([]models.Pet, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var pets []models.Pet
+
+	err := db.
+		Where("status IN ? AND reminder_sent_at < ?", []string{models.PetStatusLost, models.PetStatusSighted}, reminderBefore).
+		Or("status IN ? AND COALESCE(found_at, updated_at) < ?", []string{models.PetStatusFound, models.PetStatusReunited}, foundBefore).
+		Order("id ASC").
+		Limit(limit).
+		Find(&pets).Error
 	if err != nil {
-		return errors.NewInternalServerError("Failed to delete pet picture")
+		return nil, errors.NewInternalServerError("An error occurred while getting archivable pets from database")
 	}
 
-	err = r.db.Delete(&models.Pet{}, pet.ID).Error
+	return pets, nil
+}
+
+// Renew reinicia el plazo de vencimiento cuando el dueño confirma que la mascota sigue perdida
+func (r *PetRepositorySQLServer) Renew(ctx context.Context, id int, renewedAt time.Time) error {
+	updates := map[string]interface{}{
+		"reminder_sent_at": nil,
+		"renewed_at":       renewedAt,
+	}
+	err := r.updateWithEvent(ctx, id, updates, models.EventPetUpdated)
+	if err != nil {
+		return errors.NewInternalServerError("Failed to renew pet")
+	}
+	return nil
+}
+
+// Delete borra la mascota y encola el borrado de su foto, que se hace en segundo plano
+// para que una falla del storage no impida eliminar la publicación
+func (r *PetRepositorySQLServer) Delete(ctx context.Context, pet *models.Pet) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return deletePet(tx, pet)
+	})
 	if err != nil {
 		return errors.NewInternalServerError("Failed to delete pet")
 	}
 
+	r.signals.notifyOutbox()
+	r.signals.notifyJobs()
+	return nil
+}
+
+// deletePet borra la mascota dentro de tx, encola el borrado de su foto y deja el evento en el
+// outbox. La usan Delete y la baja de cuenta, que borra todas las mascotas en su transacción
+func deletePet(tx *gorm.DB, pet *models.Pet) error {
+	if err := tx.Delete(&models.Pet{}, pet.ID).Error; err != nil {
+		return err
+	}
+
+	if pet.PictureURL != "" {
+		payload := models.DeletePicturePayload{PictureURL: pet.PictureURL}
+		if err := enqueueJob(tx, models.JobQueueImages, models.JobTypeDeletePicture, payload, deletePictureMaxAttempts); err != nil {
+			return err
+		}
+	}
+
+	return writeOutbox(tx, models.AggregatePet, pet.ID, models.EventPetDeleted, models.NewPetEventData(pet))
+}
+
+// CountActiveLost cuenta las mascotas perdidas o vistas que siguen publicadas, por tipo y provincia.
+// La provincia no tiene columna propia: es la primera parte de last_seen_place ("Provincia, Ciudad")
+func (r *PetRepositorySQLServer) CountActiveLost(ctx context.Context) ([]models.LostPetCount, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var rows []struct {
+		Type          string
+		LastSeenPlace string
+		Total         int64
+	}
+
+	err := db.Model(&models.Pet{}).
+		Select("type, last_seen_place, COUNT(*) AS total").
+		Where("status IN ? AND hidden_at IS NULL", []string{models.PetStatusLost, models.PetStatusSighted}).
+		Group("type, last_seen_place").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("An error occurred while counting lost pets")
+	}
+
+	counts := make([]models.LostPetCount, 0, len(rows))
+	index := map[[2]string]int{}
+	for _, row := range rows {
+		province, _, _ := strings.Cut(row.LastSeenPlace, ",")
+		key := [2]string{row.Type, strings.TrimSpace(province)}
+
+		if i, found := index[key]; found {
+			counts[i].Count += row.Total
+			continue
+		}
+		index[key] = len(counts)
+		counts = append(counts, models.LostPetCount{Type: key[0], Province: key[1], Count: row.Total})
+	}
+
+	return counts, nil
+}
+
+// updateWithEvent aplica los cambios y guarda el evento con la mascota ya actualizada
+func (r *PetRepositorySQLServer) updateWithEvent(ctx context.Context, id int, updates map[string]interface{}, eventType string) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Pet{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var pet models.Pet
+		if err := tx.Where("id = ?", id).First(&pet).Error; err != nil {
+			return err
+		}
+
+		return writeOutbox(tx, models.AggregatePet, pet.ID, eventType, models.NewPetEventData(&pet))
+	})
+	if err != nil {
+		return err
+	}
+
+	r.signals.notifyOutbox()
 	return nil
 }
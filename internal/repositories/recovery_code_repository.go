@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewRecoveryCodeRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *RecoveryCodeRepositorySQLServer {
+	return &RecoveryCodeRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *RecoveryCodeRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+// Replace invalida los códigos anteriores del usuario y guarda los nuevos
+func (r *RecoveryCodeRepositorySQLServer) Replace(ctx context.Context, userID int, codes []models.RecoveryCode) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return errors.NewInternalServerError("Failed to save recovery codes")
+	}
+	return nil
+}
+
+func (r *RecoveryCodeRepositorySQLServer) ListUnused(ctx context.Context, userID int) ([]models.RecoveryCode, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var codes []models.RecoveryCode
+
+	err := db.Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to get recovery codes")
+	}
+
+	return codes, nil
+}
+
+// MarkUsed devuelve false si otro request ya consumió el código
+func (r *RecoveryCodeRepositorySQLServer) MarkUsed(ctx context.Context, id int) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Model(&models.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, errors.NewInternalServerError("Failed to update recovery code")
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -1,36 +1,175 @@
 package repositories
 
 import (
+	"context"
 	"go-api-find-my-friend/internal/models"
 	"go-api-find-my-friend/pkg/pagination"
+	"go-api-find-my-friend/pkg/storage_provider"
+	"go-api-find-my-friend/pkg/utils"
 	"mime/multipart"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // PetRepository define los métodos para operaciones con mascotas
 type PetRepository interface {
-	Create(pet *models.Pet, picture *multipart.FileHeader) error
-	GetByID(id int) (*models.Pet, error)
-	Search(filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
-	Update(id int, updates map[string]interface{}) error
-	Delete(pet *models.Pet) error
+	Create(ctx context.Context, pet *models.Pet, picture *multipart.FileHeader) error
+	GetByID(ctx context.Context, id int) (*models.Pet, error)
+	ListByUserID(ctx context.Context, userID int) ([]models.Pet, error)
+	Search(ctx context.Context, filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
+	Update(ctx context.Context, id int, updates map[string]interface{}) error
+	ChangeStatus(ctx context.Context, change *models.PetStatusChange, updates map[string]interface{}) error
+	ListStatusChanges(ctx context.Context, petID int) ([]models.PetStatusChange, error)
+	ListExpiring(ctx context.Context, before time.Time, limit int) ([]models.Pet, error)
+	MarkReminderSent(ctx context.Context, id int, sentAt time.Time) error
+	ListArchivable(ctx context.Context, reminderBefore time.Time, foundBefore time.Time, limit int) ([]models.Pet, error)
+	Renew(ctx context.Context, id int, renewedAt time.Time) error
+	Delete(ctx context.Context, pet *models.Pet) error
+	CountActiveLost(ctx context.Context) ([]models.LostPetCount, error)
+}
+
+type PetReportRepository interface {
+	Create(ctx context.Context, report *models.PetReport) error
+	GetByID(ctx context.Context, id int) (*models.PetReport, error)
+	ExistsOpen(ctx context.Context, petID int, reporterID int) (bool, error)
+	Search(ctx context.Context, filter *models.PetReport, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
+	Resolve(ctx context.Context, id int, status string, resolution string, resolvedByID int) error
+	ResolveOpenByPetID(ctx context.Context, petID int, resolution string, resolvedByID int) (int64, error)
 }
 
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id int) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	ExistsByEmail(email string) (bool, error)
-	ExistsByID(id int) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ExistsByID(ctx context.Context, id int) (bool, error)
+	Update(ctx context.Context, id int, updates map[string]interface{}) error
+	AddWarning(ctx context.Context, id int) error
+	ClaimTOTPStep(ctx context.Context, id int, step int64) (bool, error)
+	Anonymize(ctx context.Context, id int) error
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	Search(ctx context.Context, action string, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
+}
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID int, codes []models.RecoveryCode) error
+	ListUnused(ctx context.Context, userID int) ([]models.RecoveryCode, error)
+	MarkUsed(ctx context.Context, id int) (bool, error)
+}
+
+type ConversationRepository interface {
+	Create(ctx context.Context, conversation *models.Conversation) error
+	GetByID(ctx context.Context, id int) (*models.Conversation, error)
+	GetByPetAndFinder(ctx context.Context, petID int, finderID int) (*models.Conversation, error)
+	ListByParticipant(ctx context.Context, userID int) ([]models.Conversation, error)
+	ListByPetID(ctx context.Context, petID int) ([]models.Conversation, error)
+	Update(ctx context.Context, id int, updates map[string]interface{}) error
+	CreateMessage(ctx context.Context, message *models.Message) error
+	ListMessages(ctx context.Context, conversationID int, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
+	CountUnread(ctx context.Context, conversationID int, userID int, lastReadAt *time.Time) (int64, error)
+}
+
+type SightingRepository interface {
+	Create(ctx context.Context, sighting *models.Sighting) error
+	ListByPetID(ctx context.Context, petID int) ([]models.Sighting, error)
+}
+
+type NotificationPreferenceRepository interface {
+	GetByUserID(ctx context.Context, userID int) (*models.NotificationPreference, error)
+	Save(ctx context.Context, preference *models.NotificationPreference) error
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetByID(ctx context.Context, id int) (*models.WebhookSubscription, error)
+	List(ctx context.Context) ([]models.WebhookSubscription, error)
+	ListActiveByEvent(ctx context.Context, eventType string) ([]models.WebhookSubscription, error)
+	Update(ctx context.Context, id int, updates map[string]interface{}) error
+	Delete(ctx context.Context, id int) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+	LastRedelivery(ctx context.Context, subscriptionID int, eventID string) (int, error)
+	GetDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID int, status string, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int, excludeSubscriptions []int) ([]models.WebhookDelivery, error)
+	ClaimDelivery(ctx context.Context, id int, attempts int, leaseUntil time.Time) (bool, error)
+	UpdateDelivery(ctx context.Context, id int, updates map[string]interface{}) error
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxRepository interface {
+	ListPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	Claim(ctx context.Context, id int64, workerID string, now time.Time, lockUntil time.Time) (bool, error)
+	MarkDispatched(ctx context.Context, id int64, workerID string) error
+	MarkFailed(ctx context.Context, id int64, workerID string, attempts int, completedHandlers string, lastError string, nextAttemptAt time.Time) error
+	MarkDead(ctx context.Context, id int64, workerID string, attempts int, completedHandlers string, lastError string) error
+	DeleteDispatchedBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteDeadBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type JobRepository interface {
+	Create(ctx context.Context, job *models.Job) error
+	GetByID(ctx context.Context, id int64) (*models.Job, error)
+	Search(ctx context.Context, filter *models.Job, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
+	ClaimNext(ctx context.Context, queue string, workerID string, now time.Time, lockUntil time.Time) (*models.Job, error)
+	Finish(ctx context.Context, id int64, workerID string, updates map[string]interface{}) (bool, error)
+	Retry(ctx context.Context, id int64) error
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type ImageRepository interface {
 	Upload(file *multipart.FileHeader) (string, error)
 }
 
-func NewPetRepository() PetRepository {
-	return NewPetRepositorySQLServer()
+func NewPetRepository(db *gorm.DB, storageProvider storage_provider.StorageProvider, queryTimeout time.Duration, sagaObserver SagaObserver, signals *Signals) PetRepository {
+	return NewPetRepositorySQLServer(db, storageProvider, queryTimeout, sagaObserver, signals)
+}
+
+func NewPetReportRepository(db *gorm.DB, queryTimeout time.Duration) PetReportRepository {
+	return NewPetReportRepositorySQLServer(db, queryTimeout)
+}
+
+func NewUserRepository(db *gorm.DB, queryTimeout time.Duration, signals *Signals) UserRepository {
+	return NewUserRepositorySQLServer(db, queryTimeout, signals)
+}
+
+func NewAuditRepository(db *gorm.DB, queryTimeout time.Duration) AuditRepository {
+	return NewAuditRepositorySQLServer(db, queryTimeout)
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB, queryTimeout time.Duration) RecoveryCodeRepository {
+	return NewRecoveryCodeRepositorySQLServer(db, queryTimeout)
+}
+
+func NewConversationRepository(db *gorm.DB, queryTimeout time.Duration) ConversationRepository {
+	return NewConversationRepositorySQLServer(db, queryTimeout)
+}
+
+func NewSightingRepository(db *gorm.DB, queryTimeout time.Duration) SightingRepository {
+	return NewSightingRepositorySQLServer(db, queryTimeout)
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB, queryTimeout time.Duration) NotificationPreferenceRepository {
+	return NewNotificationPreferenceRepositorySQLServer(db, queryTimeout)
+}
+
+func NewWebhookRepository(db *gorm.DB, queryTimeout time.Duration) WebhookRepository {
+	return NewWebhookRepositorySQLServer(db, queryTimeout)
+}
+
+func NewOutboxRepository(db *gorm.DB, queryTimeout time.Duration) OutboxRepository {
+	return NewOutboxRepositorySQLServer(db, queryTimeout)
+}
+
+func NewJobRepository(db *gorm.DB, queryTimeout time.Duration, signals *Signals) JobRepository {
+	return NewJobRepositorySQLServer(db, queryTimeout, signals)
 }
 
-func NewUserRepository() UserRepository {
-	return NewUserRepositorySQLServer()
+// withContext ata db a ctx acotado a timeout; cancel se llama al terminar la operación
+func withContext(ctx context.Context, db *gorm.DB, timeout time.Duration) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := utils.WithTimeout(ctx, timeout)
+	return db.WithContext(ctx), cancel
 }
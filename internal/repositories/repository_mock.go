@@ -1,90 +1,197 @@
 package repositories
 
 import (
+	"context"
 	"go-api-find-my-friend/internal/models"
 	"go-api-find-my-friend/pkg/pagination"
 	"mime/multipart"
+	"time"
+)
+
+// Los mocks tienen que seguir cumpliendo las interfaces cuando estas cambian
+var (
+	_ PetRepository  = (*PetRepositoryMock)(nil)
+	_ UserRepository = (*UserRepositoryMock)(nil)
 )
 
 type PetRepositoryMock struct {
-	CreateFunc  func(pet *models.Pet, picture *multipart.FileHeader) error
-	GetByIDFunc func(id int) (*models.Pet, error)
-	SearchFunc  func(filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
-	UpdateFunc  func(id int, updates map[string]interface{}) error
-	DeleteFunc  func(id int) error
+	CreateFunc            func(ctx context.Context, pet *models.Pet, picture *multipart.FileHeader) error
+	GetByIDFunc           func(ctx context.Context, id int) (*models.Pet, error)
+	ListByUserIDFunc      func(ctx context.Context, userID int) ([]models.Pet, error)
+	SearchFunc            func(ctx context.Context, filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error)
+	UpdateFunc            func(ctx context.Context, id int, updates map[string]interface{}) error
+	ChangeStatusFunc      func(ctx context.Context, change *models.PetStatusChange, updates map[string]interface{}) error
+	ListStatusChangesFunc func(ctx context.Context, petID int) ([]models.PetStatusChange, error)
+	ListExpiringFunc      func(ctx context.Context, before time.Time, limit int) ([]models.Pet, error)
+	MarkReminderSentFunc  func(ctx context.Context, id int, sentAt time.Time) error
+	ListArchivableFunc    func(ctx context.Context, reminderBefore time.Time, foundBefore time.Time, limit int) ([]models.Pet, error)
+	RenewFunc             func(ctx context.Context, id int, renewedAt time.Time) error
+	DeleteFunc            func(ctx context.Context, pet *models.Pet) error
+	CountActiveLostFunc   func(ctx context.Context) ([]models.LostPetCount, error)
 }
 
-func (m *PetRepositoryMock) Create(pet *models.Pet, picture *multipart.FileHeader) error {
+func (m *PetRepositoryMock) Create(ctx context.Context, pet *models.Pet, picture *multipart.FileHeader) error {
 	if m.CreateFunc != nil {
-		return m.CreateFunc(pet, picture)
+		return m.CreateFunc(ctx, pet, picture)
 	}
 	return nil
 }
 
-func (m *PetRepositoryMock) GetByID(id int) (*models.Pet, error) {
+func (m *PetRepositoryMock) GetByID(ctx context.Context, id int) (*models.Pet, error) {
 	if m.GetByIDFunc != nil {
-		return m.GetByIDFunc(id)
+		return m.GetByIDFunc(ctx, id)
 	}
 	return nil, nil
 }
 
-func (m *PetRepositoryMock) Search(filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+func (m *PetRepositoryMock) ListByUserID(ctx context.Context, userID int) ([]models.Pet, error) {
+	if m.ListByUserIDFunc != nil {
+		return m.ListByUserIDFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *PetRepositoryMock) Search(ctx context.Context, filter *pagination.FilterPet, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
 	if m.SearchFunc != nil {
-		return m.SearchFunc(filter, search)
+		return m.SearchFunc(ctx, filter, search)
 	}
 	return nil, nil
 }
 
-func (m *PetRepositoryMock) Update(id int, updates map[string]interface{}) error {
+func (m *PetRepositoryMock) Update(ctx context.Context, id int, updates map[string]interface{}) error {
 	if m.UpdateFunc != nil {
-		return m.UpdateFunc(id, updates)
+		return m.UpdateFunc(ctx, id, updates)
+	}
+	return nil
+}
+
+func (m *PetRepositoryMock) ChangeStatus(ctx context.Context, change *models.PetStatusChange, updates map[string]interface{}) error {
+	if m.ChangeStatusFunc != nil {
+		return m.ChangeStatusFunc(ctx, change, updates)
+	}
+	return nil
+}
+
+func (m *PetRepositoryMock) ListStatusChanges(ctx context.Context, petID int) ([]models.PetStatusChange, error) {
+	if m.ListStatusChangesFunc != nil {
+		return m.ListStatusChangesFunc(ctx, petID)
+	}
+	return nil, nil
+}
+
+func (m *PetRepositoryMock) ListExpiring(ctx context.Context, before time.Time, limit int) ([]models.Pet, error) {
+	if m.ListExpiringFunc != nil {
+		return m.ListExpiringFunc(ctx, before, limit)
+	}
+	return nil, nil
+}
+
+func (m *PetRepositoryMock) MarkReminderSent(ctx context.Context, id int, sentAt time.Time) error {
+	if m.MarkReminderSentFunc != nil {
+		return m.MarkReminderSentFunc(ctx, id, sentAt)
+	}
+	return nil
+}
+
+func (m *PetRepositoryMock) ListArchivable(ctx context.Context, reminderBefore time.Time, foundBefore time.Time, limit int) ([]models.Pet, error) {
+	if m.ListArchivableFunc != nil {
+		return m.ListArchivableFunc(ctx, reminderBefore, foundBefore, limit)
+	}
+	return nil, nil
+}
+
+func (m *PetRepositoryMock) Renew(ctx context.Context, id int, renewedAt time.Time) error {
+	if m.RenewFunc != nil {
+		return m.RenewFunc(ctx, id, renewedAt)
 	}
 	return nil
 }
 
-func (m *PetRepositoryMock) Delete(id int) error {
+func (m *PetRepositoryMock) Delete(ctx context.Context, pet *models.Pet) error {
 	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+		return m.DeleteFunc(ctx, pet)
 	}
 	return nil
 }
 
+func (m *PetRepositoryMock) CountActiveLost(ctx context.Context) ([]models.LostPetCount, error) {
+	if m.CountActiveLostFunc != nil {
+		return m.CountActiveLostFunc(ctx)
+	}
+	return nil, nil
+}
+
 type UserRepositoryMock struct {
-	CreateFunc        func(user *models.User) error
-	GetByIDFunc       func(id int) (*models.User, error)
-	GetByEmailFunc    func(email string) (*models.User, error)
-	ExistsByEmailFunc func(email string) (bool, error)
-	ExistsByIDFunc    func(id int) (bool, error)
+	CreateFunc        func(ctx context.Context, user *models.User) error
+	GetByIDFunc       func(ctx context.Context, id int) (*models.User, error)
+	GetByEmailFunc    func(ctx context.Context, email string) (*models.User, error)
+	ExistsByEmailFunc func(ctx context.Context, email string) (bool, error)
+	ExistsByIDFunc    func(ctx context.Context, id int) (bool, error)
+	UpdateFunc        func(ctx context.Context, id int, updates map[string]interface{}) error
+	AddWarningFunc    func(ctx context.Context, id int) error
+	ClaimTOTPStepFunc func(ctx context.Context, id int, step int64) (bool, error)
+	AnonymizeFunc     func(ctx context.Context, id int) error
 }
 
-func (m *UserRepositoryMock) Create(user *models.User) error {
+func (m *UserRepositoryMock) Create(ctx context.Context, user *models.User) error {
 	if m.CreateFunc != nil {
-		return m.CreateFunc(user)
+		return m.CreateFunc(ctx, user)
 	}
 	return nil
 }
 
-func (m *UserRepositoryMock) GetByEmail(email string) (*models.User, error) {
+func (m *UserRepositoryMock) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	if m.GetByEmailFunc != nil {
-		return m.GetByEmailFunc(email)
+		return m.GetByEmailFunc(ctx, email)
 	}
 	return nil, nil
 }
 
-func (m *UserRepositoryMock) ExistsByEmail(email string) (bool, error) {
+func (m *UserRepositoryMock) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	if m.ExistsByEmailFunc != nil {
-		return m.ExistsByEmailFunc(email)
+		return m.ExistsByEmailFunc(ctx, email)
 	}
 	return false, nil
 }
 
-func (m *UserRepositoryMock) ExistsByID(id int) (bool, error) {
+func (m *UserRepositoryMock) ExistsByID(ctx context.Context, id int) (bool, error) {
 	if m.ExistsByIDFunc != nil {
-		return m.ExistsByIDFunc(id)
+		return m.ExistsByIDFunc(ctx, id)
 	}
 	return false, nil
 }
 
-func (m *UserRepositoryMock) GetByID(id int) (*models.User, error) {
+func (m *UserRepositoryMock) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
 	return nil, nil
 }
+
+func (m *UserRepositoryMock) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, id, updates)
+	}
+	return nil
+}
+
+func (m *UserRepositoryMock) AddWarning(ctx context.Context, id int) error {
+	if m.AddWarningFunc != nil {
+		return m.AddWarningFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *UserRepositoryMock) ClaimTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	if m.ClaimTOTPStepFunc != nil {
+		return m.ClaimTOTPStepFunc(ctx, id, step)
+	}
+	return true, nil
+}
+
+func (m *UserRepositoryMock) Anonymize(ctx context.Context, id int) error {
+	if m.AnonymizeFunc != nil {
+		return m.AnonymizeFunc(ctx, id)
+	}
+	return nil
+}
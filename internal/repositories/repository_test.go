@@ -0,0 +1,511 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/database"
+	_ "go-api-find-my-friend/pkg/database/migrations"
+	"go-api-find-my-friend/pkg/pagination"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// testDialect es un motor contra el que corren las pruebas. SQLite corre siempre en un archivo
+// temporal; Postgres y SQL Server solo si su variable de entorno tiene un DSN, y usan esa base
+// tal cual, así que tiene que ser una base descartable
+type testDialect struct {
+	name   string
+	envVar string
+	open   func(dsn string) gorm.Dialector
+}
+
+var testDialects = []testDialect{
+	{name: database.DriverSQLite},
+	{name: database.DriverPostgres, envVar: "TEST_POSTGRES_DSN", open: func(dsn string) gorm.Dialector { return postgres.Open(dsn) }},
+	{name: database.DriverSQLServer, envVar: "TEST_SQLSERVER_DSN", open: func(dsn string) gorm.Dialector { return sqlserver.Open(dsn) }},
+}
+
+// testTables son las tablas que se vacían antes de cada prueba, en orden de dependencias
+var testTables = []string{"webhook_deliveries", "webhook_subscriptions", "jobs", "outbox_events", "pet_status_changes", "pets", "users"}
+
+// forEachDialect corre test en un subtest por motor, con el esquema migrado y las tablas vacías
+func forEachDialect(t *testing.T, test func(t *testing.T, db *gorm.DB)) {
+	for _, dialect := range testDialects {
+		t.Run(dialect.name, func(t *testing.T) {
+			db := openTestDB(t, dialect)
+
+			if err := database.Migrate(db); err != nil {
+				t.Fatalf("failed to migrate: %v", err)
+			}
+			for _, table := range testTables {
+				if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+					t.Fatalf("failed to clean %s: %v", table, err)
+				}
+			}
+
+			test(t, db)
+		})
+	}
+}
+
+func openTestDB(t *testing.T, dialect testDialect) *gorm.DB {
+	if dialect.envVar == "" {
+		db := database.Connect(&config.Config{Database: config.DatabaseConfig{
+			Driver:     database.DriverSQLite,
+			SQLitePath: filepath.Join(t.TempDir(), "test.db"),
+		}})
+		t.Cleanup(func() { database.Close(db) })
+		return db
+	}
+
+	dsn := os.Getenv(dialect.envVar)
+	if dsn == "" {
+		t.Skipf("%s is not set", dialect.envVar)
+	}
+
+	db, err := gorm.Open(dialect.open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	return db
+}
+
+func createTestUser(t *testing.T, db *gorm.DB) *models.User {
+	user := &models.User{Name: "Ana", LastName: "García", Email: "ana@example.com", Password: "hash"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+func TestPetRepositorySearch(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		user := createTestUser(t, db)
+		hiddenAt := time.Now()
+		pets := []models.Pet{
+			{Name: "Toby", Type: "dog", Breed: "Caniche Toy", UserID: user.ID, LastSeenTime: time.Now(), LastSeenPlace: "San Martín", Status: models.PetStatusLost},
+			{Name: "Luna", Type: "dog", Breed: "Labrador", UserID: user.ID, LastSeenTime: time.Now(), LastSeenPlace: "Córdoba", Status: models.PetStatusLost},
+			{Name: "Nube", Type: "dog", Breed: "caniche", UserID: user.ID, LastSeenTime: time.Now(), LastSeenPlace: "La Plata", Status: models.PetStatusLost, HiddenAt: &hiddenAt},
+			{Name: "Copo", Type: "cat", Breed: "CANICHE", UserID: user.ID, LastSeenTime: time.Now(), LastSeenPlace: "San Martín", Status: models.PetStatusRemoved},
+		}
+		if err := db.Create(&pets).Error; err != nil {
+			t.Fatalf("failed to create pets: %v", err)
+		}
+
+		repository := NewPetRepositorySQLServer(db, nil, 5*time.Second, nil, nil)
+		text := func(value string) *string { return &value }
+
+		tests := []struct {
+			name   string
+			filter pagination.FilterPet
+			want   []string
+		}{
+			{name: "breed ignores case", filter: pagination.FilterPet{Breed: text("CANICHE")}, want: []string{"Toby"}},
+			{name: "place ignores accents", filter: pagination.FilterPet{LastSeenPlace: text("san martin")}, want: []string{"Toby"}},
+			{name: "accented term matches", filter: pagination.FilterPet{LastSeenPlace: text("CÓRDOBA")}, want: []string{"Luna"}},
+			{name: "hidden only when requested", filter: pagination.FilterPet{Breed: text("caniche"), IncludeHidden: true}, want: []string{"Toby", "Nube"}},
+			{name: "hidden for the owner", filter: pagination.FilterPet{Breed: text("caniche"), ViewerID: &user.ID}, want: []string{"Toby", "Nube"}},
+			{name: "no match", filter: pagination.FilterPet{Breed: text("siamés")}, want: nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := repository.Search(context.Background(), &tt.filter, &pagination.PaginationParams{Page: 1, Size: 10, SortDir: "ASC"})
+				if err != nil {
+					t.Fatalf("Search returned %v", err)
+				}
+
+				var names []string
+				if result.Data != nil {
+					for _, pet := range *result.Data.(*[]models.PetSearchResult) {
+						names = append(names, pet.Name)
+					}
+				}
+				if int(result.Total) != len(tt.want) || !equalNames(names, tt.want) {
+					t.Errorf("got %v (total %d), want %v", names, result.Total, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func equalNames(got []string, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[string]bool, len(got))
+	for _, name := range got {
+		seen[name] = true
+	}
+	for _, name := range want {
+		if !seen[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// Dos requests cambian el estado de la misma mascota a la vez: solo uno gana y el otro recibe
+// ErrPetStatusConflict en lugar de pisar el cambio
+func TestPetRepositoryChangeStatusRace(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		user := createTestUser(t, db)
+		pet := models.Pet{Name: "Toby", Type: "dog", Breed: "Caniche", UserID: user.ID, LastSeenTime: time.Now(), LastSeenPlace: "San Martín", Status: models.PetStatusLost}
+		if err := db.Create(&pet).Error; err != nil {
+			t.Fatalf("failed to create pet: %v", err)
+		}
+
+		repository := NewPetRepositorySQLServer(db, nil, 5*time.Second, nil, nil)
+
+		targets := []string{models.PetStatusFound, models.PetStatusArchived}
+		errs := make([]error, len(targets))
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				change := &models.PetStatusChange{PetID: pet.ID, FromStatus: models.PetStatusLost, ToStatus: target, ChangedByID: &user.ID}
+				errs[i] = repository.ChangeStatus(context.Background(), change, map[string]interface{}{})
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		winner := -1
+		for i, err := range errs {
+			switch err {
+			case nil:
+				if winner != -1 {
+					t.Fatalf("both writers changed the status: %v", errs)
+				}
+				winner = i
+			case ErrPetStatusConflict:
+			default:
+				t.Fatalf("writer %d returned %v", i, err)
+			}
+		}
+		if winner == -1 {
+			t.Fatalf("no writer changed the status: %v", errs)
+		}
+
+		var stored models.Pet
+		if err := db.First(&stored, pet.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != targets[winner] {
+			t.Errorf("pet has status %s, want %s", stored.Status, targets[winner])
+		}
+
+		var changes int64
+		if err := db.Model(&models.PetStatusChange{}).Where("pet_id = ?", pet.ID).Count(&changes).Error; err != nil {
+			t.Fatal(err)
+		}
+		if changes != 1 {
+			t.Errorf("history has %d changes, want 1", changes)
+		}
+	})
+}
+
+func TestJobRepositoryClaimNext(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		now := time.Now()
+		expired := now.Add(-time.Minute)
+		locked := now.Add(time.Minute)
+		jobs := []models.Job{
+			{Queue: "default", Type: "due", Payload: "{}", Status: models.JobPending, MaxAttempts: 3, RunAt: now.Add(-time.Minute)},
+			{Queue: "default", Type: "future", Payload: "{}", Status: models.JobPending, MaxAttempts: 3, RunAt: now.Add(time.Hour)},
+			{Queue: "other", Type: "other queue", Payload: "{}", Status: models.JobPending, MaxAttempts: 3, RunAt: now.Add(-time.Hour)},
+			{Queue: "default", Type: "expired lock", Payload: "{}", Status: models.JobRunning, Attempts: 1, MaxAttempts: 3, RunAt: now.Add(-time.Hour), LockedUntil: &expired, LockedBy: "dead-worker"},
+			{Queue: "default", Type: "expired last attempt", Payload: "{}", Status: models.JobRunning, Attempts: 3, MaxAttempts: 3, RunAt: now.Add(-4 * time.Hour), LockedUntil: &expired, LockedBy: "dead-worker"},
+			{Queue: "default", Type: "locked", Payload: "{}", Status: models.JobRunning, Attempts: 1, MaxAttempts: 3, RunAt: now.Add(-2 * time.Hour), LockedUntil: &locked, LockedBy: "live-worker"},
+			{Queue: "default", Type: "finished", Payload: "{}", Status: models.JobSucceeded, Attempts: 1, MaxAttempts: 3, RunAt: now.Add(-3 * time.Hour)},
+		}
+		if err := db.Create(&jobs).Error; err != nil {
+			t.Fatalf("failed to create jobs: %v", err)
+		}
+
+		repository := NewJobRepositorySQLServer(db, 5*time.Second, nil)
+		lockUntil := now.Add(5 * time.Minute)
+
+		var claimed []string
+		for {
+			job, err := repository.ClaimNext(context.Background(), "default", "worker-1", now, lockUntil)
+			if err != nil {
+				t.Fatalf("ClaimNext returned %v", err)
+			}
+			if job == nil {
+				break
+			}
+			if job.Status != models.JobRunning || job.LockedBy != "worker-1" {
+				t.Errorf("%s: got status %s locked by %s", job.Type, job.Status, job.LockedBy)
+			}
+			claimed = append(claimed, job.Type)
+		}
+
+		// Sale primero el de run_at más viejo: el que quedó con el lock vencido
+		want := []string{"expired lock", "due"}
+		if len(claimed) != len(want) || claimed[0] != want[0] || claimed[1] != want[1] {
+			t.Fatalf("claimed %v, want %v", claimed, want)
+		}
+
+		var retaken models.Job
+		if err := db.Where("type = ?", "expired lock").First(&retaken).Error; err != nil {
+			t.Fatal(err)
+		}
+		if retaken.Attempts != 2 || retaken.LockedBy != "worker-1" {
+			t.Errorf("retaken job has attempts %d and lock %s", retaken.Attempts, retaken.LockedBy)
+		}
+
+		var exhausted models.Job
+		if err := db.Where("type = ?", "expired last attempt").First(&exhausted).Error; err != nil {
+			t.Fatal(err)
+		}
+		if exhausted.Status != models.JobDead || exhausted.LockedBy != "" {
+			t.Errorf("job without attempts left has status %s and lock %s", exhausted.Status, exhausted.LockedBy)
+		}
+
+		// Solo el worker que tiene el lock puede guardar el resultado
+		finished, err := repository.Finish(context.Background(), retaken.ID, "dead-worker", map[string]interface{}{"status": models.JobSucceeded})
+		if err != nil || finished {
+			t.Errorf("Finish with a lost lock returned %v, %v", finished, err)
+		}
+		finished, err = repository.Finish(context.Background(), retaken.ID, "worker-1", map[string]interface{}{"status": models.JobSucceeded, "locked_by": ""})
+		if err != nil || !finished {
+			t.Errorf("Finish by the lock owner returned %v, %v", finished, err)
+		}
+	})
+}
+
+func TestOutboxRepositoryListPending(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		now := time.Now()
+		past := now.Add(-time.Minute)
+		future := now.Add(time.Minute)
+		event := func(aggregateID int, eventType string, status string) models.OutboxEvent {
+			return models.OutboxEvent{AggregateType: models.AggregatePet, AggregateID: aggregateID, EventType: eventType, Payload: "{}", Status: status}
+		}
+
+		events := []models.OutboxEvent{
+			event(1, "first of 1", models.OutboxPending),
+			event(1, "second of 1", models.OutboxPending),
+			event(2, "backed off", models.OutboxPending),
+			event(2, "behind backed off", models.OutboxPending),
+			event(3, "dispatched", models.OutboxDispatched),
+			event(3, "after dispatched", models.OutboxPending),
+			event(4, "locked", models.OutboxPending),
+			event(5, "expired lock", models.OutboxPending),
+			event(6, "dead", models.OutboxDead),
+			event(6, "after dead", models.OutboxPending),
+		}
+		events[2].NextAttemptAt = &future
+		events[6].LockedUntil = &future
+		events[6].LockedBy = "live-worker"
+		events[7].LockedUntil = &past
+		events[7].LockedBy = "dead-worker"
+		for i := range events {
+			if err := db.Create(&events[i]).Error; err != nil {
+				t.Fatalf("failed to create outbox event: %v", err)
+			}
+		}
+
+		repository := NewOutboxRepositorySQLServer(db, 5*time.Second)
+
+		pending, err := repository.ListPending(context.Background(), now, 10)
+		if err != nil {
+			t.Fatalf("ListPending returned %v", err)
+		}
+
+		want := []string{"first of 1", "after dispatched", "expired lock", "after dead"}
+		var got []string
+		for _, pendingEvent := range pending {
+			got = append(got, pendingEvent.EventType)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+
+		limited, err := repository.ListPending(context.Background(), now, 1)
+		if err != nil {
+			t.Fatalf("ListPending returned %v", err)
+		}
+		if len(limited) != 1 || limited[0].EventType != "first of 1" {
+			t.Errorf("limit 1 returned %d events", len(limited))
+		}
+	})
+}
+
+func TestOutboxRepositoryRelease(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		ctx := context.Background()
+		now := time.Now()
+		event := models.OutboxEvent{AggregateType: models.AggregatePet, AggregateID: 1, EventType: "PetCreated", Payload: "{}", Status: models.OutboxPending}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatalf("failed to create outbox event: %v", err)
+		}
+
+		repository := NewOutboxRepositorySQLServer(db, 5*time.Second)
+
+		// worker-1 toma el evento, su lock vence y lo retoma worker-2
+		if claimed, err := repository.Claim(ctx, event.ID, "worker-1", now, now.Add(-time.Second)); err != nil || !claimed {
+			t.Fatalf("first Claim returned %v, %v", claimed, err)
+		}
+		if claimed, err := repository.Claim(ctx, event.ID, "worker-2", now, now.Add(time.Minute)); err != nil || !claimed {
+			t.Fatalf("Claim of an expired lock returned %v, %v", claimed, err)
+		}
+
+		if err := repository.MarkFailed(ctx, event.ID, "worker-1", 1, "", "boom", now.Add(time.Minute)); err != ErrOutboxLockLost {
+			t.Errorf("MarkFailed with a lost lock returned %v", err)
+		}
+		if err := repository.MarkDispatched(ctx, event.ID, "worker-2"); err != nil {
+			t.Fatalf("MarkDispatched by the lock owner returned %v", err)
+		}
+
+		var stored models.OutboxEvent
+		if err := db.First(&stored, event.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != models.OutboxDispatched || stored.Attempts != 0 || stored.LockedBy != "" {
+			t.Errorf("event has status %s, attempts %d and lock %q", stored.Status, stored.Attempts, stored.LockedBy)
+		}
+	})
+}
+
+func TestOutboxRepositoryDeleteDeadBefore(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		old := time.Now().Add(-48 * time.Hour)
+		events := []models.OutboxEvent{
+			{AggregateType: models.AggregatePet, AggregateID: 1, EventType: "old dead", Payload: "{}", Status: models.OutboxDead, CreatedAt: old},
+			{AggregateType: models.AggregatePet, AggregateID: 2, EventType: "recent dead", Payload: "{}", Status: models.OutboxDead},
+			{AggregateType: models.AggregatePet, AggregateID: 3, EventType: "old pending", Payload: "{}", Status: models.OutboxPending, CreatedAt: old},
+		}
+		if err := db.Create(&events).Error; err != nil {
+			t.Fatalf("failed to create outbox events: %v", err)
+		}
+
+		repository := NewOutboxRepositorySQLServer(db, 5*time.Second)
+
+		deleted, err := repository.DeleteDeadBefore(context.Background(), time.Now().Add(-24*time.Hour))
+		if err != nil || deleted != 1 {
+			t.Fatalf("DeleteDeadBefore returned %d, %v", deleted, err)
+		}
+
+		var remaining []string
+		if err := db.Model(&models.OutboxEvent{}).Order("id").Pluck("event_type", &remaining).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(remaining) != 2 || remaining[0] != "recent dead" || remaining[1] != "old pending" {
+			t.Errorf("remaining events %v", remaining)
+		}
+	})
+}
+
+func TestUserRepositoryAnonymize(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		repository := NewUserRepositorySQLServer(db, 5*time.Second, nil)
+
+		user := &models.User{Name: "Ana", LastName: "García", Email: "ana@example.com", Password: "hash"}
+		other := &models.User{Name: "Juan", LastName: "Pérez", Email: "juan@example.com", Password: "hash"}
+		for _, u := range []*models.User{user, other} {
+			if err := repository.Create(context.Background(), u); err != nil {
+				t.Fatalf("failed to create user: %v", err)
+			}
+		}
+
+		unrelated := fmt.Sprintf(`{"kind":"pet_found","recipient_id":%d}`, other.ID)
+		emails := []struct {
+			payload string
+			status  string
+		}{
+			{payload: fmt.Sprintf(`{"kind":"pet_found","recipient_id":%d}`, user.ID), status: models.JobPending},
+			{payload: fmt.Sprintf(`{"kind":"message_received","recipient_id":%d,"sender_id":%d,"data":{"SenderName":"Ana"}}`, other.ID, user.ID), status: models.JobDead},
+			{payload: unrelated, status: models.JobPending},
+		}
+		for _, email := range emails {
+			job := models.Job{Queue: models.JobQueueEmails, Type: models.JobTypeSendEmail, Payload: email.payload, Status: email.status, MaxAttempts: 3, RunAt: time.Now()}
+			if err := db.Create(&job).Error; err != nil {
+				t.Fatalf("failed to create job: %v", err)
+			}
+		}
+
+		if err := repository.Anonymize(context.Background(), user.ID); err != nil {
+			t.Fatalf("Anonymize returned %v", err)
+		}
+
+		var remaining []models.Job
+		if err := db.Find(&remaining).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(remaining) != 1 || remaining[0].Payload != unrelated {
+			t.Errorf("%d email jobs left after the deletion", len(remaining))
+		}
+
+		var events []models.OutboxEvent
+		if err := db.Where("aggregate_type = ? AND aggregate_id = ?", models.AggregateUser, user.ID).Find(&events).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("got %d events of the user", len(events))
+		}
+		if strings.Contains(events[0].Payload, "ana@example.com") || strings.Contains(events[0].Payload, "García") {
+			t.Errorf("event payload still has personal data: %s", events[0].Payload)
+		}
+	})
+}
+
+func TestWebhookRepositoryListDueDeliveries(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *gorm.DB) {
+		subscriptions := []models.WebhookSubscription{
+			{URL: "https://example.com/active", Secret: "secret", Events: models.WebhookEventPetCreated, Active: true, CreatedByID: 1},
+			{URL: "https://example.com/inactive", Secret: "secret", Events: models.WebhookEventPetCreated, Active: false, CreatedByID: 1},
+		}
+		if err := db.Create(&subscriptions).Error; err != nil {
+			t.Fatalf("failed to create subscriptions: %v", err)
+		}
+
+		now := time.Now()
+		past := now.Add(-time.Minute)
+		future := now.Add(time.Minute)
+		deliveries := []models.WebhookDelivery{
+			{SubscriptionID: subscriptions[0].ID, EventID: "due", EventType: models.WebhookEventPetCreated, Payload: "{}", Status: models.WebhookDeliveryPending, NextAttemptAt: &past},
+			{SubscriptionID: subscriptions[0].ID, EventID: "future", EventType: models.WebhookEventPetCreated, Payload: "{}", Status: models.WebhookDeliveryPending, NextAttemptAt: &future},
+			{SubscriptionID: subscriptions[0].ID, EventID: "failed", EventType: models.WebhookEventPetCreated, Payload: "{}", Status: models.WebhookDeliveryFailed},
+			{SubscriptionID: subscriptions[1].ID, EventID: "inactive", EventType: models.WebhookEventPetCreated, Payload: "{}", Status: models.WebhookDeliveryPending, NextAttemptAt: &past},
+		}
+		if err := db.Create(&deliveries).Error; err != nil {
+			t.Fatalf("failed to create deliveries: %v", err)
+		}
+
+		repository := NewWebhookRepositorySQLServer(db, 5*time.Second)
+
+		due, err := repository.ListDueDeliveries(context.Background(), now, 10, nil)
+		if err != nil {
+			t.Fatalf("ListDueDeliveries returned %v", err)
+		}
+		if len(due) != 1 || due[0].EventID != "due" {
+			t.Fatalf("got %d deliveries, want only the due one of the active subscription", len(due))
+		}
+		if due[0].Subscription.URL != subscriptions[0].URL {
+			t.Errorf("subscription was not preloaded: %+v", due[0].Subscription)
+		}
+	})
+}
@@ -1,18 +1,20 @@
 package repositories
 
 import (
-	"fmt"
+	"context"
 	"go-api-find-my-friend/internal/models"
 	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/logger"
 	"go-api-find-my-friend/pkg/storage_provider"
 	"mime/multipart"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type SagaStep interface {
-	Execute() error
-	Compensate() error
+	Execute(ctx context.Context) error
+	Compensate(ctx context.Context) error
 	GetName() string
 	SetNext(next SagaStep)
 	SetPrevious(prev SagaStep)
@@ -22,15 +24,24 @@ type SagaStep interface {
 	SetExecuted(executed bool)
 }
 
+// SagaObserver recibe el resultado de cada paso ejecutado o compensado, por ejemplo para exponer métricas
+type SagaObserver interface {
+	StepExecuted(step string, err error)
+	StepCompensated(step string, err error)
+}
+
 type SagaOrchestrator struct {
-	head SagaStep
-	tail SagaStep
+	head     SagaStep
+	tail     SagaStep
+	observer SagaObserver
 }
 
-func NewSagaOrchestrator() *SagaOrchestrator {
+// NewSagaOrchestrator acepta un observer nil
+func NewSagaOrchestrator(observer SagaObserver) *SagaOrchestrator {
 	return &SagaOrchestrator{
-		head: nil,
-		tail: nil,
+		head:     nil,
+		tail:     nil,
+		observer: observer,
 	}
 }
 
@@ -52,12 +63,16 @@ func (s *SagaOrchestrator) addStep(step SagaStep) {
 	s.tail = step
 }
 
-func (s *SagaOrchestrator) Run() error {
+func (s *SagaOrchestrator) Run(ctx context.Context) error {
 	current := s.head
 
 	for current != nil {
-		if err := current.Execute(); err != nil {
-			s.rollback(current)
+		err := current.Execute(ctx)
+		if s.observer != nil {
+			s.observer.StepExecuted(current.GetName(), err)
+		}
+		if err != nil {
+			s.rollback(ctx, current)
 			return err
 		}
 		current.SetExecuted(true)
@@ -67,13 +82,20 @@ func (s *SagaOrchestrator) Run() error {
 	return nil
 }
 
-func (s *SagaOrchestrator) rollback(failedStep SagaStep) {
+// rollback compensa los pasos ya ejecutados. Usa un contexto que no se cancela con el original:
+// si el cliente se desconectó igual hay que deshacer lo hecho
+func (s *SagaOrchestrator) rollback(ctx context.Context, failedStep SagaStep) {
+	ctx = context.WithoutCancel(ctx)
 	current := failedStep.GetPrevious()
 
 	for current != nil {
 		if current.IsExecuted() {
-			if err := current.Compensate(); err != nil {
-				fmt.Printf("Warning: Failed to compensate step %s: %v\n", current.GetName(), err)
+			err := current.Compensate(ctx)
+			if s.observer != nil {
+				s.observer.StepCompensated(current.GetName(), err)
+			}
+			if err != nil {
+				logger.FromContext(ctx).Warn("failed to compensate saga step", "step", current.GetName(), logger.Err(err))
 			}
 		}
 		current = current.GetPrevious()
@@ -99,20 +121,21 @@ func NewUploadPictureStep(pet *models.Pet, picture *multipart.FileHeader, storag
 	}
 }
 
-func (s *UploadPictureStep) Execute() error {
-	pictureURL, err := s.storageProvider.Upload(s.picture)
+func (s *UploadPictureStep) Execute(ctx context.Context) error {
+	pictureURL, err := s.storageProvider.Upload(ctx, s.picture)
 	if err != nil {
 		return errors.NewInternalServerError("Failed to upload picture")
 	}
 
 	s.uploaded = true
+	s.pictureURL = pictureURL
 	s.pet.PictureURL = pictureURL
 	return nil
 }
 
-func (s *UploadPictureStep) Compensate() error {
+func (s *UploadPictureStep) Compensate(ctx context.Context) error {
 	if s.uploaded && s.pictureURL != "" {
-		return s.storageProvider.Delete(s.pictureURL)
+		return s.storageProvider.Delete(ctx, s.pictureURL)
 	}
 	return nil
 }
@@ -150,32 +173,46 @@ func (s *UploadPictureStep) SetExecuted(executed bool) {
 }
 
 type CreatePetStep struct {
-	pet      *models.Pet
-	db       *gorm.DB
-	created  bool
-	executed bool
-	next     SagaStep
-	previous SagaStep
+	pet          *models.Pet
+	db           *gorm.DB
+	queryTimeout time.Duration
+	signals      *Signals
+	created      bool
+	executed     bool
+	next         SagaStep
+	previous     SagaStep
 }
 
-func NewCreatePetStep(pet *models.Pet, db *gorm.DB) *CreatePetStep {
+func NewCreatePetStep(pet *models.Pet, db *gorm.DB, queryTimeout time.Duration, signals *Signals) *CreatePetStep {
 	return &CreatePetStep{
-		pet: pet,
-		db:  db,
+		pet:          pet,
+		db:           db,
+		queryTimeout: queryTimeout,
+		signals:      signals,
 	}
 }
 
-func (s *CreatePetStep) Execute() error {
-	err := s.db.Create(s.pet).Error
+// Execute guarda la mascota y su evento PetCreated en la misma transacción
+func (s *CreatePetStep) Execute(ctx context.Context) error {
+	db, cancel := withContext(ctx, s.db, s.queryTimeout)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(s.pet).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, models.AggregatePet, s.pet.ID, models.EventPetCreated, models.NewPetEventData(s.pet))
+	})
 	if err != nil {
 		return errors.NewInternalServerError("Failed to create pet")
 	}
 
+	s.signals.notifyOutbox()
 	s.created = true
 	return nil
 }
 
-func (s *CreatePetStep) Compensate() error {
+func (s *CreatePetStep) Compensate(ctx context.Context) error {
 	return nil
 }
 
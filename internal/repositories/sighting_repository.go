@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SightingRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewSightingRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *SightingRepositorySQLServer {
+	return &SightingRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *SightingRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+func (r *SightingRepositorySQLServer) Create(ctx context.Context, sighting *models.Sighting) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(sighting).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to create sighting")
+	}
+	return nil
+}
+
+func (r *SightingRepositorySQLServer) ListByPetID(ctx context.Context, petID int) ([]models.Sighting, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var sightings []models.Sighting
+
+	err := db.Preload("Reporter").Where("pet_id = ?", petID).Order("seen_at DESC").Find(&sightings).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list sightings")
+	}
+
+	return sightings, nil
+}
@@ -0,0 +1,52 @@
+package repositories
+
+// Signals avisa a los procesos en segundo plano de una App que hay trabajo nuevo sin esperar al
+// próximo poll. Cada App tiene los suyos, así dos instancias en el mismo proceso no se despiertan
+// entre sí. Un *Signals nil no avisa a nadie, como en fmfctl
+type Signals struct {
+	outbox chan struct{}
+	jobs   chan struct{}
+}
+
+func NewSignals() *Signals {
+	return &Signals{
+		outbox: make(chan struct{}, 1),
+		jobs:   make(chan struct{}, 1),
+	}
+}
+
+// Outbox se activa cada vez que se confirma una transacción que escribió en el outbox
+func (s *Signals) Outbox() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.outbox
+}
+
+// Jobs se activa cada vez que se confirma una transacción que encoló trabajos
+func (s *Signals) Jobs() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.jobs
+}
+
+func (s *Signals) notifyOutbox() {
+	if s == nil {
+		return
+	}
+	select {
+	case s.outbox <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Signals) notifyJobs() {
+	if s == nil {
+		return
+	}
+	select {
+	case s.jobs <- struct{}{}:
+	default:
+	}
+}
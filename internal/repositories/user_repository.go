@@ -1,43 +1,57 @@
 package repositories
 
 import (
+	"context"
+	"fmt"
 	"go-api-find-my-friend/internal/models"
-	"go-api-find-my-friend/pkg/database"
 	"go-api-find-my-friend/pkg/errors"
-	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type UserRepositorySQLServer struct {
-	db *gorm.DB
+	db           *gorm.DB
+	queryTimeout time.Duration
+	signals      *Signals
 }
 
-var (
-	userRepositoryInstance *UserRepositorySQLServer
-	userRepositoryOnce     sync.Once
-)
+func NewUserRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration, signals *Signals) *UserRepositorySQLServer {
+	return &UserRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+		signals:      signals,
+	}
+}
 
-func NewUserRepositorySQLServer() *UserRepositorySQLServer {
-	userRepositoryOnce.Do(func() {
-		userRepositoryInstance = &UserRepositorySQLServer{
-			db: database.DB,
-		}
-	})
-	return userRepositoryInstance
+func (r *UserRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
 }
 
-func (r *UserRepositorySQLServer) Create(user *models.User) error {
-	err := r.db.Create(user).Error
+func (r *UserRepositorySQLServer) Create(ctx context.Context, user *models.User) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, models.AggregateUser, user.ID, models.EventUserRegistered, models.NewUserEventData(user))
+	})
 	if err != nil {
 		return errors.NewInternalServerError("Failed to create user")
 	}
+
+	r.signals.notifyOutbox()
 	return nil
 }
 
-func (r *UserRepositorySQLServer) ExistsByEmail(email string) (bool, error) {
+func (r *UserRepositorySQLServer) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := db.Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return false, nil
@@ -47,9 +61,12 @@ func (r *UserRepositorySQLServer) ExistsByEmail(email string) (bool, error) {
 	return true, nil
 }
 
-func (r *UserRepositorySQLServer) ExistsByID(id int) (bool, error) {
+func (r *UserRepositorySQLServer) ExistsByID(ctx context.Context, id int) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := db.Where("id = ?", id).First(&user).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return false, nil
@@ -59,20 +76,159 @@ func (r *UserRepositorySQLServer) ExistsByID(id int) (bool, error) {
 	return true, nil
 }
 
-func (r *UserRepositorySQLServer) GetByID(id int) (*models.User, error) {
+func (r *UserRepositorySQLServer) GetByID(ctx context.Context, id int) (*models.User, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := db.Where("id = ?", id).First(&user).Error
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("user with id %d not found", id))
+		}
 		return nil, errors.NewInternalServerError("Failed to get user")
 	}
 	return &user, nil
 }
 
-func (r *UserRepositorySQLServer) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepositorySQLServer) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := db.Where("email = ?", email).First(&user).Error
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("user not found")
+		}
 		return nil, errors.NewInternalServerError("Failed to get user by email")
 	}
 	return &user, nil
 }
+
+func (r *UserRepositorySQLServer) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to update user")
+	}
+	return nil
+}
+
+// AddWarning suma una advertencia de moderación al usuario
+func (r *UserRepositorySQLServer) AddWarning(ctx context.Context, id int) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.User{}).Where("id = ?", id).UpdateColumn("warnings", gorm.Expr("warnings + ?", 1)).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to update user")
+	}
+	return nil
+}
+
+// ClaimTOTPStep registra step como el último paso TOTP usado. Devuelve false si ya se había
+// aceptado ese paso o uno posterior, así un código no sirve dos veces aunque lleguen a la vez
+func (r *UserRepositorySQLServer) ClaimTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return false, errors.NewInternalServerError("Failed to update user")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Anonymize da de baja al usuario en una sola transacción: borra sus mascotas (con sus fotos y
+// eventos), sus conversaciones, códigos de recuperación, preferencias y los emails que lo mencionan,
+// quita las IPs de su auditoría, reemplaza sus datos en los eventos del outbox y borra sus datos de
+// contacto y de MFA antes del soft delete. El email se reemplaza por uno único para liberar el
+// original y respetar el índice unique
+func (r *UserRepositorySQLServer) Anonymize(ctx context.Context, id int) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	deletedEmail := fmt.Sprintf("deleted-user-%d@deleted.invalid", id)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+
+		var pets []models.Pet
+		if err := tx.Where("user_id = ?", id).Find(&pets).Error; err != nil {
+			return err
+		}
+		for i := range pets {
+			if err := deletePet(tx, &pets[i]); err != nil {
+				return err
+			}
+		}
+
+		conversations := tx.Model(&models.Conversation{}).Select("id").Where("owner_id = ? OR finder_id = ?", id, id)
+		if err := tx.Where("conversation_id IN (?)", conversations).Delete(&models.Message{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id = ? OR finder_id = ?", id, id).Delete(&models.Conversation{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", id).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.NotificationPreference{}).Error; err != nil {
+			return err
+		}
+
+		// Los eventos de auditoría se conservan, pero sin la IP ni el email del usuario
+		if err := tx.Model(&models.AuditEvent{}).Where("actor_id = ? OR subject = ?", id, user.Email).Update("ip", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AuditEvent{}).Where("subject = ?", user.Email).Update("subject", deletedEmail).Error; err != nil {
+			return err
+		}
+
+		if err := deleteUserEmailJobs(tx, id); err != nil {
+			return err
+		}
+
+		user.Name = "Usuario"
+		user.LastName = "Eliminado"
+		user.Email = deletedEmail
+		if err := scrubUserEvents(tx, &user); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"name":         user.Name,
+			"last_name":    user.LastName,
+			"email":        user.Email,
+			"phone":        "",
+			"password":     "",
+			"totp_secret":  "",
+			"totp_enabled": false,
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.User{}, id).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("User not found")
+		}
+		return errors.NewInternalServerError("Failed to delete user")
+	}
+
+	r.signals.notifyOutbox()
+	r.signals.notifyJobs()
+	return nil
+}
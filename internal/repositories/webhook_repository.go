@@ -0,0 +1,265 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepositorySQLServer struct {
+	db           *gorm.DB
+	queryTimeout time.Duration
+}
+
+func NewWebhookRepositorySQLServer(db *gorm.DB, queryTimeout time.Duration) *WebhookRepositorySQLServer {
+	return &WebhookRepositorySQLServer{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *WebhookRepositorySQLServer) conn(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return withContext(ctx, r.db, r.queryTimeout)
+}
+
+func (r *WebhookRepositorySQLServer) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(subscription).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to create webhook")
+	}
+	return nil
+}
+
+func (r *WebhookRepositorySQLServer) GetByID(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var subscription models.WebhookSubscription
+
+	err := db.Where("id = ?", id).First(&subscription).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("webhook with id %d not found", id))
+		}
+		return nil, errors.NewInternalServerError("Failed to get webhook")
+	}
+
+	return &subscription, nil
+}
+
+func (r *WebhookRepositorySQLServer) List(ctx context.Context) ([]models.WebhookSubscription, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var subscriptions []models.WebhookSubscription
+
+	err := db.Order("created_at DESC").Find(&subscriptions).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list webhooks")
+	}
+
+	return subscriptions, nil
+}
+
+// ListActiveByEvent filtra en memoria porque los eventos se guardan como una lista separada por comas
+func (r *WebhookRepositorySQLServer) ListActiveByEvent(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var subscriptions []models.WebhookSubscription
+
+	err := db.Where("active = ?", true).Find(&subscriptions).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list webhooks")
+	}
+
+	result := make([]models.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if subscription.Listens(eventType) {
+			result = append(result, subscription)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *WebhookRepositorySQLServer) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.WebhookSubscription{}).Where("id = ?", id).Updates(updates).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to update webhook")
+	}
+	return nil
+}
+
+func (r *WebhookRepositorySQLServer) Delete(ctx context.Context, id int) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Delete(&models.WebhookSubscription{}, id).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to delete webhook")
+	}
+	return nil
+}
+
+// CreateDelivery guarda el envío y devuelve false si ya existía uno con la misma suscripción,
+// evento y número de reenvío. El índice único decide entre instancias que encolan a la vez;
+// como cada motor informa la violación distinto, si el insert falla se busca la fila existente
+func (r *WebhookRepositorySQLServer) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Create(delivery).Error
+	if err == nil {
+		return true, nil
+	}
+
+	var count int64
+	err = db.Model(&models.WebhookDelivery{}).
+		Where("subscription_id = ? AND event_id = ? AND redelivery = ?", delivery.SubscriptionID, delivery.EventID, delivery.Redelivery).
+		Count(&count).Error
+	if err != nil || count == 0 {
+		return false, errors.NewInternalServerError("Failed to create webhook delivery")
+	}
+	return false, nil
+}
+
+// LastRedelivery devuelve el número del último reenvío del evento a la suscripción, 0 si no hubo
+func (r *WebhookRepositorySQLServer) LastRedelivery(ctx context.Context, subscriptionID int, eventID string) (int, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var last int
+
+	err := db.Model(&models.WebhookDelivery{}).
+		Select("COALESCE(MAX(redelivery), 0)").
+		Where("subscription_id = ? AND event_id = ?", subscriptionID, eventID).
+		Scan(&last).Error
+	if err != nil {
+		return 0, errors.NewInternalServerError("Failed to get webhook delivery")
+	}
+
+	return last, nil
+}
+
+func (r *WebhookRepositorySQLServer) GetDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var delivery models.WebhookDelivery
+
+	err := db.Preload("Subscription").Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("webhook delivery with id %d not found", id))
+		}
+		return nil, errors.NewInternalServerError("Failed to get webhook delivery")
+	}
+
+	return &delivery, nil
+}
+
+func (r *WebhookRepositorySQLServer) ListDeliveries(ctx context.Context, subscriptionID int, status string, search *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	query := db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.NewInternalServerError("Failed to list webhook deliveries")
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, search.Size)
+	err := query.Order("created_at " + search.SortDir).
+		Offset(pagination.CalculateOffset(search.Page, search.Size)).
+		Limit(search.Size).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list webhook deliveries")
+	}
+
+	result := pagination.CreatePaginationResult(&deliveries, total, *search)
+	return &result, nil
+}
+
+// ListDueDeliveries devuelve los envíos pendientes cuyo próximo intento ya venció, salvo los de
+// excludeSubscriptions. Los de suscripciones desactivadas quedan pendientes y se retoman si se la
+// vuelve a activar
+func (r *WebhookRepositorySQLServer) ListDueDeliveries(ctx context.Context, now time.Time, limit int, excludeSubscriptions []int) ([]models.WebhookDelivery, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	var deliveries []models.WebhookDelivery
+
+	active := db.Model(&models.WebhookSubscription{}).Select("id").Where("active = ?", true)
+
+	query := db.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Where("subscription_id IN (?)", active)
+	if len(excludeSubscriptions) > 0 {
+		query = query.Where("subscription_id NOT IN ?", excludeSubscriptions)
+	}
+
+	err := query.
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to list due webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDelivery reserva el envío hasta leaseUntil. El número de intentos funciona como
+// versión: si otra instancia lo tomó primero, no se actualiza ninguna fila.
+func (r *WebhookRepositorySQLServer) ClaimDelivery(ctx context.Context, id int, attempts int, leaseUntil time.Time) (bool, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, models.WebhookDeliveryPending, attempts).
+		Updates(map[string]interface{}{"next_attempt_at": leaseUntil, "attempts": attempts + 1})
+	if result.Error != nil {
+		return false, errors.NewInternalServerError("Failed to claim webhook delivery")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *WebhookRepositorySQLServer) UpdateDelivery(ctx context.Context, id int, updates map[string]interface{}) error {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	err := db.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(updates).Error
+	if err != nil {
+		return errors.NewInternalServerError("Failed to update webhook delivery")
+	}
+	return nil
+}
+
+// DeleteDeliveriesBefore borra el historial de envíos terminados
+func (r *WebhookRepositorySQLServer) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	db, cancel := r.conn(ctx)
+	defer cancel()
+
+	result := db.Where("status <> ? AND created_at < ?", models.WebhookDeliveryPending, before).Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return 0, errors.NewInternalServerError("Failed to delete webhook deliveries")
+	}
+	return result.RowsAffected, nil
+}
@@ -3,37 +3,150 @@ package routes
 import (
 	"go-api-find-my-friend/internal/controllers"
 	"go-api-find-my-friend/internal/middleware"
+	"go-api-find-my-friend/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine) {
-	userController := controllers.NewUserController()
-	petController := controllers.NewPetController()
-	authController := controllers.NewAuthController()
+// Dependencies son los controladores y la configuración que necesitan las rutas
+type Dependencies struct {
+	UserController         *controllers.UserController
+	PetController          *controllers.PetController
+	AuthController         *controllers.AuthController
+	AdminController        *controllers.AdminController
+	ConversationController *controllers.ConversationController
+	SightingController     *controllers.SightingController
+	RealtimeController     *controllers.RealtimeController
+	WebhookController      *controllers.WebhookController
+	ModerationController   *controllers.ModerationController
+	HealthController       *controllers.HealthController
+	// MetricsController es opcional; sin él no se registra /metrics
+	MetricsController *controllers.MetricsController
+	// JWTSecret es la clave con la que se validan los tokens de sesión
+	JWTSecret string
+	// Users resuelve en cada request el usuario del token con su rol actual
+	Users middleware.UserLookup
+}
+
+func SetupRoutes(router *gin.Engine, deps Dependencies) {
+	userController := deps.UserController
+	petController := deps.PetController
+	authController := deps.AuthController
+	adminController := deps.AdminController
+	conversationController := deps.ConversationController
+	sightingController := deps.SightingController
+	realtimeController := deps.RealtimeController
+	webhookController := deps.WebhookController
+	moderationController := deps.ModerationController
+	healthController := deps.HealthController
+	authRequired := middleware.AuthMiddleware(deps.JWTSecret, deps.Users)
+
+	router.GET("/healthz", healthController.Liveness)
+	router.GET("/readyz", healthController.Readiness)
+	if deps.MetricsController != nil {
+		router.GET("/metrics", deps.MetricsController.Metrics)
+	}
 
 	v1 := router.Group("/api/v1")
 	{
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", authController.Login)
+			auth.POST("/mfa/verify", authController.VerifyMFA)
+			auth.POST("/stream-token", authRequired, authController.StreamToken)
+
+			mfa := auth.Group("/mfa")
+			mfa.Use(authRequired)
+			{
+				mfa.POST("/enroll", authController.EnrollMFA)
+				mfa.POST("/confirm", authController.ConfirmMFA)
+				mfa.DELETE("", authController.DisableMFA)
+			}
 		}
 
 		users := v1.Group("/users")
 		{
 			users.POST("/", userController.Register)
+
+			me := users.Group("/me")
+			me.Use(authRequired)
+			{
+				me.GET("", userController.GetMe)
+				me.PATCH("", userController.UpdateMe)
+				me.DELETE("", userController.DeleteMe)
+				me.GET("/export", userController.ExportMe)
+				me.PUT("/password", userController.ChangePassword)
+				me.GET("/pets", userController.GetMyPets)
+				me.GET("/notifications", userController.GetNotificationPreferences)
+				me.PUT("/notifications", userController.UpdateNotificationPreferences)
+			}
+
+			users.PATCH("/:id/role", authRequired, middleware.RequireRole(models.RoleAdmin), userController.UpdateRole)
 		}
 
 		pets := v1.Group("/pets")
-		pets.Use(middleware.AuthMiddleware())
+		pets.Use(authRequired)
 		{
 			pets.POST("/", petController.CreatePet)
 			pets.GET("/", petController.SearchPets)
 			pets.GET("/:id", petController.GetPet)
 			pets.PUT("/:id", petController.UpdatePet)
 			pets.PATCH("/:id/mark-found", petController.UpdatePetMarkAsFound)
+			pets.PATCH("/:id/status", petController.ChangeStatus)
+			pets.GET("/:id/history", petController.GetStatusHistory)
+			pets.POST("/:id/reactivate", petController.ReactivatePet)
+			pets.POST("/:id/contact-reveal", petController.RevealContact)
+			pets.POST("/:id/conversations", conversationController.StartConversation)
+			pets.POST("/:id/sightings", sightingController.CreateSighting)
+			pets.GET("/:id/sightings", sightingController.ListSightings)
+			pets.POST("/:id/reports", moderationController.ReportPet)
 			pets.DELETE("/:id", petController.DeletePet)
 		}
+
+		conversations := v1.Group("/conversations")
+		conversations.Use(authRequired)
+		{
+			conversations.GET("", conversationController.ListConversations)
+			conversations.GET("/:id", conversationController.GetConversation)
+			conversations.GET("/:id/messages", conversationController.ListMessages)
+			conversations.POST("/:id/messages", conversationController.SendMessage)
+			conversations.POST("/:id/block", conversationController.Block)
+			conversations.DELETE("/:id/block", conversationController.Unblock)
+			conversations.POST("/:id/share-contact", conversationController.ShareContact)
+		}
+
+		v1.GET("/events/stream", middleware.QueryTokenAuthMiddleware(deps.JWTSecret, deps.Users), realtimeController.Stream)
+
+		moderation := v1.Group("/moderation")
+		moderation.Use(authRequired, middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+		{
+			moderation.GET("/reports", moderationController.ListReports)
+			moderation.POST("/reports/:id/dismiss", moderationController.DismissReport)
+			moderation.POST("/pets/:id/hide", moderationController.HidePet)
+			moderation.POST("/pets/:id/restore", moderationController.RestorePet)
+			moderation.POST("/pets/:id/remove", moderationController.RemovePet)
+			moderation.POST("/users/:id/warn", moderationController.WarnUser)
+			moderation.POST("/users/:id/ban", moderationController.BanUser)
+			moderation.DELETE("/users/:id/ban", moderationController.UnbanUser)
+		}
+
+		admin := v1.Group("/admin")
+		admin.Use(authRequired, middleware.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/audit-events", adminController.ListAuditEvents)
+			admin.GET("/jobs", adminController.ListJobs)
+			admin.GET("/jobs/:id", adminController.GetJob)
+			admin.POST("/jobs/:id/retry", adminController.RetryJob)
+
+			admin.POST("/webhooks", webhookController.CreateWebhook)
+			admin.GET("/webhooks", webhookController.ListWebhooks)
+			admin.GET("/webhooks/:id", webhookController.GetWebhook)
+			admin.PATCH("/webhooks/:id", webhookController.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", webhookController.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", webhookController.ListDeliveries)
+			admin.POST("/webhooks/:id/test", webhookController.TestWebhook)
+			admin.POST("/webhook-deliveries/:id/redeliver", webhookController.Redeliver)
+		}
 	}
 
 	router.GET("/", func(c *gin.Context) {
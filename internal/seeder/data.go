@@ -0,0 +1,41 @@
+package seeder
+
+import "go-api-find-my-friend/internal/models"
+
+// staffAccounts son las cuentas fijas para probar la administración y la moderación
+var staffAccounts = []models.User{
+	{Name: "Admin", LastName: "Find My Friend", Email: "admin@findmyfriend.local", Phone: "+54 11 5555-0000", Role: models.RoleAdmin},
+	{Name: "Mora", LastName: "Moderadora", Email: "moderator@findmyfriend.local", Phone: "+54 11 5555-0001", Role: models.RoleModerator},
+}
+
+var firstNames = []string{
+	"Lucía", "Martín", "Sofía", "Mateo", "Valentina", "Santiago", "Camila", "Benjamín", "Julieta", "Tomás",
+	"Florencia", "Joaquín", "Agustina", "Facundo", "Milagros", "Nicolás", "Carolina", "Federico", "Paula", "Ignacio",
+}
+
+var lastNames = []string{
+	"González", "Rodríguez", "Gómez", "Fernández", "López", "Díaz", "Martínez", "Pérez", "García", "Sánchez",
+	"Romero", "Sosa", "Álvarez", "Torres", "Ruiz", "Ramírez", "Flores", "Acosta", "Benítez", "Medina",
+}
+
+var areaCodes = []string{"11", "221", "223", "341", "342", "351", "261", "381", "387", "299"}
+
+var petNames = []string{
+	"Firulais", "Luna", "Rocco", "Michi", "Coco", "Toby", "Mora", "Simón", "Lola", "Pancho",
+	"Nala", "Milo", "Kira", "Felipe", "Olivia", "Bruno", "Frida", "Tango", "Pipa", "Manchita",
+}
+
+var colors = []string{"negro", "blanco", "marrón", "gris", "atigrado", "dorado", "tricolor", "blanco y negro", "canela", "manchado"}
+
+var details = []string{
+	"tiene collar rojo",
+	"responde a su nombre",
+	"es muy asustadizo",
+	"tiene una cicatriz en la oreja",
+	"lleva chapita con teléfono",
+	"está castrado",
+	"es mayor y camina despacio",
+	"se escapó durante la tormenta",
+	"es muy sociable con otros animales",
+	"necesita medicación diaria",
+}
@@ -0,0 +1,75 @@
+package seeder
+
+import (
+	"bytes"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"mime/multipart"
+	"net/textproto"
+)
+
+const placeholderSize = 256
+
+// placeholderPicture dibuja un PNG con un color de fondo al azar y una franja según el tipo de
+// mascota, y lo arma como el FileHeader que recibiría el controller al subir una foto
+func placeholderPicture(pet *models.Pet, r *rand.Rand) (*multipart.FileHeader, error) {
+	background := color.RGBA{R: uint8(120 + r.Intn(120)), G: uint8(120 + r.Intn(120)), B: uint8(120 + r.Intn(120)), A: 255}
+	stripe := map[string]color.RGBA{
+		"perro": {R: 150, G: 90, B: 40, A: 255},
+		"gato":  {R: 60, G: 60, B: 60, A: 255},
+	}[pet.Type]
+	if stripe.A == 0 {
+		stripe = color.RGBA{R: 40, G: 110, B: 60, A: 255}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, placeholderSize, placeholderSize))
+	for y := 0; y < placeholderSize; y++ {
+		for x := 0; x < placeholderSize; x++ {
+			if y > placeholderSize*2/5 && y < placeholderSize*3/5 {
+				img.Set(x, y, stripe)
+			} else {
+				img.Set(x, y, background)
+			}
+		}
+	}
+
+	var content bytes.Buffer
+	if err := png.Encode(&content, img); err != nil {
+		return nil, err
+	}
+
+	return memoryFileHeader(fmt.Sprintf("%s.png", pet.Type), "image/png", content.Bytes())
+}
+
+// memoryFileHeader arma un multipart.FileHeader con el contenido en memoria; los campos internos
+// del FileHeader solo se completan al leer un formulario, así que se escribe uno y se vuelve a leer
+func memoryFileHeader(filename string, contentType string, content []byte) (*multipart.FileHeader, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="picture"; filename="%s"`, filename))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(int64(len(content)) + 1024)
+	if err != nil {
+		return nil, err
+	}
+
+	return form.File["picture"][0], nil
+}
@@ -0,0 +1,286 @@
+// Package seeder genera datos de prueba realistas: usuarios, mascotas con tipos, razas y
+// ciudades válidos e imágenes de muestra. Con el mismo Seed y la misma fecha de referencia
+// genera siempre los mismos datos.
+package seeder
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/storage_provider"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Password es la contraseña de los usuarios comunes generados. Las cuentas del staff reciben una
+// contraseña aleatoria en cada ejecución, que se devuelve en Result.StaffPasswords
+const Password = "password123"
+
+// ErrNotEmpty se devuelve cuando la base ya tiene usuarios; el seeder no mezcla datos generados con datos reales
+var ErrNotEmpty = errors.New("database already has users")
+
+type Options struct {
+	// Seed determina los datos generados
+	Seed int64
+	// Users es la cantidad de usuarios comunes, además de las cuentas de administrador y moderador
+	Users int
+	// Pets es la cantidad de mascotas publicadas
+	Pets int
+	// Images sube una imagen de muestra por mascota con el StorageProvider configurado
+	Images bool
+	// Now es la fecha de referencia de los tiempos generados; si es cero se usa el comienzo del día actual
+	Now time.Time
+}
+
+// DefaultOptions son los valores que usa el modo desarrollo
+func DefaultOptions() Options {
+	return Options{Seed: 1, Users: 10, Pets: 40, Images: true}
+}
+
+type Result struct {
+	Users []models.User
+	Pets  []models.Pet
+	// StaffPasswords tiene la contraseña de cada cuenta de administración y moderación, por email
+	StaffPasswords map[string]string
+}
+
+type Seeder struct {
+	db              *gorm.DB
+	storageProvider storage_provider.StorageProvider
+}
+
+func New(db *gorm.DB, storageProvider storage_provider.StorageProvider) *Seeder {
+	return &Seeder{db: db, storageProvider: storageProvider}
+}
+
+// Run genera y guarda los datos en una base sin usuarios. Las imágenes se suben antes de la
+// transacción; si algo falla después quedan en el storage hasta que se purgan
+func (s *Seeder) Run(ctx context.Context, opts Options) (*Result, error) {
+	db := s.db.WithContext(ctx)
+
+	var count int64
+	if err := db.Model(&models.User{}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrNotEmpty
+	}
+
+	if opts.Now.IsZero() {
+		now := time.Now()
+		opts.Now = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	staffPasswords := make(map[string]string, len(staffAccounts))
+	staffHashes := make([]string, 0, len(staffAccounts))
+	for _, account := range staffAccounts {
+		password, hash, err := newStaffPassword()
+		if err != nil {
+			return nil, err
+		}
+		staffPasswords[account.Email] = password
+		staffHashes = append(staffHashes, hash)
+	}
+
+	g := &generator{rand: rand.New(rand.NewSource(opts.Seed)), now: opts.Now}
+	users := g.users(opts.Users, staffHashes, string(hashedPassword))
+	pets := make([]models.Pet, 0, opts.Pets)
+	for i := 0; i < opts.Pets; i++ {
+		pets = append(pets, g.pet())
+	}
+
+	if opts.Images {
+		for i := range pets {
+			picture, err := placeholderPicture(&pets[i], g.rand)
+			if err != nil {
+				return nil, err
+			}
+			if pets[i].PictureURL, err = s.storageProvider.Upload(ctx, picture); err != nil {
+				return nil, fmt.Errorf("failed to upload placeholder image: %w", err)
+			}
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&users).Error; err != nil {
+			return err
+		}
+
+		// Los usuarios comunes empiezan después de las cuentas del staff
+		members := users[len(staffAccounts):]
+		if len(members) == 0 {
+			members = users
+		}
+		for i := range pets {
+			pets[i].UserID = members[g.ownerIndexes[i]%len(members)].ID
+		}
+
+		if len(pets) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&pets, 100).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Users: users, Pets: pets, StaffPasswords: staffPasswords}, nil
+}
+
+// WriteStaffPasswords escribe las credenciales del staff en w. Es la única vez que se muestran:
+// no se guardan en ningún otro lado ni pasan por el logger
+func (r *Result) WriteStaffPasswords(w io.Writer) {
+	for _, account := range staffAccounts {
+		fmt.Fprintf(w, "%s (%s): %s\n", account.Email, account.Role, r.StaffPasswords[account.Email])
+	}
+}
+
+// newStaffPassword genera la contraseña de una cuenta del staff. No sale del generador con
+// semilla, porque una contraseña que se puede reproducir es tan conocida como una fija
+func newStaffPassword() (string, string, error) {
+	buffer := make([]byte, 12)
+	if _, err := cryptorand.Read(buffer); err != nil {
+		return "", "", err
+	}
+
+	password := base64.RawURLEncoding.EncodeToString(buffer)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return password, string(hash), nil
+}
+
+type generator struct {
+	rand         *rand.Rand
+	now          time.Time
+	ownerIndexes []int
+}
+
+func (g *generator) users(count int, staffHashes []string, hashedPassword string) []models.User {
+	users := make([]models.User, 0, len(staffAccounts)+count)
+	for i, account := range staffAccounts {
+		account.Password = staffHashes[i]
+		account.CreatedAt = g.now.AddDate(0, -6, 0)
+		users = append(users, account)
+	}
+
+	for i := 1; i <= count; i++ {
+		name := pick(g.rand, firstNames)
+		users = append(users, models.User{
+			Name:      name,
+			LastName:  pick(g.rand, lastNames),
+			Email:     fmt.Sprintf("user%d@findmyfriend.local", i),
+			Password:  hashedPassword,
+			Phone:     fmt.Sprintf("+54 %s 555-%04d", pick(g.rand, areaCodes), g.rand.Intn(10000)),
+			Role:      models.RoleUser,
+			CreatedAt: g.now.Add(-time.Duration(g.rand.Intn(180*24)) * time.Hour),
+		})
+	}
+
+	return users
+}
+
+func (g *generator) pet() models.Pet {
+	petType := pick(g.rand, models.PetTypes)
+	province := pick(g.rand, provinces())
+
+	pet := models.Pet{
+		Name:           pick(g.rand, petNames),
+		Type:           petType,
+		Breed:          pick(g.rand, models.PetBreeds[petType]),
+		LastSeenTime:   g.lastSeenTime(),
+		LastSeenPlace:  province + ", " + pick(g.rand, models.CitiesByProvince[province]),
+		ContactPrivacy: pick(g.rand, models.ContactPrivacyOptions),
+	}
+	pet.Description = fmt.Sprintf("%s %s, %s.", strings.ToUpper(pet.Breed[:1])+pet.Breed[1:], pick(g.rand, colors), pick(g.rand, details))
+
+	// Se publica entre una y 36 horas después de perderse, sin pasar la fecha de referencia
+	pet.CreatedAt = pet.LastSeenTime.Add(time.Duration(1+g.rand.Intn(36)) * time.Hour)
+	if pet.CreatedAt.After(g.now) {
+		pet.CreatedAt = g.now
+	}
+	pet.UpdatedAt = pet.CreatedAt
+
+	g.status(&pet)
+	g.ownerIndexes = append(g.ownerIndexes, g.rand.Intn(1<<30))
+	return pet
+}
+
+// lastSeenTime sigue una distribución exponencial con media de cinco días y a lo sumo 60 días
+// atrás: la mayoría de las publicaciones son recientes. La hora se concentra durante el día
+func (g *generator) lastSeenTime() time.Time {
+	days := math.Min(g.rand.ExpFloat64()*5, 60)
+	day := g.now.Add(-time.Duration(days*24) * time.Hour)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+
+	hour := int(math.Round(g.rand.NormFloat64()*3.5 + 15))
+	if hour < 0 || hour > 23 {
+		hour = 8 + g.rand.Intn(14)
+	}
+	return day.Add(time.Duration(hour)*time.Hour + time.Duration(g.rand.Intn(60))*time.Minute)
+}
+
+// status reparte los estados con una proporción parecida a la real: la mayoría siguen perdidas
+func (g *generator) status(pet *models.Pet) {
+	pet.Status = models.PetStatusLost
+
+	roll := g.rand.Intn(100)
+	switch {
+	case roll < 60:
+		return
+	case roll < 75:
+		pet.Status = models.PetStatusSighted
+		return
+	case roll < 90:
+		pet.Status = models.PetStatusFound
+	case roll < 95:
+		pet.Status = models.PetStatusReunited
+	default:
+		archivedAt := g.between(pet.CreatedAt, g.now)
+		pet.Status = models.PetStatusArchived
+		pet.ArchivedAt = &archivedAt
+		return
+	}
+
+	foundAt := g.between(pet.CreatedAt, g.now)
+	pet.IsFound = true
+	pet.FoundAt = &foundAt
+	pet.UpdatedAt = foundAt
+}
+
+func (g *generator) between(from time.Time, to time.Time) time.Time {
+	if !to.After(from) {
+		return from
+	}
+	return from.Add(time.Duration(g.rand.Int63n(int64(to.Sub(from)))))
+}
+
+func pick(r *rand.Rand, values []string) string {
+	return values[r.Intn(len(values))]
+}
+
+// provinces devuelve las provincias ordenadas; el orden de un map no es estable entre ejecuciones
+func provinces() []string {
+	result := make([]string, 0, len(models.CitiesByProvince))
+	for province := range models.CitiesByProvince {
+		result = append(result, province)
+	}
+	sort.Strings(result)
+	return result
+}
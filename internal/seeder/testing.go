@@ -0,0 +1,32 @@
+package seeder
+
+import (
+	"context"
+	"go-api-find-my-friend/pkg/storage_provider"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TestOptions genera un conjunto chico sin imágenes y con una fecha de referencia fija, para que
+// los tests no dependan del día en que se ejecutan
+func TestOptions(seed int64) Options {
+	return Options{
+		Seed:  seed,
+		Users: 3,
+		Pets:  10,
+		Now:   time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// MustRun ejecuta el seeder desde un test y lo corta si falla
+func MustRun(tb testing.TB, db *gorm.DB, storageProvider storage_provider.StorageProvider, opts Options) *Result {
+	tb.Helper()
+
+	result, err := New(db, storageProvider).Run(context.Background(), opts)
+	if err != nil {
+		tb.Fatalf("seeder: %v", err)
+	}
+	return result
+}
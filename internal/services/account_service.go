@@ -0,0 +1,156 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/storage_provider"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDeleteAccountInvalidPassword = errors.NewUnauthorizedError("password is incorrect")
+)
+
+// AccountService agrupa las operaciones sobre los datos personales del usuario
+// exigidas por la Ley 25.326 (acceso y supresión).
+type AccountService struct {
+	userRepository  repositories.UserRepository
+	petRepository   repositories.PetRepository
+	storageProvider storage_provider.StorageProvider
+	loginGuard      *LoginGuard
+}
+
+func NewAccountService(userRepository repositories.UserRepository, petRepository repositories.PetRepository, storageProvider storage_provider.StorageProvider, loginGuard *LoginGuard) *AccountService {
+	return &AccountService{
+		userRepository:  userRepository,
+		petRepository:   petRepository,
+		storageProvider: storageProvider,
+		loginGuard:      loginGuard,
+	}
+}
+
+type accountExportManifest struct {
+	GeneratedAt   time.Time `json:"generated_at"`
+	UserID        int       `json:"user_id"`
+	Photos        []string  `json:"photos"`
+	MissingPhotos []string  `json:"missing_photos,omitempty"`
+}
+
+// DeleteAccount elimina las mascotas del usuario (junto con sus fotos) y anonimiza su cuenta.
+// Todo ocurre en la transacción de Anonymize: si algo falla la cuenta queda como estaba. Una
+// contraseña incorrecta cuenta como intento fallido de login, igual que en ChangePassword
+func (s *AccountService) DeleteAccount(ctx context.Context, userID int, dto *UserDeleteDTO, ip string) error {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.loginGuard.Check(user.Email, ip); err != nil {
+		return err
+	}
+
+	if err := checkPassword(dto.Password, user.Password); err != nil {
+		if err := s.loginGuard.Fail(ctx, user.Email, ip); err != nil {
+			return err
+		}
+		return ErrDeleteAccountInvalidPassword
+	}
+
+	return s.userRepository.Anonymize(ctx, userID)
+}
+
+// ExportData escribe en w un ZIP con el perfil, las mascotas y las fotos del usuario
+func (s *AccountService) ExportData(ctx context.Context, userID int, w io.Writer) error {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	pets, err := s.petRepository.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+
+	if err := writeJSONEntry(archive, "profile.json", user); err != nil {
+		return errors.NewInternalServerError("Failed to export profile")
+	}
+
+	if err := writeJSONEntry(archive, "pets.json", pets); err != nil {
+		return errors.NewInternalServerError("Failed to export pets")
+	}
+
+	manifest := accountExportManifest{
+		GeneratedAt: time.Now().UTC(),
+		UserID:      user.ID,
+		Photos:      []string{},
+	}
+
+	for _, pet := range pets {
+		if pet.PictureURL == "" {
+			continue
+		}
+
+		name := fmt.Sprintf("photos/pet_%d%s", pet.ID, pictureExtension(pet.PictureURL))
+		if err := s.copyPicture(ctx, archive, name, pet.PictureURL); err != nil {
+			logger.FromContext(ctx).Warn("failed to export pet picture", "pet_id", pet.ID, logger.Err(err))
+			manifest.MissingPhotos = append(manifest.MissingPhotos, pet.PictureURL)
+			continue
+		}
+		manifest.Photos = append(manifest.Photos, name)
+	}
+
+	if err := writeJSONEntry(archive, "manifest.json", manifest); err != nil {
+		return errors.NewInternalServerError("Failed to export manifest")
+	}
+
+	if err := archive.Close(); err != nil {
+		return errors.NewInternalServerError("Failed to build export file")
+	}
+
+	return nil
+}
+
+func (s *AccountService) copyPicture(ctx context.Context, archive *zip.Writer, name string, pictureURL string) error {
+	src, err := s.storageProvider.Download(ctx, pictureURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func writeJSONEntry(archive *zip.Writer, name string, value interface{}) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func pictureExtension(pictureURL string) string {
+	ext := path.Ext(pictureURL)
+	if ext == "" || len(ext) > 5 || strings.ContainsAny(ext, "?#/") {
+		return ".jpg"
+	}
+	return ext
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/pagination"
+	"log/slog"
+)
+
+type AuditService struct {
+	auditRepository repositories.AuditRepository
+}
+
+func NewAuditService(auditRepository repositories.AuditRepository) *AuditService {
+	return &AuditService{
+		auditRepository: auditRepository,
+	}
+}
+
+// Record guarda el evento de auditoría. Un fallo al auditar no debe interrumpir
+// la operación que lo origina, por eso el error solo se registra en el log.
+func (s *AuditService) Record(ctx context.Context, event *models.AuditEvent) {
+	if err := s.auditRepository.Create(ctx, event); err != nil {
+		slog.Error("failed to record audit event", "action", event.Action, logger.Err(err))
+	}
+}
+
+func (s *AuditService) Search(ctx context.Context, action string, paginationParams *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	customConfig := pagination.PaginationConfig{
+		DefaultPage:    1,
+		DefaultSize:    20,
+		MaxSize:        100,
+		DefaultSortBy:  "created_at",
+		DefaultSortDir: "DESC",
+	}
+	pagination.NormalizeParams(paginationParams, customConfig)
+
+	return s.auditRepository.Search(ctx, action, paginationParams)
+}
@@ -1,9 +1,10 @@
 package services
 
 import (
+	"context"
 	"go-api-find-my-friend/pkg/config"
 	"go-api-find-my-friend/pkg/errors"
-	"sync"
+	"math"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,40 +13,90 @@ import (
 
 var (
 	ErrInvalidCredentials = errors.NewUnauthorizedError("invalid credentials")
+	ErrInvalidMFAToken    = errors.NewUnauthorizedError("invalid or expired two-factor session")
 )
 
-var (
-	authServiceInstance *AuthService
-	authServiceOnce     sync.Once
+const (
+	TokenPurposeMFA    = "mfa_pending"
+	TokenPurposeStream = "stream"
+	mfaTokenTTL        = 5 * time.Minute
+	StreamTokenTTL     = time.Minute
 )
 
 type AuthService struct {
 	secretKey   string
 	userService *UserService
+	mfaService  *MFAService
+	loginGuard  *LoginGuard
+	dummyHash   []byte
 }
 
-func NewAuthService() *AuthService {
-	authServiceOnce.Do(func() {
-		authServiceInstance = &AuthService{
-			secretKey:   config.ConfigInstance.JWT.Secret,
-			userService: NewUserService(),
-		}
-	})
-	return authServiceInstance
+func NewAuthService(userService *UserService, mfaService *MFAService, loginGuard *LoginGuard, jwtConfig config.JWTConfig) *AuthService {
+	// Hash usado para comparar cuando el email no existe, así la respuesta
+	// tarda lo mismo que con un usuario real
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("find-my-friend-dummy-password"), bcrypt.DefaultCost)
+
+	return &AuthService{
+		secretKey:   jwtConfig.Secret,
+		userService: userService,
+		mfaService:  mfaService,
+		loginGuard:  loginGuard,
+		dummyHash:   dummyHash,
+	}
 }
 
 type Claims struct {
 	UserID int    `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role"`
+	// Purpose vacío indica un token de sesión completo
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func (s *AuthService) GenerateToken(userID int, email string) (string, error) {
+func (s *AuthService) GenerateToken(userID int, email string, role string) (string, error) {
 	claims := &Claims{
 		UserID: userID,
 		Email:  email,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			// El middleware compara iat con el último cambio de contraseña
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.secretKey))
+}
+
+// generateMFAToken emite un token de corta duración que solo sirve para completar el segundo factor
+func (s *AuthService) generateMFAToken(userID int, email string) (string, error) {
+	claims := &Claims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: TokenPurposeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.secretKey))
+}
+
+// GenerateStreamToken emite un token de corta duración que solo sirve para abrir el stream de
+// eventos. Es el único que se acepta en la URL, que queda en los logs del proxy; lleva iat para
+// que un cambio de contraseña también lo invalide
+func (s *AuthService) GenerateStreamToken(userID int, email string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: TokenPurposeStream,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(StreamTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -53,22 +104,119 @@ func (s *AuthService) GenerateToken(userID int, email string) (string, error) {
 	return token.SignedString([]byte(s.secretKey))
 }
 
-func (s *AuthService) AuthenticateUser(email string, password string) (string, error) {
-	user, err := s.userService.GetByEmail(email)
+func (s *AuthService) parseMFAToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(s.secretKey), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidMFAToken
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || claims.Purpose != TokenPurposeMFA {
+		return nil, ErrInvalidMFAToken
+	}
+
+	return claims, nil
+}
+
+func (s *AuthService) AuthenticateUser(ctx context.Context, email string, password string, ip string) (*LoginResult, error) {
+	if err := s.loginGuard.Check(email, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetByEmail(ctx, email)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		if !isNotFound(err) {
+			return nil, err
+		}
+
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return nil, s.registerFailure(ctx, email, ip)
 	}
 
 	if err := checkPassword(password, user.Password); err != nil {
-		return "", ErrInvalidCredentials
+		return nil, s.registerFailure(ctx, email, ip)
+	}
+
+	if user.IsBanned() {
+		return nil, ErrAccountSuspended
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := s.generateMFAToken(user.ID, user.Email)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	s.loginGuard.Succeed(email)
+
+	token, err := s.GenerateToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{Token: token}, nil
+}
+
+// VerifyMFA completa el login de un usuario con 2FA. Los códigos inválidos cuentan
+// como intentos fallidos para que el código de 6 dígitos no pueda adivinarse por fuerza bruta.
+func (s *AuthService) VerifyMFA(ctx context.Context, dto *MFAVerifyDTO, ip string) (*LoginResult, error) {
+	claims, err := s.parseMFAToken(dto.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loginGuard.Check(claims.Email, ip); err != nil {
+		return nil, err
 	}
 
-	token, err := s.GenerateToken(user.ID, user.Email)
+	user, err := s.userService.GetByID(ctx, claims.UserID)
 	if err != nil {
-		return "", err
+		return nil, ErrInvalidMFAToken
 	}
 
-	return token, nil
+	if user.IsBanned() {
+		return nil, ErrAccountSuspended
+	}
+
+	valid, err := s.mfaService.VerifyCode(ctx, user, dto.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !valid {
+		if err := s.loginGuard.Fail(ctx, user.Email, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidMFACode
+	}
+
+	s.loginGuard.Succeed(user.Email)
+
+	token, err := s.GenerateToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{Token: token}, nil
+}
+
+func (s *AuthService) registerFailure(ctx context.Context, email string, ip string) error {
+	if err := s.loginGuard.Fail(ctx, email, ip); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+func newLoginLockedError(retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return errors.NewTooManyRequestsError("Too many failed login attempts, try again later", seconds)
 }
 
 func checkPassword(password string, hashedPassword string) error {
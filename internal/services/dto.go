@@ -1,12 +1,18 @@
 package services
 
 import (
+	"fmt"
 	"go-api-find-my-friend/internal/models"
 	"mime/multipart"
+	"net/url"
+	"regexp"
 	"slices"
 	"strings"
+	"time"
 )
 
+var phoneRegexp = regexp.MustCompile(`^\+?[0-9 ()-]{6,20}$`)
+
 type AuthCredentials struct {
 	Token        string `json:"token"`
 	UserID       int    `json:"user_id"`
@@ -16,6 +22,223 @@ type AuthCredentials struct {
 	UserPhone    string `json:"user_phone"`
 }
 
+type LoginResult struct {
+	Token       string `json:"token,omitempty"`
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+type PetContactDTO struct {
+	OwnerName     string `json:"owner_name"`
+	OwnerLastName string `json:"owner_last_name"`
+	OwnerPhone    string `json:"owner_phone,omitempty"`
+	OwnerEmail    string `json:"owner_email,omitempty"`
+}
+
+type ParticipantDTO struct {
+	UserID      int    `json:"user_id"`
+	DisplayName string `json:"display_name"`
+	Phone       string `json:"phone,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
+type ConversationDTO struct {
+	ID                       int            `json:"id"`
+	PetID                    int            `json:"pet_id"`
+	PetName                  string         `json:"pet_name"`
+	IsOwner                  bool           `json:"is_owner"`
+	Counterpart              ParticipantDTO `json:"counterpart"`
+	UnreadCount              int64          `json:"unread_count"`
+	SharesContact            bool           `json:"shares_contact"`
+	CounterpartSharesContact bool           `json:"counterpart_shares_contact"`
+	Blocked                  bool           `json:"blocked"`
+	BlockedByMe              bool           `json:"blocked_by_me"`
+	LastMessageAt            *time.Time     `json:"last_message_at"`
+	CreatedAt                time.Time      `json:"created_at"`
+}
+
+type ConversationCreateDTO struct {
+	Message string `json:"message"`
+}
+
+// Validate aplica al primer mensaje, que es opcional, los mismos límites que a MessageCreateDTO
+func (dto *ConversationCreateDTO) Validate(errors *map[string]string) bool {
+	if len(strings.TrimSpace(dto.Message)) > MaxMessageLength {
+		(*errors)["message"] = fmt.Sprintf("Message cannot exceed %d characters", MaxMessageLength)
+	}
+
+	return len(*errors) == 0
+}
+
+type MessageCreateDTO struct {
+	Body string `json:"body" binding:"required"`
+}
+
+func (dto *MessageCreateDTO) Validate(errors *map[string]string) bool {
+	body := strings.TrimSpace(dto.Body)
+	if body == "" {
+		(*errors)["body"] = "Message is required"
+	}
+
+	if len(body) > MaxMessageLength {
+		(*errors)["body"] = fmt.Sprintf("Message cannot exceed %d characters", MaxMessageLength)
+	}
+
+	return len(*errors) == 0
+}
+
+type PetEventDTO struct {
+	ID            int    `json:"id"`
+	UserID        int    `json:"user_id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Breed         string `json:"breed"`
+	LastSeenPlace string `json:"last_seen_place"`
+	PictureURL    string `json:"picture_url"`
+	Status        string `json:"status"`
+	IsFound       bool   `json:"is_found"`
+	Moderated     bool   `json:"moderated,omitempty"`
+}
+
+type SightingCreateDTO struct {
+	SeenAt       string `json:"seen_at" binding:"required"`
+	SeenProvince string `json:"seen_province" binding:"required"`
+	SeenCity     string `json:"seen_city" binding:"required"`
+	Notes        string `json:"notes"`
+}
+
+func (dto *SightingCreateDTO) Validate(errors *map[string]string) bool {
+	if _, err := time.Parse(time.RFC3339, dto.SeenAt); err != nil {
+		(*errors)["seen_at"] = "Invalid date, expected RFC 3339 format (2006-01-02T15:04:05Z07:00)"
+	}
+
+	validCities, provinceExists := models.CitiesByProvince[dto.SeenProvince]
+	if !provinceExists {
+		(*errors)["seen_province"] = "Invalid province"
+	} else if !slices.Contains(validCities, dto.SeenCity) {
+		(*errors)["seen_city"] = "Invalid city for this province"
+	}
+
+	if len(dto.Notes) > 1000 {
+		(*errors)["notes"] = "Notes cannot exceed 1000 characters"
+	}
+
+	return len(*errors) == 0
+}
+
+type SightingDTO struct {
+	ID           int       `json:"id"`
+	PetID        int       `json:"pet_id"`
+	ReporterID   int       `json:"reporter_id"`
+	ReporterName string    `json:"reporter_name"`
+	SeenAt       time.Time `json:"seen_at"`
+	SeenPlace    string    `json:"seen_place"`
+	Notes        string    `json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PetStatusUpdateDTO struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+func (dto *PetStatusUpdateDTO) Validate(errors *map[string]string) bool {
+	if !slices.Contains(models.PetStatuses, dto.Status) {
+		(*errors)["status"] = "Invalid status, must be one of: " + strings.Join(models.PetStatuses, ", ")
+	}
+
+	if len(dto.Reason) > 500 {
+		(*errors)["reason"] = "Reason cannot exceed 500 characters"
+	}
+
+	return len(*errors) == 0
+}
+
+type PetStatusChangeDTO struct {
+	ID            int       `json:"id"`
+	FromStatus    string    `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	ChangedByID   *int      `json:"changed_by_id"`
+	ChangedByName string    `json:"changed_by_name,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PetReportCreateDTO struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details"`
+}
+
+func (dto *PetReportCreateDTO) Validate(errors *map[string]string) bool {
+	if !slices.Contains(models.ReportReasons, dto.Reason) {
+		(*errors)["reason"] = "Invalid reason, must be one of: " + strings.Join(models.ReportReasons, ", ")
+	}
+
+	if dto.Reason == models.ReportReasonOther && strings.TrimSpace(dto.Details) == "" {
+		(*errors)["details"] = "Details are required when the reason is other"
+	}
+
+	if len(dto.Details) > 1000 {
+		(*errors)["details"] = "Details cannot exceed 1000 characters"
+	}
+
+	return len(*errors) == 0
+}
+
+type PetReportDTO struct {
+	ID           int        `json:"id"`
+	PetID        int        `json:"pet_id"`
+	PetName      string     `json:"pet_name,omitempty"`
+	PetStatus    string     `json:"pet_status,omitempty"`
+	PetHidden    bool       `json:"pet_hidden"`
+	ReporterID   int        `json:"reporter_id"`
+	ReporterName string     `json:"reporter_name,omitempty"`
+	Reason       string     `json:"reason"`
+	Details      string     `json:"details,omitempty"`
+	Status       string     `json:"status"`
+	Resolution   string     `json:"resolution,omitempty"`
+	ResolvedByID *int       `json:"resolved_by_id,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ModerationActionDTO es el cuerpo de las acciones de moderación; el motivo queda en la auditoría
+type ModerationActionDTO struct {
+	Reason string `json:"reason"`
+}
+
+func (dto *ModerationActionDTO) Validate(errors *map[string]string, required bool) bool {
+	if required && strings.TrimSpace(dto.Reason) == "" {
+		(*errors)["reason"] = "Reason is required"
+	}
+
+	if len(dto.Reason) > 500 {
+		(*errors)["reason"] = "Reason cannot exceed 500 characters"
+	}
+
+	return len(*errors) == 0
+}
+
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"`
+}
+
+type MFACodeDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFAVerifyDTO struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFADisableDTO struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
 type PetCreateDTO struct {
 	Name             string                `form:"name" binding:"required"`
 	Description      string                `form:"description"`
@@ -24,6 +247,7 @@ type PetCreateDTO struct {
 	LastSeenTime     string                `form:"last_seen_time" binding:"required"`
 	LastSeenProvince string                `form:"last_seen_province" binding:"required"`
 	LastSeenCity     string                `form:"last_seen_city" binding:"required"`
+	ContactPrivacy   string                `form:"contact_privacy"`
 	Picture          *multipart.FileHeader `form:"picture"`
 }
 
@@ -63,6 +287,8 @@ func (dto *UserCreateDTO) Validate(errors *map[string]string) bool {
 
 	if dto.Phone == "" {
 		(*errors)["phone"] = "Phone is required"
+	} else if !phoneRegexp.MatchString(dto.Phone) {
+		(*errors)["phone"] = "Invalid phone number"
 	}
 
 	if len(*errors) > 0 {
@@ -72,6 +298,169 @@ func (dto *UserCreateDTO) Validate(errors *map[string]string) bool {
 	return true
 }
 
+type UserUpdateDTO struct {
+	Name     *string `json:"name,omitempty"`
+	LastName *string `json:"last_name,omitempty"`
+	Phone    *string `json:"phone,omitempty"`
+}
+
+func (dto *UserUpdateDTO) Validate(errors *map[string]string) bool {
+	if dto.Name == nil && dto.LastName == nil && dto.Phone == nil {
+		(*errors)["body"] = "At least one field is required"
+	}
+
+	if dto.Name != nil && strings.TrimSpace(*dto.Name) == "" {
+		(*errors)["name"] = "Name cannot be empty"
+	}
+
+	if dto.LastName != nil && strings.TrimSpace(*dto.LastName) == "" {
+		(*errors)["last_name"] = "Last name cannot be empty"
+	}
+
+	if dto.Phone != nil && !phoneRegexp.MatchString(*dto.Phone) {
+		(*errors)["phone"] = "Invalid phone number"
+	}
+
+	return len(*errors) == 0
+}
+
+type UserPasswordUpdateDTO struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,min=6"`
+}
+
+func (dto *UserPasswordUpdateDTO) Validate(errors *map[string]string) bool {
+	if dto.CurrentPassword == "" {
+		(*errors)["current_password"] = "Current password is required"
+	}
+
+	if len(dto.NewPassword) < 6 {
+		(*errors)["new_password"] = "New password must have at least 6 characters"
+	}
+
+	if dto.NewPassword == dto.CurrentPassword {
+		(*errors)["new_password"] = "New password must be different from the current one"
+	}
+
+	if dto.NewPassword != dto.ConfirmPassword {
+		(*errors)["confirm_password"] = "Passwords do not match"
+	}
+
+	return len(*errors) == 0
+}
+
+type UserDeleteDTO struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type NotificationPreferenceUpdateDTO struct {
+	Language         *string `json:"language"`
+	PetCreated       *bool   `json:"pet_created"`
+	SightingReceived *bool   `json:"sighting_received"`
+	MessageReceived  *bool   `json:"message_received"`
+	PetFound         *bool   `json:"pet_found"`
+}
+
+func (dto *NotificationPreferenceUpdateDTO) Validate(errors *map[string]string) bool {
+	if dto.Language != nil && !slices.Contains(models.Languages, *dto.Language) {
+		(*errors)["language"] = "Invalid language, must be one of: " + strings.Join(models.Languages, ", ")
+	}
+
+	return len(*errors) == 0
+}
+
+type WebhookCreateDTO struct {
+	URL         string   `json:"url" binding:"required"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events" binding:"required"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+func (dto *WebhookCreateDTO) Validate(errors *map[string]string) bool {
+	validateWebhookURL(dto.URL, errors)
+	validateWebhookEvents(dto.Events, errors)
+
+	if dto.Secret != "" && len(dto.Secret) < 16 {
+		(*errors)["secret"] = "Secret must be at least 16 characters long"
+	}
+
+	return len(*errors) == 0
+}
+
+type WebhookUpdateDTO struct {
+	URL         *string   `json:"url"`
+	Events      *[]string `json:"events"`
+	Description *string   `json:"description"`
+	Active      *bool     `json:"active"`
+}
+
+func (dto *WebhookUpdateDTO) Validate(errors *map[string]string) bool {
+	if dto.URL != nil {
+		validateWebhookURL(*dto.URL, errors)
+	}
+	if dto.Events != nil {
+		validateWebhookEvents(*dto.Events, errors)
+	}
+
+	return len(*errors) == 0
+}
+
+func validateWebhookURL(value string, errors *map[string]string) {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		(*errors)["url"] = "URL must be an absolute http or https URL"
+	}
+}
+
+func validateWebhookEvents(events []string, errors *map[string]string) {
+	if len(events) == 0 {
+		(*errors)["events"] = "At least one event is required"
+		return
+	}
+
+	for _, event := range events {
+		if !slices.Contains(models.WebhookEventTypes, event) {
+			(*errors)["events"] = "Invalid event, must be one of: " + strings.Join(models.WebhookEventTypes, ", ")
+			return
+		}
+	}
+}
+
+// WebhookDTO es la vista de una suscripción. El secreto solo se incluye al crearla.
+type WebhookDTO struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedByID int       `json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookPayload es el cuerpo JSON que recibe el endpoint suscripto
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type UserRoleUpdateDTO struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (dto *UserRoleUpdateDTO) Validate(errors *map[string]string) bool {
+	if !slices.Contains(models.UserRoles, dto.Role) {
+		(*errors)["role"] = "Invalid role, must be one of: " + strings.Join(models.UserRoles, ", ")
+	}
+
+	return len(*errors) == 0
+}
+
 func (dto *PetCreateDTO) Validate(errors *map[string]string) bool {
 	if dto.Name == "" {
 		(*errors)["name"] = "Name is required"
@@ -120,6 +509,10 @@ func (dto *PetCreateDTO) Validate(errors *map[string]string) bool {
 		(*errors)["last_seen_city"] = "Invalid city"
 	}
 
+	if dto.ContactPrivacy != "" && !slices.Contains(models.ContactPrivacyOptions, dto.ContactPrivacy) {
+		(*errors)["contact_privacy"] = "Invalid contact privacy, must be one of: " + strings.Join(models.ContactPrivacyOptions, ", ")
+	}
+
 	if dto.Picture == nil {
 		(*errors)["picture"] = "Picture is required"
 	}
@@ -146,7 +539,7 @@ type PetUpdateDTO struct {
 	LastSeenProvince *string `json:"last_seen_province,omitempty"`
 	LastSeenCity     *string `json:"last_seen_city,omitempty"`
 	PictureURL       *string `json:"picture_url,omitempty"`
-	IsFound          *bool   `json:"is_found,omitempty"`
+	ContactPrivacy   *string `json:"contact_privacy,omitempty"`
 }
 
 func (dto *PetUpdateDTO) Validate(errors *map[string]string) bool {
@@ -177,5 +570,9 @@ func (dto *PetUpdateDTO) Validate(errors *map[string]string) bool {
 		}
 	}
 
+	if dto.ContactPrivacy != nil && !slices.Contains(models.ContactPrivacyOptions, *dto.ContactPrivacy) {
+		(*errors)["contact_privacy"] = "Invalid contact privacy, must be one of: " + strings.Join(models.ContactPrivacyOptions, ", ")
+	}
+
 	return len(*errors) == 0
 }
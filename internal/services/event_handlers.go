@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/pkg/eventbus"
+)
+
+// webhookEventTypes traduce los eventos de dominio a los nombres públicos de los webhooks
+var webhookEventTypes = map[string]string{
+	models.EventPetCreated: models.WebhookEventPetCreated,
+	models.EventPetUpdated: models.WebhookEventPetUpdated,
+	models.EventPetFound:   models.WebhookEventPetFound,
+	models.EventPetDeleted: models.WebhookEventPetDeleted,
+}
+
+// RegisterEventHandlers suscribe al bus los efectos secundarios de los eventos de dominio:
+// tiempo real, emails y webhooks
+func RegisterEventHandlers(bus *eventbus.Bus, realtimeService *RealtimeService, notificationService *NotificationService, webhookService *WebhookService) {
+	bus.Subscribe(models.EventPetCreated, "realtime", petHandler(realtimeService.PublishPetCreated))
+	bus.Subscribe(models.EventPetCreated, "notifications", petHandler(notificationService.NotifyPetCreated))
+
+	bus.Subscribe(models.EventPetFound, "realtime", petHandler(realtimeService.PublishPetFound))
+	bus.Subscribe(models.EventPetFound, "notifications", petHandler(notificationService.NotifyPetFound))
+
+	for eventType, webhookEventType := range webhookEventTypes {
+		bus.Subscribe(eventType, "webhooks", webhookHandler(webhookService, webhookEventType))
+	}
+}
+
+// petHandler ignora los eventos de publicaciones moderadas: no se anuncian en tiempo real ni por email
+func petHandler(handle func(ctx context.Context, pet *models.Pet)) eventbus.Handler {
+	return func(ctx context.Context, event eventbus.Event) error {
+		var data models.PetEventData
+		if err := event.Decode(&data); err != nil {
+			return err
+		}
+		if data.Moderated {
+			return nil
+		}
+
+		pet := data.Pet()
+		handle(ctx, &pet)
+		return nil
+	}
+}
+
+// webhookHandler envía los eventos de publicaciones moderadas sin su contenido, marcados con moderated
+func webhookHandler(webhookService *WebhookService, webhookEventType string) eventbus.Handler {
+	return func(ctx context.Context, event eventbus.Event) error {
+		var data models.PetEventData
+		if err := event.Decode(&data); err != nil {
+			return err
+		}
+
+		dto := PetEventDTO{ID: data.ID, UserID: data.UserID, Status: data.Status, Moderated: true}
+		if !data.Moderated {
+			pet := data.Pet()
+			dto = newPetEventDTO(&pet)
+		}
+		return webhookService.Dispatch(ctx, fmt.Sprintf("evt_%d", event.ID), webhookEventType, dto)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/storage_provider"
+)
+
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// JobHandlerDependencies son las dependencias que usan los handlers de los trabajos
+type JobHandlerDependencies struct {
+	StorageProvider     storage_provider.StorageProvider
+	NotificationService *NotificationService
+	PetExpiryService    *PetExpiryService
+	JobRepository       repositories.JobRepository
+	OutboxRepository    repositories.OutboxRepository
+	WebhookRepository   repositories.WebhookRepository
+	// Retention es cuánto se conservan los eventos despachados y los trabajos terminados
+	Retention time.Duration
+	// DeadOutboxRetention es cuánto se conservan los eventos del outbox en dead
+	DeadOutboxRetention time.Duration
+}
+
+// RegisterJobHandlers registra los handlers de cada tipo de trabajo y las tareas periódicas de limpieza
+// y vencimiento de publicaciones
+func RegisterJobHandlers(runner *JobRunner, deps JobHandlerDependencies) {
+	runner.Register(models.JobTypeDeletePicture, func(ctx context.Context, payload json.RawMessage) error {
+		var data models.DeletePicturePayload
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return err
+		}
+		return deps.StorageProvider.Delete(ctx, data.PictureURL)
+	})
+
+	runner.Register(models.JobTypeSendEmail, deps.NotificationService.SendEmail)
+
+	runner.Register(models.JobTypeCleanupOutbox, cleanupHandler("outbox events", func(ctx context.Context) (int64, error) {
+		dispatched, err := deps.OutboxRepository.DeleteDispatchedBefore(ctx, time.Now().Add(-deps.Retention))
+		if err != nil {
+			return 0, err
+		}
+		dead, err := deps.OutboxRepository.DeleteDeadBefore(ctx, time.Now().Add(-deps.DeadOutboxRetention))
+		return dispatched + dead, err
+	}))
+
+	runner.Register(models.JobTypeCleanupJobs, cleanupHandler("finished jobs", func(ctx context.Context) (int64, error) {
+		return deps.JobRepository.DeleteFinishedBefore(ctx, time.Now().Add(-deps.Retention))
+	}))
+
+	runner.Register(models.JobTypeCleanupWebhookDeliveries, cleanupHandler("webhook deliveries", func(ctx context.Context) (int64, error) {
+		return deps.WebhookRepository.DeleteDeliveriesBefore(ctx, time.Now().Add(-webhookDeliveryRetention))
+	}))
+
+	runner.Register(models.JobTypeExpirePets, deps.PetExpiryService.ExpirePets)
+
+	schedules := map[string]string{
+		models.JobTypeExpirePets:               "0 * * * *",
+		models.JobTypeCleanupOutbox:            "15 3 * * *",
+		models.JobTypeCleanupJobs:              "30 3 * * *",
+		models.JobTypeCleanupWebhookDeliveries: "45 3 * * *",
+	}
+	for jobType, spec := range schedules {
+		if err := runner.Schedule(spec, jobType, models.JobQueueDefault); err != nil {
+			slog.Error("failed to schedule job", "job_type", jobType, logger.Err(err))
+		}
+	}
+}
+
+func cleanupHandler(name string, cleanup func(ctx context.Context) (int64, error)) JobHandler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		deleted, err := cleanup(ctx)
+		if err != nil {
+			return err
+		}
+
+		logger.FromContext(ctx).Info("cleanup finished", "target", name, "deleted", deleted)
+		return nil
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/utils"
+
+	"github.com/robfig/cron/v3"
+)
+
+const maxJobErrorLength = 2000
+
+// JobHandler ejecuta un trabajo a partir de su payload. Si devuelve error el trabajo
+// se reintenta con espera exponencial hasta agotar sus intentos. ctx se cancela cuando vence
+// el lock del trabajo, porque a partir de ahí otro worker puede tomarlo.
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// JobRunner levanta un pool de workers por cola y el scheduler de trabajos periódicos
+type JobRunner struct {
+	jobRepository repositories.JobRepository
+	jobService    *JobService
+	handlers      map[string]JobHandler
+	cron          *cron.Cron
+	queues        map[string]int
+	pollInterval  time.Duration
+	lockTimeout   time.Duration
+	retryBase     time.Duration
+	retryMax      time.Duration
+	scheduler     bool
+	hostname      string
+	wake          <-chan struct{}
+	mu            sync.RWMutex
+	startOnce     sync.Once
+	stopOnce      sync.Once
+	stop          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewJobRunner crea el runner; wake despierta a los workers antes del próximo poll y puede ser nil
+func NewJobRunner(jobRepository repositories.JobRepository, jobService *JobService, jobsConfig config.JobsConfig, wake <-chan struct{}) *JobRunner {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+
+	return &JobRunner{
+		jobRepository: jobRepository,
+		jobService:    jobService,
+		handlers:      make(map[string]JobHandler),
+		cron:          cron.New(),
+		queues:        jobsConfig.Queues,
+		pollInterval:  jobsConfig.PollInterval,
+		lockTimeout:   jobsConfig.LockTimeout,
+		retryBase:     jobsConfig.RetryBase,
+		retryMax:      jobsConfig.RetryMax,
+		scheduler:     jobsConfig.SchedulerEnabled,
+		hostname:      hostname,
+		wake:          wake,
+		stop:          make(chan struct{}),
+	}
+}
+
+func (r *JobRunner) Register(jobType string, handler JobHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[jobType] = handler
+}
+
+// Schedule encola el trabajo según una expresión cron ("@daily", "0 3 * * *", ...)
+func (r *JobRunner) Schedule(spec string, jobType string, queue string) error {
+	_, err := r.cron.AddFunc(spec, func() {
+		if _, err := r.jobService.Enqueue(context.Background(), jobType, struct{}{}, JobOptions{Queue: queue}); err != nil {
+			slog.Error("failed to enqueue scheduled job", "job_type", jobType, logger.Err(err))
+		}
+	})
+	return err
+}
+
+// Start lanza los workers y el scheduler; los handlers y los trabajos periódicos se registran
+// antes con RegisterJobHandlers. Llamarlo más de una vez no tiene efecto.
+func (r *JobRunner) Start() {
+	r.startOnce.Do(func() {
+		if r.scheduler {
+			r.cron.Start()
+		}
+
+		for queue, workers := range r.queues {
+			for i := 0; i < workers; i++ {
+				r.wg.Add(1)
+				go r.work(queue, fmt.Sprintf("%s/%s/%d", r.hostname, queue, i))
+			}
+		}
+	})
+}
+
+// Stop deja de encolar trabajos periódicos y de tomar trabajos nuevos, y espera a que los
+// workers terminen el trabajo en curso o a que venza ctx. Un trabajo cortado por el vencimiento
+// queda running y otro worker lo retoma cuando vence su lock
+func (r *JobRunner) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+
+	cronDone := r.cron.Stop()
+	if err := waitDone(ctx, &r.wg); err != nil {
+		return err
+	}
+
+	select {
+	case <-cronDone.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *JobRunner) work(queue string, workerID string) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	// El trabajo en curso no se cancela al detenerse: Stop espera a que termine
+	ctx := context.Background()
+	for {
+		for !r.stopping() && r.RunNext(ctx, queue, workerID) {
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.wake:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *JobRunner) stopping() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunNext ejecuta el próximo trabajo vencido de la cola y devuelve false si no había ninguno
+func (r *JobRunner) RunNext(ctx context.Context, queue string, workerID string) bool {
+	now := time.Now()
+	job, err := r.jobRepository.ClaimNext(ctx, queue, workerID, now, now.Add(r.lockTimeout))
+	if err != nil {
+		slog.Error("failed to claim job", "queue", queue, logger.Err(err))
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	jobLogger := slog.With("job_id", job.ID, "job_type", job.Type, "queue", queue, "attempt", job.Attempts)
+
+	err = r.execute(ctx, job, jobLogger)
+	finishedAt := time.Now()
+
+	updates := map[string]interface{}{
+		"locked_until": nil,
+		"locked_by":    "",
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = models.JobSucceeded
+		updates["finished_at"] = finishedAt
+		updates["last_error"] = ""
+	case job.Attempts >= job.MaxAttempts:
+		jobLogger.Error("job is dead after exhausting its attempts", logger.Err(err))
+		updates["status"] = models.JobDead
+		updates["finished_at"] = finishedAt
+		updates["last_error"] = truncateRunes(err.Error(), maxJobErrorLength)
+	default:
+		delay := r.backoff(job.Attempts)
+		jobLogger.Warn("job failed, retrying", "retry_in", delay.String(), logger.Err(err))
+		updates["status"] = models.JobPending
+		updates["run_at"] = finishedAt.Add(delay)
+		updates["last_error"] = truncateRunes(err.Error(), maxJobErrorLength)
+	}
+
+	finished, err := r.jobRepository.Finish(ctx, job.ID, workerID, updates)
+	if err != nil {
+		jobLogger.Error("failed to update job", logger.Err(err))
+	} else if !finished {
+		jobLogger.Warn("job lock was lost before finishing, result discarded")
+	}
+
+	return true
+}
+
+// execute corre el handler con jobLogger en el contexto y convierte un panic en error para no perder el worker
+func (r *JobRunner) execute(ctx context.Context, job *models.Job, jobLogger *slog.Logger) (err error) {
+	r.mu.RLock()
+	handler, ok := r.handlers[job.Type]
+	r.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no handler registered for job type %s", job.Type)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	ctx, cancel := utils.WithTimeout(logger.WithContext(ctx, jobLogger), r.lockTimeout)
+	defer cancel()
+
+	return handler(ctx, json.RawMessage(job.Payload))
+}
+
+func (r *JobRunner) backoff(attempts int) time.Duration {
+	if attempts > 16 {
+		return r.retryMax
+	}
+	return min(r.retryBase<<(attempts-1), r.retryMax)
+}
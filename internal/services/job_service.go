@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+)
+
+var (
+	ErrJobNotRetryable = errors.NewConflictError("Only dead or succeeded jobs can be retried")
+)
+
+// JobOptions permite cambiar la cola, la fecha de ejecución o los intentos de un trabajo
+type JobOptions struct {
+	Queue       string
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+type JobService struct {
+	jobRepository repositories.JobRepository
+	maxAttempts   int
+}
+
+func NewJobService(jobRepository repositories.JobRepository, jobsConfig config.JobsConfig) *JobService {
+	return &JobService{
+		jobRepository: jobRepository,
+		maxAttempts:   max(jobsConfig.MaxAttempts, 1),
+	}
+}
+
+// Enqueue guarda el trabajo para que lo ejecute un worker de su cola
+func (s *JobService) Enqueue(ctx context.Context, jobType string, payload interface{}, options JobOptions) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to encode job payload")
+	}
+
+	job := models.Job{
+		Queue:       options.Queue,
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.JobPending,
+		MaxAttempts: options.MaxAttempts,
+		RunAt:       options.RunAt,
+	}
+
+	if job.Queue == "" {
+		job.Queue = models.JobQueueDefault
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = s.maxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	if err := s.jobRepository.Create(ctx, &job); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (s *JobService) SearchJobs(ctx context.Context, filter *models.Job, paginationParams *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	customConfig := pagination.PaginationConfig{
+		DefaultPage:    1,
+		DefaultSize:    20,
+		MaxSize:        100,
+		DefaultSortBy:  "created_at",
+		DefaultSortDir: "DESC",
+	}
+	pagination.NormalizeParams(paginationParams, customConfig)
+
+	return s.jobRepository.Search(ctx, filter, paginationParams)
+}
+
+func (s *JobService) GetJob(ctx context.Context, id int64) (*models.Job, error) {
+	return s.jobRepository.GetByID(ctx, id)
+}
+
+// RetryJob vuelve a encolar un trabajo muerto (o uno terminado, para repetirlo)
+func (s *JobService) RetryJob(ctx context.Context, id int64) (*models.Job, error) {
+	job, err := s.jobRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != models.JobDead && job.Status != models.JobSucceeded {
+		return nil, ErrJobNotRetryable
+	}
+
+	if err := s.jobRepository.Retry(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.jobRepository.GetByID(ctx, id)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// LoginThrottler cuenta los intentos fallidos por clave (email o IP) y, superado
+// el máximo permitido, bloquea la clave con un tiempo que se duplica en cada fallo.
+// Los contadores viven en la memoria del proceso: con varias réplicas cada una lleva los
+// suyos, así que un atacante repartido por el balanceador tiene hasta N veces el máximo, y
+// un reinicio los borra. Para más de una instancia hay que respaldarlo en la base o en Redis.
+type LoginThrottler struct {
+	mu          sync.Mutex
+	attempts    map[string]*loginAttempts
+	maxFailures int
+	baseLockout time.Duration
+	maxLockout  time.Duration
+	now         func() time.Time
+}
+
+const maxTrackedLoginKeys = 10000
+
+type loginAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func NewLoginThrottler(maxFailures int, baseLockout time.Duration, maxLockout time.Duration) *LoginThrottler {
+	return &LoginThrottler{
+		attempts:    make(map[string]*loginAttempts),
+		maxFailures: maxFailures,
+		baseLockout: baseLockout,
+		maxLockout:  maxLockout,
+		now:         time.Now,
+	}
+}
+
+// RetryAfter devuelve cuánto falta para que la clave vuelva a estar habilitada
+func (t *LoginThrottler) RetryAfter(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attempts := t.get(key)
+	if attempts == nil {
+		return 0
+	}
+
+	remaining := attempts.lockedUntil.Sub(t.now())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RegisterFailure suma un fallo y devuelve la duración del bloqueo aplicado (0 si no se bloqueó)
+func (t *LoginThrottler) RegisterFailure(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.attempts) >= maxTrackedLoginKeys {
+		t.sweep()
+	}
+
+	now := t.now()
+	attempts := t.get(key)
+	if attempts == nil {
+		attempts = &loginAttempts{}
+		t.attempts[key] = attempts
+	}
+
+	attempts.failures++
+	attempts.lastFailure = now
+
+	if attempts.failures < t.maxFailures {
+		return 0
+	}
+
+	exponent := attempts.failures - t.maxFailures
+	lockout := t.maxLockout
+	if exponent < 32 {
+		lockout = t.baseLockout << exponent
+	}
+	if lockout <= 0 || lockout > t.maxLockout {
+		lockout = t.maxLockout
+	}
+
+	attempts.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+func (t *LoginThrottler) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.attempts, key)
+}
+
+func (t *LoginThrottler) sweep() {
+	for key := range t.attempts {
+		t.get(key)
+	}
+}
+
+// get descarta los contadores que quedaron inactivos más tiempo que el bloqueo máximo
+func (t *LoginThrottler) get(key string) *loginAttempts {
+	attempts, ok := t.attempts[key]
+	if !ok {
+		return nil
+	}
+
+	now := t.now()
+	if now.After(attempts.lockedUntil) && now.Sub(attempts.lastFailure) > t.maxLockout {
+		delete(t.attempts, key)
+		return nil
+	}
+
+	return attempts
+}
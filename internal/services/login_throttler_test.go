@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestThrottler devuelve un throttler de 3 fallos, bloqueo de 1 a 10 minutos y un reloj que la
+// prueba adelanta a mano
+func newTestThrottler() (*LoginThrottler, *time.Time) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	throttler := NewLoginThrottler(3, time.Minute, 10*time.Minute)
+	throttler.now = func() time.Time { return now }
+	return throttler, &now
+}
+
+func TestLoginThrottlerRegisterFailure(t *testing.T) {
+	throttler, _ := newTestThrottler()
+
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, lockout := range want {
+		if got := throttler.RegisterFailure("email:ana@example.com"); got != lockout {
+			t.Errorf("failure %d: got lockout %s, want %s", i+1, got, lockout)
+		}
+	}
+
+	if got := throttler.RegisterFailure("email:luis@example.com"); got != 0 {
+		t.Errorf("another key was locked for %s after its first failure", got)
+	}
+}
+
+func TestLoginThrottlerRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		elapsed  time.Duration
+		want     time.Duration
+	}{
+		{"unknown key", 0, 0, 0},
+		{"below the limit", 2, 0, 0},
+		{"just locked", 3, 0, time.Minute},
+		{"halfway through the lockout", 3, 30 * time.Second, 30 * time.Second},
+		{"lockout over", 3, 2 * time.Minute, 0},
+		{"doubled lockout", 4, time.Minute, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttler, now := newTestThrottler()
+			for range tt.failures {
+				throttler.RegisterFailure("ip:10.0.0.1")
+			}
+			*now = now.Add(tt.elapsed)
+
+			if got := throttler.RetryAfter("ip:10.0.0.1"); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlerReset(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset func(throttler *LoginThrottler, now *time.Time)
+	}{
+		{"Reset", func(throttler *LoginThrottler, _ *time.Time) { throttler.Reset("email:ana@example.com") }},
+		// Un contador sin fallos por más tiempo que el bloqueo máximo se descarta solo
+		{"inactivity", func(_ *LoginThrottler, now *time.Time) { *now = now.Add(11 * time.Minute) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttler, now := newTestThrottler()
+			for range 3 {
+				throttler.RegisterFailure("email:ana@example.com")
+			}
+
+			tt.reset(throttler, now)
+
+			if got := throttler.RetryAfter("email:ana@example.com"); got != 0 {
+				t.Errorf("key is still locked for %s", got)
+			}
+			if got := throttler.RegisterFailure("email:ana@example.com"); got != 0 {
+				t.Errorf("first failure after the reset locked the key for %s", got)
+			}
+		})
+	}
+}
+
+func TestAuthServiceAuthenticateUserLockout(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Name: "Ana", LastName: "García", Email: "ana@example.com", Password: string(hash), Role: models.RoleUser}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := repositories.NewUserRepositorySQLServer(db, 5*time.Second, nil)
+	auditRepository := repositories.NewAuditRepositorySQLServer(db, 5*time.Second)
+	loginGuard := NewLoginGuard(NewAuditService(auditRepository), config.LoginConfig{
+		MaxFailuresPerEmail: 3,
+		MaxFailuresPerIP:    10,
+		LockoutBase:         time.Minute,
+		LockoutMax:          10 * time.Minute,
+	})
+	authService := NewAuthService(NewUserService(userRepository, loginGuard), nil, loginGuard, config.JWTConfig{Secret: testJWTSecret})
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := authService.AuthenticateUser(ctx, user.Email, "wrong", "10.0.0.1"); err != ErrInvalidCredentials {
+			t.Fatalf("attempt %d returned %v, want invalid credentials", attempt, err)
+		}
+	}
+
+	// El tercer fallo bloquea el email: responde 429 con Retry-After y queda en la auditoría
+	_, err = authService.AuthenticateUser(ctx, "ANA@example.com ", "wrong", "10.0.0.1")
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.Code != http.StatusTooManyRequests || appErr.RetryAfter != 60 {
+		t.Fatalf("third failure returned %v, want 429 with retry after 60s", err)
+	}
+
+	// Bloqueado, ni siquiera la contraseña correcta entra
+	_, err = authService.AuthenticateUser(ctx, user.Email, "Passw0rd!", "10.0.0.2")
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != http.StatusTooManyRequests {
+		t.Errorf("correct password while locked returned %v, want 429", err)
+	}
+
+	var events []models.AuditEvent
+	if err := db.Where("action = ?", models.AuditActionLoginLockout).Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].IP != "10.0.0.1" || events[0].Details != "login locked for 1m0s" {
+		t.Errorf("got audit events %+v, want one lockout from 10.0.0.1", events)
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+	"strings"
+	"time"
+)
+
+const MaxMessageLength = 2000
+
+var (
+	ErrConversationOwnPet       = errors.NewBadRequestError("You cannot start a conversation about your own pet")
+	ErrConversationForbidden    = errors.NewForbiddenError("You are not a participant of this conversation")
+	ErrConversationBlocked      = errors.NewForbiddenError("This conversation is blocked")
+	ErrConversationNotBlockedBy = errors.NewForbiddenError("Only the participant who blocked the conversation can unblock it")
+)
+
+type MessagingService struct {
+	conversationRepository repositories.ConversationRepository
+	userRepository         repositories.UserRepository
+	petService             *PetService
+	realtimeService        *RealtimeService
+	notificationService    *NotificationService
+}
+
+func NewMessagingService(
+	conversationRepository repositories.ConversationRepository,
+	userRepository repositories.UserRepository,
+	petService *PetService,
+	realtimeService *RealtimeService,
+	notificationService *NotificationService,
+) *MessagingService {
+	return &MessagingService{
+		conversationRepository: conversationRepository,
+		userRepository:         userRepository,
+		petService:             petService,
+		realtimeService:        realtimeService,
+		notificationService:    notificationService,
+	}
+}
+
+// StartConversation abre (o reutiliza) el hilo entre el usuario y el dueño de la mascota. Solo se
+// puede escribir sobre mascotas que el actor puede ver
+func (s *MessagingService) StartConversation(ctx context.Context, actor policies.Actor, petID int, dto *ConversationCreateDTO) (*ConversationDTO, error) {
+	if err := s.checkSender(ctx, actor); err != nil {
+		return nil, err
+	}
+
+	pet, err := s.petService.GetVisiblePet(ctx, actor, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.Owns(pet) {
+		return nil, ErrConversationOwnPet
+	}
+
+	conversation, err := s.conversationRepository.GetByPetAndFinder(ctx, petID, actor.UserID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	if conversation == nil {
+		conversation = &models.Conversation{
+			PetID:    pet.ID,
+			OwnerID:  pet.UserID,
+			FinderID: actor.UserID,
+		}
+
+		if err := s.conversationRepository.Create(ctx, conversation); err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(dto.Message) != "" {
+		if _, err := s.SendMessage(ctx, actor, conversation.ID, &MessageCreateDTO{Body: dto.Message}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetConversation(ctx, actor, conversation.ID)
+}
+
+func (s *MessagingService) GetConversation(ctx context.Context, actor policies.Actor, conversationID int) (*ConversationDTO, error) {
+	conversation, err := s.getForParticipant(ctx, actor, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(ctx, actor, conversation)
+}
+
+func (s *MessagingService) ListConversations(ctx context.Context, actor policies.Actor) ([]ConversationDTO, error) {
+	conversations, err := s.conversationRepository.ListByParticipant(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ConversationDTO, 0, len(conversations))
+	for i := range conversations {
+		dto, err := s.toDTO(ctx, actor, &conversations[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *dto)
+	}
+
+	return result, nil
+}
+
+// ListMessages devuelve los mensajes del hilo y los marca como leídos para el usuario
+func (s *MessagingService) ListMessages(ctx context.Context, actor policies.Actor, conversationID int, paginationParams *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	conversation, err := s.getForParticipant(ctx, actor, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	customConfig := pagination.PaginationConfig{
+		DefaultPage:    1,
+		DefaultSize:    50,
+		MaxSize:        100,
+		DefaultSortBy:  "created_at",
+		DefaultSortDir: "DESC",
+	}
+	pagination.NormalizeParams(paginationParams, customConfig)
+
+	result, err := s.conversationRepository.ListMessages(ctx, conversation.ID, paginationParams)
+	if err != nil {
+		return nil, err
+	}
+
+	readColumn := "finder_last_read_at"
+	if conversation.OwnerID == actor.UserID {
+		readColumn = "owner_last_read_at"
+	}
+
+	err = s.conversationRepository.Update(ctx, conversation.ID, map[string]interface{}{readColumn: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *MessagingService) SendMessage(ctx context.Context, actor policies.Actor, conversationID int, dto *MessageCreateDTO) (*models.Message, error) {
+	conversation, err := s.getForParticipant(ctx, actor, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.IsBlocked() {
+		return nil, ErrConversationBlocked
+	}
+
+	if err := s.checkSender(ctx, actor); err != nil {
+		return nil, err
+	}
+
+	message := models.Message{
+		ConversationID: conversation.ID,
+		SenderID:       actor.UserID,
+		Body:           strings.TrimSpace(dto.Body),
+		CreatedAt:      time.Now(),
+	}
+
+	if err := s.conversationRepository.CreateMessage(ctx, &message); err != nil {
+		return nil, err
+	}
+
+	s.realtimeService.PublishMessageCreated(conversation, &message)
+	s.notificationService.NotifyMessageReceived(ctx, conversation, &message)
+
+	return &message, nil
+}
+
+func (s *MessagingService) Block(ctx context.Context, actor policies.Actor, conversationID int) error {
+	conversation, err := s.getForParticipant(ctx, actor, conversationID)
+	if err != nil {
+		return err
+	}
+
+	if conversation.IsBlocked() {
+		return nil
+	}
+
+	return s.conversationRepository.Update(ctx, conversation.ID, map[string]interface{}{"blocked_by_id": actor.UserID})
+}
+
+func (s *MessagingService) Unblock(ctx context.Context, actor policies.Actor, conversationID int) error {
+	conversation, err := s.getForParticipant(ctx, actor, conversationID)
+	if err != nil {
+		return err
+	}
+
+	if !conversation.IsBlocked() {
+		return nil
+	}
+
+	if *conversation.BlockedByID != actor.UserID {
+		return ErrConversationNotBlockedBy
+	}
+
+	return s.conversationRepository.Update(ctx, conversation.ID, map[string]interface{}{"blocked_by_id": nil})
+}
+
+// ShareContact registra que el usuario acepta compartir sus datos. Los datos solo se
+// muestran cuando ambos participantes aceptaron.
+func (s *MessagingService) ShareContact(ctx context.Context, actor policies.Actor, conversationID int) (*ConversationDTO, error) {
+	conversation, err := s.getForParticipant(ctx, actor, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.IsBlocked() {
+		return nil, ErrConversationBlocked
+	}
+
+	column := "finder_shares_contact"
+	if conversation.OwnerID == actor.UserID {
+		column = "owner_shares_contact"
+	}
+
+	if err := s.conversationRepository.Update(ctx, conversation.ID, map[string]interface{}{column: true}); err != nil {
+		return nil, err
+	}
+
+	return s.GetConversation(ctx, actor, conversation.ID)
+}
+
+// checkSender impide escribir a los usuarios suspendidos
+func (s *MessagingService) checkSender(ctx context.Context, actor policies.Actor) error {
+	sender, err := s.userRepository.GetByID(ctx, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if sender.IsBanned() {
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+func (s *MessagingService) getForParticipant(ctx context.Context, actor policies.Actor, conversationID int) (*models.Conversation, error) {
+	conversation, err := s.conversationRepository.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !conversation.IsParticipant(actor.UserID) {
+		return nil, ErrConversationForbidden
+	}
+
+	return conversation, nil
+}
+
+func (s *MessagingService) toDTO(ctx context.Context, actor policies.Actor, conversation *models.Conversation) (*ConversationDTO, error) {
+	isOwner := conversation.OwnerID == actor.UserID
+
+	counterpart := conversation.Owner
+	lastReadAt := conversation.FinderLastReadAt
+	sharesContact := conversation.FinderSharesContact
+	counterpartSharesContact := conversation.OwnerSharesContact
+	if isOwner {
+		counterpart = conversation.Finder
+		lastReadAt = conversation.OwnerLastReadAt
+		sharesContact = conversation.OwnerSharesContact
+		counterpartSharesContact = conversation.FinderSharesContact
+	}
+
+	unread, err := s.conversationRepository.CountUnread(ctx, conversation.ID, actor.UserID, lastReadAt)
+	if err != nil {
+		return nil, err
+	}
+
+	participant := ParticipantDTO{
+		UserID:      counterpart.ID,
+		DisplayName: displayName(&counterpart),
+	}
+	if conversation.ContactShared() {
+		participant.Phone = counterpart.Phone
+		participant.Email = counterpart.Email
+	}
+
+	return &ConversationDTO{
+		ID:                       conversation.ID,
+		PetID:                    conversation.PetID,
+		PetName:                  conversation.Pet.Name,
+		IsOwner:                  isOwner,
+		Counterpart:              participant,
+		UnreadCount:              unread,
+		SharesContact:            sharesContact,
+		CounterpartSharesContact: counterpartSharesContact,
+		Blocked:                  conversation.IsBlocked(),
+		BlockedByMe:              conversation.IsBlocked() && *conversation.BlockedByID == actor.UserID,
+		LastMessageAt:            conversation.LastMessageAt,
+		CreatedAt:                conversation.CreatedAt,
+	}, nil
+}
+
+// displayName muestra el nombre y la inicial del apellido, por ejemplo "Juan P."
+func displayName(user *models.User) string {
+	lastName := strings.TrimSpace(user.LastName)
+	if lastName == "" {
+		return user.Name
+	}
+	return user.Name + " " + string([]rune(lastName)[0]) + "."
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/errors"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	mfaIssuer         = "Find My Friend"
+	recoveryCodeCount = 10
+	totpPeriod        = 30
+	// totpSkew son los pasos de tolerancia a cada lado del actual, como totp.Validate
+	totpSkew = 1
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.NewConflictError("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.NewBadRequestError("two-factor authentication enrollment was not started")
+	ErrMFANotEnabled     = errors.NewBadRequestError("two-factor authentication is not enabled")
+	ErrInvalidMFACode    = errors.NewUnauthorizedError("invalid two-factor code")
+)
+
+type MFAService struct {
+	userRepository         repositories.UserRepository
+	recoveryCodeRepository repositories.RecoveryCodeRepository
+	auditService           *AuditService
+	loginGuard             *LoginGuard
+}
+
+func NewMFAService(userRepository repositories.UserRepository, recoveryCodeRepository repositories.RecoveryCodeRepository, auditService *AuditService, loginGuard *LoginGuard) *MFAService {
+	return &MFAService{
+		userRepository:         userRepository,
+		recoveryCodeRepository: recoveryCodeRepository,
+		auditService:           auditService,
+		loginGuard:             loginGuard,
+	}
+}
+
+// Enroll genera un secreto nuevo. El 2FA queda pendiente hasta que se confirme con un primer código.
+func (s *MFAService) Enroll(ctx context.Context, userID int) (*MFAEnrollment, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      mfaIssuer,
+		AccountName: user.Email,
+	})
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to generate two-factor secret")
+	}
+
+	err = s.userRepository.Update(ctx, userID, map[string]interface{}{"totp_secret": key.Secret()})
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := key.Image(256, 256)
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to generate QR code")
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, image); err != nil {
+		return nil, errors.NewInternalServerError("Failed to generate QR code")
+	}
+
+	return &MFAEnrollment{
+		Secret:     key.Secret(),
+		OTPAuthURI: key.URL(),
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()),
+	}, nil
+}
+
+// Confirm activa el 2FA y devuelve los códigos de recuperación, que solo se muestran esta vez.
+// Los códigos inválidos cuentan como intentos fallidos de login del usuario y de ip
+func (s *MFAService) Confirm(ctx context.Context, userID int, code string, ip string) ([]string, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loginGuard.Check(user.Email, ip); err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	valid, err := s.validateTOTP(ctx, user, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		if err := s.loginGuard.Fail(ctx, user.Email, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidMFACode
+	}
+	s.loginGuard.Succeed(user.Email)
+
+	recoveryCodes, err := s.regenerateRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userRepository.Update(ctx, userID, map[string]interface{}{"totp_enabled": true})
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, &models.AuditEvent{
+		Action:  models.AuditActionMFAEnabled,
+		ActorID: &userID,
+		Subject: user.Email,
+	})
+
+	return recoveryCodes, nil
+}
+
+// Disable desactiva el 2FA con la contraseña y un código. Como Confirm, los fallos cuentan como
+// intentos de login, así quien tenga solo el token de sesión no puede probar sin límite
+func (s *MFAService) Disable(ctx context.Context, userID int, dto *MFADisableDTO, ip string) error {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.loginGuard.Check(user.Email, ip); err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if err := checkPassword(dto.Password, user.Password); err != nil {
+		if err := s.loginGuard.Fail(ctx, user.Email, ip); err != nil {
+			return err
+		}
+		return ErrInvalidCurrentPassword
+	}
+
+	valid, err := s.VerifyCode(ctx, user, dto.Code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		if err := s.loginGuard.Fail(ctx, user.Email, ip); err != nil {
+			return err
+		}
+		return ErrInvalidMFACode
+	}
+	s.loginGuard.Succeed(user.Email)
+
+	err = s.userRepository.Update(ctx, userID, map[string]interface{}{"totp_enabled": false, "totp_secret": ""})
+	if err != nil {
+		return err
+	}
+
+	if err := s.recoveryCodeRepository.Replace(ctx, userID, nil); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, &models.AuditEvent{
+		Action:  models.AuditActionMFADisabled,
+		ActorID: &userID,
+		Subject: user.Email,
+	})
+
+	return nil
+}
+
+// VerifyCode acepta un código TOTP o, si no es numérico, un código de recuperación sin usar
+func (s *MFAService) VerifyCode(ctx context.Context, user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		return s.validateTOTP(ctx, user, code)
+	}
+
+	hash := hashRecoveryCode(code)
+	codes, err := s.recoveryCodeRepository.ListUnused(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, recoveryCode := range codes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode.CodeHash), []byte(hash)) != 1 {
+			continue
+		}
+
+		used, err := s.recoveryCodeRepository.MarkUsed(ctx, recoveryCode.ID)
+		if err != nil || !used {
+			return false, err
+		}
+
+		s.auditService.Record(ctx, &models.AuditEvent{
+			Action:  models.AuditActionRecoveryUsed,
+			ActorID: &user.ID,
+			Subject: user.Email,
+		})
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// validateTOTP acepta cada código una sola vez: busca el paso de la ventana con el que coincide
+// y lo registra en el usuario, que rechaza ese paso y los anteriores de ahí en más
+func (s *MFAService) validateTOTP(ctx context.Context, user *models.User, code string) (bool, error) {
+	now := time.Now()
+	opts := totp.ValidateOpts{
+		Period:    totpPeriod,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		at := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		expected, err := totp.GenerateCodeCustom(user.TOTPSecret, at, opts)
+		if err != nil {
+			return false, nil
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		step := at.Unix() / totpPeriod
+		if step <= user.TOTPLastStep {
+			return false, nil
+		}
+		return s.userRepository.ClaimTOTPStep(ctx, user.ID, step)
+	}
+
+	return false, nil
+}
+
+func (s *MFAService) regenerateRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	codes := make([]models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, errors.NewInternalServerError("Failed to generate recovery codes")
+		}
+
+		plain = append(plain, code)
+		codes = append(codes, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(code),
+		})
+	}
+
+	if err := s.recoveryCodeRepository.Replace(ctx, userID, codes); err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, char := range code {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecoveryCode genera códigos con el formato xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	buffer := make([]byte, 7)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buffer))[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// Los códigos de recuperación son aleatorios y de alta entropía, alcanza con SHA-256
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(code, "-", ""), " ", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/internal/middleware"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testJWTSecret = "test-secret"
+
+type mfaTestEnv struct {
+	user           *models.User
+	userRepository *repositories.UserRepositorySQLServer
+	authService    *AuthService
+	secret         string
+	recoveryCodes  []string
+	confirmedAt    time.Time
+	confirmCode    string
+}
+
+// newMFATestEnv crea un usuario con contraseña "Passw0rd!" y le activa el 2FA con un primer código
+func newMFATestEnv(t *testing.T) *mfaTestEnv {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Name: "Ana", LastName: "García", Email: "ana@example.com", Password: string(hash), Role: models.RoleUser}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := repositories.NewUserRepositorySQLServer(db, 5*time.Second, nil)
+	auditService := NewAuditService(repositories.NewAuditRepositorySQLServer(db, 5*time.Second))
+	loginGuard := NewLoginGuard(auditService, config.LoginConfig{
+		MaxFailuresPerEmail: 100,
+		MaxFailuresPerIP:    100,
+		LockoutBase:         time.Minute,
+		LockoutMax:          time.Minute,
+	})
+	mfaService := NewMFAService(userRepository, repositories.NewRecoveryCodeRepositorySQLServer(db, 5*time.Second), auditService, loginGuard)
+	authService := NewAuthService(NewUserService(userRepository, loginGuard), mfaService, loginGuard, config.JWTConfig{Secret: testJWTSecret})
+
+	enrollment, err := mfaService.Enroll(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Enroll returned %v", err)
+	}
+
+	confirmedAt := time.Now()
+	code, err := totp.GenerateCode(enrollment.Secret, confirmedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCodes, err := mfaService.Confirm(ctx, user.ID, code, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Confirm returned %v", err)
+	}
+
+	return &mfaTestEnv{
+		user:           user,
+		userRepository: userRepository,
+		authService:    authService,
+		secret:         enrollment.Secret,
+		recoveryCodes:  recoveryCodes,
+		confirmedAt:    confirmedAt,
+		confirmCode:    code,
+	}
+}
+
+func (e *mfaTestEnv) login(t *testing.T) string {
+	result, err := e.authService.AuthenticateUser(context.Background(), e.user.Email, "Passw0rd!", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("AuthenticateUser returned %v", err)
+	}
+	if !result.MFARequired || result.MFAToken == "" || result.Token != "" {
+		t.Fatalf("login of a user with 2FA returned %+v", result)
+	}
+	return result.MFAToken
+}
+
+func (e *mfaTestEnv) verify(mfaToken string, code string) error {
+	_, err := e.authService.VerifyMFA(context.Background(), &MFAVerifyDTO{MFAToken: mfaToken, Code: code}, "10.0.0.1")
+	return err
+}
+
+func TestMFAVerifyRejectsReplayedTOTPCode(t *testing.T) {
+	env := newMFATestEnv(t)
+	mfaToken := env.login(t)
+
+	// El código usado para confirmar el 2FA ya no sirve para iniciar sesión
+	if err := env.verify(mfaToken, env.confirmCode); err != ErrInvalidMFACode {
+		t.Fatalf("code used in Confirm returned %v, want invalid code", err)
+	}
+
+	// El código del paso siguiente entra dentro de la tolerancia, pero una sola vez
+	next, err := totp.GenerateCode(env.secret, env.confirmedAt.Add(totpPeriod*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.verify(mfaToken, next); err != nil {
+		t.Fatalf("first use of a fresh code returned %v", err)
+	}
+	if err := env.verify(mfaToken, next); err != ErrInvalidMFACode {
+		t.Errorf("replayed code returned %v, want invalid code", err)
+	}
+}
+
+func TestMFAVerifyRejectsReusedRecoveryCode(t *testing.T) {
+	env := newMFATestEnv(t)
+	mfaToken := env.login(t)
+
+	if len(env.recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("Confirm returned %d recovery codes", len(env.recoveryCodes))
+	}
+
+	if err := env.verify(mfaToken, env.recoveryCodes[0]); err != nil {
+		t.Fatalf("first use of a recovery code returned %v", err)
+	}
+	if err := env.verify(mfaToken, env.recoveryCodes[0]); err != ErrInvalidMFACode {
+		t.Errorf("reused recovery code returned %v, want invalid code", err)
+	}
+	if err := env.verify(mfaToken, env.recoveryCodes[1]); err != nil {
+		t.Errorf("another recovery code returned %v", err)
+	}
+}
+
+func TestMFAPendingTokenIsRejectedOnProtectedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := newMFATestEnv(t)
+	mfaToken := env.login(t)
+
+	router := gin.New()
+	router.GET("/protected", middleware.AuthMiddleware(testJWTSecret, env.userRepository), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	request.Header.Set("Authorization", "Bearer "+mfaToken)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("mfa_pending token got status %d, want 401", recorder.Code)
+	}
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/pagination"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAccountSuspended  = errors.NewForbiddenError("Your account has been suspended")
+	ErrReportOwnPet      = errors.NewBadRequestError("You cannot report your own pet")
+	ErrReportAlreadyOpen = errors.NewConflictError("You already reported this pet")
+	ErrReportNotOpen     = errors.NewConflictError("The report is already closed")
+	ErrCannotModerate    = errors.NewForbiddenError("You are not allowed to moderate this user")
+)
+
+// ModerationService recibe las denuncias de los usuarios y ejecuta las acciones de moderación.
+// Cada acción queda registrada en la auditoría con el motivo que indicó el moderador.
+type ModerationService struct {
+	reportRepository    repositories.PetReportRepository
+	petRepository       repositories.PetRepository
+	userRepository      repositories.UserRepository
+	petService          *PetService
+	auditService        *AuditService
+	notificationService *NotificationService
+}
+
+func NewModerationService(
+	reportRepository repositories.PetReportRepository,
+	petRepository repositories.PetRepository,
+	userRepository repositories.UserRepository,
+	petService *PetService,
+	auditService *AuditService,
+	notificationService *NotificationService,
+) *ModerationService {
+	return &ModerationService{
+		reportRepository:    reportRepository,
+		petRepository:       petRepository,
+		userRepository:      userRepository,
+		petService:          petService,
+		auditService:        auditService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *ModerationService) ReportPet(ctx context.Context, actor policies.Actor, petID int, dto *PetReportCreateDTO) (*PetReportDTO, error) {
+	pet, err := s.petService.GetVisiblePet(ctx, actor, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.Owns(pet) {
+		return nil, ErrReportOwnPet
+	}
+
+	exists, err := s.reportRepository.ExistsOpen(ctx, pet.ID, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrReportAlreadyOpen
+	}
+
+	report := models.PetReport{
+		PetID:      pet.ID,
+		ReporterID: actor.UserID,
+		Reason:     dto.Reason,
+		Details:    strings.TrimSpace(dto.Details),
+		Status:     models.ReportOpen,
+	}
+
+	if err := s.reportRepository.Create(ctx, &report); err != nil {
+		return nil, err
+	}
+
+	result := newPetReportDTO(&report)
+	return &result, nil
+}
+
+// ListReports es la cola de moderación; por defecto muestra primero las denuncias más antiguas
+func (s *ModerationService) ListReports(ctx context.Context, filter *models.PetReport, paginationParams *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	customConfig := pagination.PaginationConfig{
+		DefaultPage:    1,
+		DefaultSize:    20,
+		MaxSize:        100,
+		DefaultSortBy:  "created_at",
+		DefaultSortDir: "ASC",
+	}
+	pagination.NormalizeParams(paginationParams, customConfig)
+
+	result, err := s.reportRepository.Search(ctx, filter, paginationParams)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := *result.Data.(*[]models.PetReport)
+	data := make([]PetReportDTO, 0, len(reports))
+	for i := range reports {
+		data = append(data, newPetReportDTO(&reports[i]))
+	}
+	result.Data = data
+
+	return result, nil
+}
+
+func (s *ModerationService) DismissReport(ctx context.Context, actor policies.Actor, reportID int, reason string) error {
+	report, err := s.reportRepository.GetByID(ctx, reportID)
+	if err != nil {
+		return err
+	}
+
+	if report.Status != models.ReportOpen {
+		return ErrReportNotOpen
+	}
+
+	if err := s.reportRepository.Resolve(ctx, report.ID, models.ReportDismissed, reason, actor.UserID); err != nil {
+		return err
+	}
+
+	s.record(ctx, actor, models.AuditActionReportDismiss, fmt.Sprintf("report:%d", report.ID), reason)
+	return nil
+}
+
+// HidePet oculta la publicación sin cambiar su estado y cierra sus denuncias abiertas
+func (s *ModerationService) HidePet(ctx context.Context, actor policies.Actor, petID int, reason string) error {
+	pet, err := s.petService.GetPetByID(ctx, petID)
+	if err != nil {
+		return err
+	}
+
+	if !policies.CanHidePet(actor, pet) {
+		return errors.NewForbiddenError("You are not allowed to hide this pet")
+	}
+
+	if pet.IsHidden() {
+		return errors.NewBadRequestError("Pet is already hidden")
+	}
+
+	updates := map[string]interface{}{"hidden_at": time.Now(), "hidden_reason": reason}
+	if err := s.petRepository.Update(ctx, pet.ID, updates); err != nil {
+		return err
+	}
+
+	return s.closeReports(ctx, actor, pet, models.AuditActionPetHidden, reason)
+}
+
+func (s *ModerationService) RestorePet(ctx context.Context, actor policies.Actor, petID int, reason string) error {
+	pet, err := s.petService.GetPetByID(ctx, petID)
+	if err != nil {
+		return err
+	}
+
+	if !policies.CanHidePet(actor, pet) {
+		return errors.NewForbiddenError("You are not allowed to restore this pet")
+	}
+
+	if !pet.IsHidden() {
+		return errors.NewBadRequestError("Pet is not hidden")
+	}
+
+	updates := map[string]interface{}{"hidden_at": nil, "hidden_reason": ""}
+	if err := s.petRepository.Update(ctx, pet.ID, updates); err != nil {
+		return err
+	}
+
+	s.record(ctx, actor, models.AuditActionPetRestored, fmt.Sprintf("pet:%d", pet.ID), reason)
+	return nil
+}
+
+// RemovePet da de baja la publicación (estado removed) y cierra sus denuncias abiertas
+func (s *ModerationService) RemovePet(ctx context.Context, actor policies.Actor, petID int, reason string) error {
+	err := s.petService.ChangeStatus(ctx, actor, petID, &PetStatusUpdateDTO{Status: models.PetStatusRemoved, Reason: reason})
+	if err != nil {
+		return err
+	}
+
+	pet, err := s.petService.GetPetByID(ctx, petID)
+	if err != nil {
+		return err
+	}
+
+	return s.closeReports(ctx, actor, pet, models.AuditActionPetRemoved, reason)
+}
+
+func (s *ModerationService) WarnUser(ctx context.Context, actor policies.Actor, userID int, reason string) error {
+	user, err := s.moderatedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepository.AddWarning(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.notificationService.NotifyModerationWarning(ctx, user.ID, reason)
+	s.record(ctx, actor, models.AuditActionUserWarned, fmt.Sprintf("user:%d", user.ID), reason)
+	return nil
+}
+
+// BanUser suspende la cuenta: el usuario no puede iniciar sesión ni publicar, y el middleware
+// de autenticación rechaza sus sesiones abiertas desde el siguiente request.
+func (s *ModerationService) BanUser(ctx context.Context, actor policies.Actor, userID int, reason string) error {
+	user, err := s.moderatedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsBanned() {
+		return errors.NewBadRequestError("User is already banned")
+	}
+
+	if err := s.userRepository.Update(ctx, user.ID, map[string]interface{}{"banned_at": time.Now(), "ban_reason": reason}); err != nil {
+		return err
+	}
+
+	s.record(ctx, actor, models.AuditActionUserBanned, fmt.Sprintf("user:%d", user.ID), reason)
+	return nil
+}
+
+func (s *ModerationService) UnbanUser(ctx context.Context, actor policies.Actor, userID int, reason string) error {
+	user, err := s.moderatedUser(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsBanned() {
+		return errors.NewBadRequestError("User is not banned")
+	}
+
+	if err := s.userRepository.Update(ctx, user.ID, map[string]interface{}{"banned_at": nil, "ban_reason": ""}); err != nil {
+		return err
+	}
+
+	s.record(ctx, actor, models.AuditActionUserUnbanned, fmt.Sprintf("user:%d", user.ID), reason)
+	return nil
+}
+
+func (s *ModerationService) moderatedUser(ctx context.Context, actor policies.Actor, userID int) (*models.User, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policies.CanModerateUser(actor, user) {
+		return nil, ErrCannotModerate
+	}
+
+	return user, nil
+}
+
+func (s *ModerationService) closeReports(ctx context.Context, actor policies.Actor, pet *models.Pet, action string, reason string) error {
+	resolution := strings.TrimPrefix(action, "moderation.")
+	if reason != "" {
+		resolution += ": " + reason
+	}
+
+	if _, err := s.reportRepository.ResolveOpenByPetID(ctx, pet.ID, resolution, actor.UserID); err != nil {
+		return err
+	}
+
+	s.record(ctx, actor, action, fmt.Sprintf("pet:%d", pet.ID), reason)
+	return nil
+}
+
+func (s *ModerationService) record(ctx context.Context, actor policies.Actor, action string, subject string, reason string) {
+	s.auditService.Record(ctx, &models.AuditEvent{
+		Action:  action,
+		ActorID: &actor.UserID,
+		Subject: subject,
+		Details: reason,
+	})
+}
+
+func newPetReportDTO(report *models.PetReport) PetReportDTO {
+	dto := PetReportDTO{
+		ID:           report.ID,
+		PetID:        report.PetID,
+		ReporterID:   report.ReporterID,
+		Reason:       report.Reason,
+		Details:      report.Details,
+		Status:       report.Status,
+		Resolution:   report.Resolution,
+		ResolvedByID: report.ResolvedByID,
+		ResolvedAt:   report.ResolvedAt,
+		CreatedAt:    report.CreatedAt,
+	}
+
+	if report.Pet.ID != 0 {
+		dto.PetName = report.Pet.Name
+		dto.PetStatus = report.Pet.Status
+		dto.PetHidden = report.Pet.IsHidden()
+	}
+	if report.Reporter.ID != 0 {
+		dto.ReporterName = displayName(&report.Reporter)
+	}
+
+	return dto
+}
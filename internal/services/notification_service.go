@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/mailer"
+)
+
+const (
+	NotificationPetCreated       = "pet_created"
+	NotificationSightingReceived = "sighting_received"
+	NotificationMessageReceived  = "message_received"
+	NotificationPetFound         = "pet_found"
+	NotificationPetExpiring      = "pet_expiring"
+	NotificationModerationWarn   = "moderation_warning"
+
+	messagePreviewMaxRunes = 200
+)
+
+var notificationKinds = []string{NotificationPetCreated, NotificationSightingReceived, NotificationMessageReceived, NotificationPetFound, NotificationPetExpiring, NotificationModerationWarn}
+
+//go:embed templates/email
+var emailTemplatesFS embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// emailData son los valores disponibles en los templates
+type emailData struct {
+	Language   string
+	Name       string
+	PetName    string
+	PetPlace   string
+	SeenAt     string
+	SeenPlace  string
+	Notes      string
+	SenderName string
+	Preview    string
+	Deadline   string
+	Link       string
+}
+
+// notification es el payload del trabajo que envía un email. El destinatario y sus preferencias
+// se resuelven al ejecutarlo para que el request que lo origina no espere a la base ni al SMTP.
+// Los campos de usuario coinciden con models.EmailJobUsers, que usa la baja de cuentas para
+// borrar los emails que mencionan al usuario
+type notification struct {
+	Kind        string    `json:"kind"`
+	RecipientID int       `json:"recipient_id"`
+	SenderID    int       `json:"sender_id,omitempty"`
+	Data        emailData `json:"data"`
+}
+
+// NotificationService encola los emails como trabajos en segundo plano, que se reintentan
+// si el envío falla
+type NotificationService struct {
+	mailer                 mailer.Mailer
+	userRepository         repositories.UserRepository
+	preferenceRepository   repositories.NotificationPreferenceRepository
+	sightingRepository     repositories.SightingRepository
+	conversationRepository repositories.ConversationRepository
+	jobService             *JobService
+	templates              map[string]*emailTemplate
+	appURL                 string
+	maxAttempts            int
+}
+
+func NewNotificationService(
+	emailConfig config.EmailConfig,
+	userRepository repositories.UserRepository,
+	preferenceRepository repositories.NotificationPreferenceRepository,
+	sightingRepository repositories.SightingRepository,
+	conversationRepository repositories.ConversationRepository,
+	jobService *JobService,
+) *NotificationService {
+	templates, err := loadEmailTemplates()
+	if err != nil {
+		logger.Fatal("failed to load email templates", logger.Err(err))
+	}
+
+	return &NotificationService{
+		mailer:                 mailer.NewMailer(emailConfig),
+		userRepository:         userRepository,
+		preferenceRepository:   preferenceRepository,
+		sightingRepository:     sightingRepository,
+		conversationRepository: conversationRepository,
+		jobService:             jobService,
+		templates:              templates,
+		appURL:                 strings.TrimRight(emailConfig.AppURL, "/"),
+		maxAttempts:            emailConfig.MaxRetries + 1,
+	}
+}
+
+// Mailer expone el transporte configurado, por ejemplo para inspeccionar el MemoryMailer en desarrollo
+func (s *NotificationService) Mailer() mailer.Mailer {
+	return s.mailer
+}
+
+func (s *NotificationService) GetPreferences(ctx context.Context, userID int) (*models.NotificationPreference, error) {
+	preference, err := s.preferenceRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		if !isNotFound(err) {
+			return nil, err
+		}
+		defaults := models.DefaultNotificationPreference(userID)
+		return &defaults, nil
+	}
+
+	return preference, nil
+}
+
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID int, dto *NotificationPreferenceUpdateDTO) (*models.NotificationPreference, error) {
+	preference, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if dto.Language != nil {
+		preference.Language = *dto.Language
+	}
+	if dto.PetCreated != nil {
+		preference.PetCreated = *dto.PetCreated
+	}
+	if dto.SightingReceived != nil {
+		preference.SightingReceived = *dto.SightingReceived
+	}
+	if dto.MessageReceived != nil {
+		preference.MessageReceived = *dto.MessageReceived
+	}
+	if dto.PetFound != nil {
+		preference.PetFound = *dto.PetFound
+	}
+
+	if err := s.preferenceRepository.Save(ctx, preference); err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+func (s *NotificationService) NotifyPetCreated(ctx context.Context, pet *models.Pet) {
+	s.enqueue(ctx, notification{
+		Kind:        NotificationPetCreated,
+		RecipientID: pet.UserID,
+		Data: emailData{
+			PetName:  pet.Name,
+			PetPlace: pet.LastSeenPlace,
+			Link:     s.petLink(pet.ID),
+		},
+	})
+}
+
+func (s *NotificationService) NotifySightingReceived(ctx context.Context, pet *models.Pet, sighting *SightingDTO) {
+	if sighting.ReporterID == pet.UserID {
+		return
+	}
+
+	s.enqueue(ctx, notification{
+		Kind:        NotificationSightingReceived,
+		RecipientID: pet.UserID,
+		SenderID:    sighting.ReporterID,
+		Data: emailData{
+			PetName:    pet.Name,
+			SeenAt:     sighting.SeenAt.Format("02/01/2006 15:04"),
+			SeenPlace:  sighting.SeenPlace,
+			Notes:      sighting.Notes,
+			SenderName: sighting.ReporterName,
+			Link:       s.petLink(pet.ID) + "/sightings",
+		},
+	})
+}
+
+func (s *NotificationService) NotifyMessageReceived(ctx context.Context, conversation *models.Conversation, message *models.Message) {
+	recipientID := conversation.OwnerID
+	sender := conversation.Finder
+	if message.SenderID == conversation.OwnerID {
+		recipientID = conversation.FinderID
+		sender = conversation.Owner
+	}
+
+	s.enqueue(ctx, notification{
+		Kind:        NotificationMessageReceived,
+		RecipientID: recipientID,
+		SenderID:    message.SenderID,
+		Data: emailData{
+			PetName:    conversation.Pet.Name,
+			SenderName: displayName(&sender),
+			Preview:    truncateRunes(message.Body, messagePreviewMaxRunes),
+			Link:       fmt.Sprintf("%s/conversations/%d", s.appURL, conversation.ID),
+		},
+	})
+}
+
+// NotifyPetFound avisa a quienes informaron avistamientos o escribieron al dueño
+func (s *NotificationService) NotifyPetFound(ctx context.Context, pet *models.Pet) {
+	recipients := map[int]bool{}
+
+	sightings, err := s.sightingRepository.ListByPetID(ctx, pet.ID)
+	if err != nil {
+		slog.Error("failed to list sightings for notifications", "pet_id", pet.ID, logger.Err(err))
+	}
+	for _, sighting := range sightings {
+		recipients[sighting.ReporterID] = true
+	}
+
+	conversations, err := s.conversationRepository.ListByPetID(ctx, pet.ID)
+	if err != nil {
+		slog.Error("failed to list conversations for notifications", "pet_id", pet.ID, logger.Err(err))
+	}
+	for _, conversation := range conversations {
+		recipients[conversation.FinderID] = true
+	}
+
+	delete(recipients, pet.UserID)
+
+	for recipientID := range recipients {
+		s.enqueue(ctx, notification{
+			Kind:        NotificationPetFound,
+			RecipientID: recipientID,
+			Data: emailData{
+				PetName: pet.Name,
+				Link:    s.petLink(pet.ID),
+			},
+		})
+	}
+}
+
+// NotifyPetExpiring le pregunta al dueño si la mascota sigue perdida antes de archivar la publicación
+func (s *NotificationService) NotifyPetExpiring(ctx context.Context, pet *models.Pet, archiveAt time.Time) {
+	s.enqueue(ctx, notification{
+		Kind:        NotificationPetExpiring,
+		RecipientID: pet.UserID,
+		Data: emailData{
+			PetName:  pet.Name,
+			Deadline: archiveAt.Format("02/01/2006"),
+			Link:     s.petLink(pet.ID),
+		},
+	})
+}
+
+func (s *NotificationService) NotifyModerationWarning(ctx context.Context, userID int, reason string) {
+	s.enqueue(ctx, notification{
+		Kind:        NotificationModerationWarn,
+		RecipientID: userID,
+		Data: emailData{
+			Notes: reason,
+			Link:  s.appURL,
+		},
+	})
+}
+
+func (s *NotificationService) enqueue(ctx context.Context, n notification) {
+	_, err := s.jobService.Enqueue(ctx, models.JobTypeSendEmail, n, JobOptions{Queue: models.JobQueueEmails, MaxAttempts: s.maxAttempts})
+	if err != nil {
+		slog.Error("failed to enqueue email", "kind", n.Kind, "user_id", n.RecipientID, logger.Err(err))
+	}
+}
+
+// SendEmail es el handler del trabajo email.send. Si el usuario ya no existe o desactivó
+// ese aviso el trabajo termina sin enviar nada; un error del transporte provoca un reintento.
+func (s *NotificationService) SendEmail(ctx context.Context, payload json.RawMessage) error {
+	var n notification
+	if err := json.Unmarshal(payload, &n); err != nil {
+		return err
+	}
+
+	user, err := s.userRepository.GetByID(ctx, n.RecipientID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	preference, err := s.GetPreferences(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !notificationEnabled(preference, n.Kind) {
+		return nil
+	}
+
+	n.Data.Language = preference.Language
+	n.Data.Name = user.Name
+
+	message, err := s.render(n.Kind, preference.Language, &n.Data)
+	if err != nil {
+		return err
+	}
+	message.To = user.Email
+
+	return s.mailer.Send(ctx, message)
+}
+
+func (s *NotificationService) render(kind string, language string, data *emailData) (*mailer.Message, error) {
+	tmpl, ok := s.templates[language+"/"+kind]
+	if !ok {
+		tmpl, ok = s.templates[models.LanguageES+"/"+kind]
+	}
+	if !ok {
+		return nil, fmt.Errorf("email template %s not found", kind)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, err
+	}
+
+	return &mailer.Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    html.String(),
+	}, nil
+}
+
+func (s *NotificationService) petLink(petID int) string {
+	return fmt.Sprintf("%s/pets/%d", s.appURL, petID)
+}
+
+func loadEmailTemplates() (map[string]*emailTemplate, error) {
+	const dir = "templates/email"
+	templates := map[string]*emailTemplate{}
+
+	for _, language := range models.Languages {
+		common := fmt.Sprintf("%s/%s/common.tmpl", dir, language)
+
+		for _, kind := range notificationKinds {
+			file := fmt.Sprintf("%s/%s/%s.tmpl", dir, language, kind)
+
+			text, err := texttemplate.ParseFS(emailTemplatesFS, common, file)
+			if err != nil {
+				return nil, err
+			}
+
+			html, err := htmltemplate.ParseFS(emailTemplatesFS, dir+"/layout.html.tmpl", common, file)
+			if err != nil {
+				return nil, err
+			}
+
+			templates[language+"/"+kind] = &emailTemplate{text: text, html: html}
+		}
+	}
+
+	return templates, nil
+}
+
+func notificationEnabled(preference *models.NotificationPreference, kind string) bool {
+	switch kind {
+	case NotificationPetCreated:
+		return preference.PetCreated
+	case NotificationSightingReceived:
+		return preference.SightingReceived
+	case NotificationMessageReceived:
+		return preference.MessageReceived
+	case NotificationPetFound:
+		return preference.PetFound
+	case NotificationPetExpiring, NotificationModerationWarn:
+		// Estos avisos no se pueden desactivar: el recordatorio evita que la publicación se
+		// archive sin aviso y las advertencias de moderación tienen que llegar siempre
+		return true
+	}
+	return false
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/eventbus"
+	"go-api-find-my-friend/pkg/logger"
+)
+
+// OutboxDispatcher lee los eventos pendientes del outbox y los publica en el bus.
+// Un evento solo se marca como despachado cuando todos sus handlers terminaron bien,
+// por lo que la entrega es at-least-once; al reintentarlo solo se ejecutan los handlers que
+// fallaron. Si un evento falla, los siguientes del mismo agregado esperan a que se reintente
+// o pase a dead para conservar el orden. Cada instancia toma los eventos con un lock, así
+// varias réplicas pueden despachar a la vez sin entregar dos veces el mismo evento.
+type OutboxDispatcher struct {
+	outboxRepository repositories.OutboxRepository
+	bus              *eventbus.Bus
+	pollInterval     time.Duration
+	batchSize        int
+	retryBase        time.Duration
+	retryMax         time.Duration
+	maxAttempts      int
+	lockTimeout      time.Duration
+	workerID         string
+	wake             <-chan struct{}
+	startOnce        sync.Once
+	stopOnce         sync.Once
+	stop             chan struct{}
+	wg               sync.WaitGroup
+}
+
+// NewOutboxDispatcher crea el dispatcher; wake adelanta el próximo poll y puede ser nil
+func NewOutboxDispatcher(outboxRepository repositories.OutboxRepository, bus *eventbus.Bus, outboxConfig config.OutboxConfig, wake <-chan struct{}) *OutboxDispatcher {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+
+	return &OutboxDispatcher{
+		outboxRepository: outboxRepository,
+		bus:              bus,
+		pollInterval:     outboxConfig.PollInterval,
+		batchSize:        max(outboxConfig.BatchSize, 1),
+		retryBase:        outboxConfig.RetryBase,
+		retryMax:         outboxConfig.RetryMax,
+		maxAttempts:      max(outboxConfig.MaxAttempts, 1),
+		lockTimeout:      outboxConfig.LockTimeout,
+		workerID:         fmt.Sprintf("%s/outbox/%d", hostname, os.Getpid()),
+		wake:             wake,
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start lanza el loop de despacho; los handlers se suscriben al bus antes con RegisterEventHandlers.
+// Llamarlo más de una vez no tiene efecto.
+func (d *OutboxDispatcher) Start() {
+	d.startOnce.Do(func() {
+		d.wg.Add(1)
+		go d.run()
+	})
+}
+
+// Stop detiene el loop y espera a que termine el lote en curso o a que venza ctx.
+// Los eventos que queden pendientes se despachan en el próximo arranque
+func (d *OutboxDispatcher) Stop(ctx context.Context) error {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+	return waitDone(ctx, &d.wg)
+}
+
+func (d *OutboxDispatcher) run() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	// El lote en curso no se cancela al detenerse: Stop espera a que termine
+	ctx := context.Background()
+	for {
+		// Solo se toma el primer evento pendiente de cada agregado, así que si se despachó algo
+		// puede haber más listos y se vuelve a leer sin esperar
+		if d.DispatchPending(ctx) > 0 {
+			select {
+			case <-d.stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// DispatchPending publica un lote de eventos pendientes y devuelve cuántos se despacharon
+func (d *OutboxDispatcher) DispatchPending(ctx context.Context) int {
+	now := time.Now()
+
+	events, err := d.outboxRepository.ListPending(ctx, now, d.batchSize)
+	if err != nil {
+		slog.Error("failed to read outbox", logger.Err(err))
+		return 0
+	}
+
+	dispatched := 0
+
+	for i := range events {
+		event := &events[i]
+
+		claimed, err := d.outboxRepository.Claim(ctx, event.ID, d.workerID, now, now.Add(d.lockTimeout))
+		if err != nil {
+			slog.Error("failed to claim outbox event", "event_id", event.ID, logger.Err(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		done := completedHandlers(event.CompletedHandlers)
+		succeeded, err := d.bus.Deliver(ctx, toBusEvent(event), done)
+		for _, name := range succeeded {
+			done[name] = true
+		}
+
+		if err != nil {
+			d.markFailed(ctx, event, done, err)
+			continue
+		}
+
+		if err := d.outboxRepository.MarkDispatched(ctx, event.ID, d.workerID); err != nil {
+			slog.Error("failed to mark outbox event as dispatched", "event_id", event.ID, logger.Err(err))
+			continue
+		}
+		dispatched++
+	}
+
+	return dispatched
+}
+
+func (d *OutboxDispatcher) markFailed(ctx context.Context, event *models.OutboxEvent, done map[string]bool, cause error) {
+	attempts := event.Attempts + 1
+	completed := joinHandlers(done)
+
+	if attempts >= d.maxAttempts {
+		slog.Error("outbox event exhausted its attempts", "event_type", event.EventType, "event_id", event.ID, "attempts", attempts, logger.Err(cause))
+
+		if err := d.outboxRepository.MarkDead(ctx, event.ID, d.workerID, attempts, completed, cause.Error()); err != nil {
+			slog.Error("failed to update outbox event", "event_id", event.ID, logger.Err(err))
+		}
+		return
+	}
+
+	delay := d.retryMax
+	if attempts <= 16 {
+		delay = min(d.retryBase<<(attempts-1), d.retryMax)
+	}
+
+	slog.Warn("failed to dispatch outbox event", "event_type", event.EventType, "event_id", event.ID, "attempt", attempts, "retry_in", delay.String(), logger.Err(cause))
+
+	if err := d.outboxRepository.MarkFailed(ctx, event.ID, d.workerID, attempts, completed, cause.Error(), time.Now().Add(delay)); err != nil {
+		slog.Error("failed to update outbox event", "event_id", event.ID, logger.Err(err))
+	}
+}
+
+// completedHandlers y joinHandlers convierten la columna completed_handlers ("a,b") en un set y viceversa
+func completedHandlers(value string) map[string]bool {
+	done := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		if name != "" {
+			done[name] = true
+		}
+	}
+	return done
+}
+
+func joinHandlers(done map[string]bool) string {
+	names := make([]string, 0, len(done))
+	for name := range done {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func toBusEvent(event *models.OutboxEvent) eventbus.Event {
+	return eventbus.Event{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       json.RawMessage(event.Payload),
+		OccurredAt:    event.CreatedAt,
+	}
+}
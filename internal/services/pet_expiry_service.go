@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/logger"
+)
+
+const petExpiryBatchSize = 100
+
+// PetExpiryService gestiona el vencimiento de las publicaciones: le pregunta al dueño si la
+// mascota sigue perdida y archiva las que no tuvieron respuesta o ya fueron encontradas
+type PetExpiryService struct {
+	petRepository       repositories.PetRepository
+	petService          *PetService
+	notificationService *NotificationService
+	expireAfter         time.Duration
+	reminderGrace       time.Duration
+	foundGrace          time.Duration
+}
+
+func NewPetExpiryService(petRepository repositories.PetRepository, petService *PetService, notificationService *NotificationService, expiryConfig config.PostExpiryConfig) *PetExpiryService {
+	return &PetExpiryService{
+		petRepository:       petRepository,
+		petService:          petService,
+		notificationService: notificationService,
+		expireAfter:         expiryConfig.ExpireAfter,
+		reminderGrace:       expiryConfig.ReminderGrace,
+		foundGrace:          expiryConfig.FoundGrace,
+	}
+}
+
+// ExpirePets es el handler del trabajo periódico pets.expire
+func (s *PetExpiryService) ExpirePets(ctx context.Context, payload json.RawMessage) error {
+	now := time.Now()
+
+	reminded, err := s.sendReminders(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	archived, err := s.archive(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	if reminded > 0 || archived > 0 {
+		logger.FromContext(ctx).Info("post expiry finished", "reminded", reminded, "archived", archived)
+	}
+	return nil
+}
+
+func (s *PetExpiryService) sendReminders(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+
+	for {
+		pets, err := s.petRepository.ListExpiring(ctx, now.Add(-s.expireAfter), petExpiryBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for i := range pets {
+			// Se marca antes de encolar el email: si el trabajo se reintenta no se repite el aviso
+			if err := s.petRepository.MarkReminderSent(ctx, pets[i].ID, now); err != nil {
+				return sent, err
+			}
+			s.notificationService.NotifyPetExpiring(ctx, &pets[i], now.Add(s.reminderGrace))
+			sent++
+		}
+
+		if len(pets) < petExpiryBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+func (s *PetExpiryService) archive(ctx context.Context, now time.Time) (int, error) {
+	archived := 0
+
+	for {
+		pets, err := s.petRepository.ListArchivable(ctx, now.Add(-s.reminderGrace), now.Add(-s.foundGrace), petExpiryBatchSize)
+		if err != nil {
+			return archived, err
+		}
+
+		for i := range pets {
+			reason := "No answer to the expiry reminder"
+			if models.IsFoundStatus(pets[i].Status) {
+				reason = "Found grace period elapsed"
+			}
+			if err := s.petService.transition(ctx, &pets[i], models.PetStatusArchived, nil, reason, nil); err != nil {
+				return archived, err
+			}
+			archived++
+		}
+
+		if len(pets) < petExpiryBatchSize {
+			return archived, nil
+		}
+	}
+}
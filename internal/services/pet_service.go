@@ -1,55 +1,80 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
 	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
 	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/logger"
 	"go-api-find-my-friend/pkg/pagination"
-	"sync"
+	"go-api-find-my-friend/pkg/ratelimit"
+	"math"
+	"strings"
 	"time"
 )
 
-type PetService struct {
-	petRepository repositories.PetRepository
-	fileService   *FileService
-}
+// lastSeenTimeLayout es el formato dd-mm-yyyy de last_seen_time, igual al crear y al editar
+const lastSeenTimeLayout = "02-01-2006"
 
 var (
-	petServiceInstance *PetService
-	petServiceOnce     sync.Once
+	ErrContactRelayOnly = errors.NewForbiddenError("The owner only accepts contact through in-app messages")
 )
 
-func NewPetService() *PetService {
-	petServiceOnce.Do(func() {
-		petServiceInstance = &PetService{
-			petRepository: repositories.NewPetRepository(),
-			fileService:   NewFileService(),
-		}
-	})
-	return petServiceInstance
+type PetService struct {
+	petRepository  repositories.PetRepository
+	userRepository repositories.UserRepository
+	fileService    *FileService
+	auditService   *AuditService
+	revealLimiter  *ratelimit.Limiter
 }
 
-func (s *PetService) CreatePet(dto *PetCreateDTO, userID int) (*models.Pet, error) {
-	lastSeenTime, err := time.Parse("2006-02-01", dto.LastSeenTime)
+func NewPetService(petRepository repositories.PetRepository, userRepository repositories.UserRepository, auditService *AuditService, contactRevealConfig config.RateLimitConfig) *PetService {
+	return &PetService{
+		petRepository:  petRepository,
+		userRepository: userRepository,
+		fileService:    NewFileService(),
+		auditService:   auditService,
+		revealLimiter:  ratelimit.NewLimiter(contactRevealConfig.Requests, contactRevealConfig.Window),
+	}
+}
+
+func (s *PetService) CreatePet(ctx context.Context, dto *PetCreateDTO, userID int) (*models.Pet, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBanned() {
+		return nil, ErrAccountSuspended
+	}
+
+	lastSeenTime, err := time.Parse(lastSeenTimeLayout, dto.LastSeenTime)
 	if err != nil {
-		fmt.Println("Error parsing last seen time:", err)
-		fmt.Println("Error parsing last seen time:", dto.LastSeenTime)
+		logger.FromContext(ctx).Debug("invalid last seen time", "value", dto.LastSeenTime, logger.Err(err))
 		return nil, errors.NewBadRequestError("Invalid date format. Expected format: dd-mm-yyyy")
 	}
 
+	contactPrivacy := dto.ContactPrivacy
+	if contactPrivacy == "" {
+		contactPrivacy = models.ContactShowPhone
+	}
+
 	pet := models.Pet{
-		Name:          dto.Name,
-		Description:   dto.Description,
-		Type:          dto.Type,
-		Breed:         dto.Breed,
-		UserID:        userID,
-		LastSeenTime:  lastSeenTime,
-		LastSeenPlace: dto.LastSeenProvince + ", " + dto.LastSeenCity,
-		IsFound:       false,
+		Name:           dto.Name,
+		Description:    dto.Description,
+		Type:           dto.Type,
+		Breed:          dto.Breed,
+		UserID:         userID,
+		LastSeenTime:   lastSeenTime,
+		LastSeenPlace:  dto.LastSeenProvince + ", " + dto.LastSeenCity,
+		Status:         models.PetStatusLost,
+		IsFound:        false,
+		ContactPrivacy: contactPrivacy,
 	}
 
-	err = s.petRepository.Create(&pet, dto.Picture)
+	err = s.petRepository.Create(ctx, &pet, dto.Picture)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +82,7 @@ func (s *PetService) CreatePet(dto *PetCreateDTO, userID int) (*models.Pet, erro
 	return &pet, nil
 }
 
-func (s *PetService) SearchPets(filters *pagination.FilterPet, paginationParams *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+func (s *PetService) SearchPets(ctx context.Context, filters *pagination.FilterPet, paginationParams *pagination.PaginationParams) (*pagination.PaginationResult, error) {
 	customConfig := pagination.PaginationConfig{
 		DefaultPage:    1,
 		DefaultSize:    10,
@@ -67,7 +92,7 @@ func (s *PetService) SearchPets(filters *pagination.FilterPet, paginationParams
 	}
 	pagination.NormalizeParams(paginationParams, customConfig)
 
-	result, err := s.petRepository.Search(filters, paginationParams)
+	result, err := s.petRepository.Search(ctx, filters, paginationParams)
 	if err != nil {
 		return nil, err
 	}
@@ -75,22 +100,37 @@ func (s *PetService) SearchPets(filters *pagination.FilterPet, paginationParams
 	return result, nil
 }
 
-func (s *PetService) GetPetByID(petID int) (*models.Pet, error) {
-	pet, err := s.petRepository.GetByID(petID)
+func (s *PetService) GetPetByID(ctx context.Context, petID int) (*models.Pet, error) {
+	pet, err := s.petRepository.GetByID(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pet, nil
+}
+
+// GetVisiblePet devuelve la mascota si el actor puede verla; las publicaciones ocultas por
+// moderación o dadas de baja responden como inexistentes para el resto de los usuarios
+func (s *PetService) GetVisiblePet(ctx context.Context, actor policies.Actor, petID int) (*models.Pet, error) {
+	pet, err := s.GetPetByID(ctx, petID)
 	if err != nil {
 		return nil, err
 	}
 
+	if !policies.CanViewPet(actor, pet) {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("pet with id %d not found", petID))
+	}
+
 	return pet, nil
 }
 
-func (s *PetService) UpdatePet(userID int, petID int, dto *PetUpdateDTO) error {
-	pet, err := s.GetPetByID(petID)
+func (s *PetService) UpdatePet(ctx context.Context, actor policies.Actor, petID int, dto *PetUpdateDTO) error {
+	pet, err := s.GetPetByID(ctx, petID)
 	if err != nil {
 		return err
 	}
 
-	if pet.UserID != userID {
+	if !policies.CanEditPet(actor, pet) {
 		return errors.NewForbiddenError("You can only update your own pets")
 	}
 
@@ -106,7 +146,7 @@ func (s *PetService) UpdatePet(userID int, petID int, dto *PetUpdateDTO) error {
 		updates["breed"] = *dto.Breed
 	}
 	if dto.LastSeenTime != nil {
-		lastSeenTime, err := time.Parse("02-01-2006", *dto.LastSeenTime)
+		lastSeenTime, err := time.Parse(lastSeenTimeLayout, *dto.LastSeenTime)
 		if err != nil {
 			return errors.NewBadRequestError("Invalid date format. Expected format: dd-mm-yyyy")
 		}
@@ -118,11 +158,11 @@ func (s *PetService) UpdatePet(userID int, petID int, dto *PetUpdateDTO) error {
 	if dto.PictureURL != nil {
 		updates["picture_url"] = *dto.PictureURL
 	}
-	if dto.IsFound != nil {
-		updates["is_found"] = *dto.IsFound
+	if dto.ContactPrivacy != nil {
+		updates["contact_privacy"] = *dto.ContactPrivacy
 	}
 
-	err = s.petRepository.Update(petID, updates)
+	err = s.petRepository.Update(ctx, petID, updates)
 	if err != nil {
 		return err
 	}
@@ -130,13 +170,13 @@ func (s *PetService) UpdatePet(userID int, petID int, dto *PetUpdateDTO) error {
 	return nil
 }
 
-func (s *PetService) UpdatePetAsFound(userID int, petID int) error {
-	pet, err := s.GetPetByID(petID)
+func (s *PetService) UpdatePetAsFound(ctx context.Context, actor policies.Actor, petID int) error {
+	pet, err := s.GetPetByID(ctx, petID)
 	if err != nil {
 		return err
 	}
 
-	if pet.UserID != userID {
+	if !policies.CanEditPet(actor, pet) {
 		return errors.NewForbiddenError("You can only update your own pets")
 	}
 
@@ -144,31 +184,184 @@ func (s *PetService) UpdatePetAsFound(userID int, petID int) error {
 		return errors.NewBadRequestError("Pet already marked as found")
 	}
 
-	updates := make(map[string]interface{})
-	updates["is_found"] = true
+	return s.transition(ctx, pet, models.PetStatusFound, &actor.UserID, "", nil)
+}
 
-	err = s.petRepository.Update(petID, updates)
+func (s *PetService) ChangeStatus(ctx context.Context, actor policies.Actor, petID int, dto *PetStatusUpdateDTO) error {
+	pet, err := s.GetPetByID(ctx, petID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if !policies.CanChangePetStatus(actor, pet, dto.Status) {
+		return errors.NewForbiddenError("You are not allowed to change this pet status")
+	}
+
+	return s.transition(ctx, pet, dto.Status, &actor.UserID, strings.TrimSpace(dto.Reason), nil)
 }
 
-func (s *PetService) DeletePet(userID int, petID int) error {
-	pet, err := s.GetPetByID(petID)
+// GetStatusHistory devuelve el historial solo si el actor puede ver la publicación
+func (s *PetService) GetStatusHistory(ctx context.Context, actor policies.Actor, petID int) ([]PetStatusChangeDTO, error) {
+	if _, err := s.GetVisiblePet(ctx, actor, petID); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.petRepository.ListStatusChanges(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PetStatusChangeDTO, 0, len(changes))
+	for _, change := range changes {
+		dto := PetStatusChangeDTO{
+			ID:          change.ID,
+			FromStatus:  change.FromStatus,
+			ToStatus:    change.ToStatus,
+			ChangedByID: change.ChangedByID,
+			Reason:      change.Reason,
+			CreatedAt:   change.CreatedAt,
+		}
+		if change.ChangedBy != nil {
+			dto.ChangedByName = displayName(change.ChangedBy)
+		}
+		result = append(result, dto)
+	}
+
+	return result, nil
+}
+
+// transition valida el cambio de estado y lo aplica junto con los campos que dependen de él.
+// changedByID es nulo cuando el cambio lo hace el sistema.
+func (s *PetService) transition(ctx context.Context, pet *models.Pet, status string, changedByID *int, reason string, updates map[string]interface{}) error {
+	if pet.Status == status {
+		return errors.NewBadRequestError(fmt.Sprintf("Pet is already %s", status))
+	}
+
+	if !models.CanTransitionPetStatus(pet.Status, status) {
+		return errors.NewConflictError(fmt.Sprintf("Cannot change pet status from %s to %s", pet.Status, status))
+	}
+
+	if updates == nil {
+		updates = make(map[string]interface{})
+	}
+
+	now := time.Now()
+	switch status {
+	case models.PetStatusLost, models.PetStatusSighted:
+		updates["is_found"] = false
+		updates["found_at"] = nil
+		updates["archived_at"] = nil
+		// Si la búsqueda se retoma, el plazo de vencimiento vuelve a empezar
+		if !models.IsActiveStatus(pet.Status) {
+			updates["renewed_at"] = now
+			updates["reminder_sent_at"] = nil
+		}
+	case models.PetStatusFound:
+		updates["is_found"] = true
+		updates["found_at"] = now
+	case models.PetStatusReunited:
+		updates["is_found"] = true
+		if pet.FoundAt == nil {
+			updates["found_at"] = now
+		}
+	case models.PetStatusArchived:
+		updates["archived_at"] = now
+	}
+
+	change := models.PetStatusChange{
+		PetID:       pet.ID,
+		FromStatus:  pet.Status,
+		ToStatus:    status,
+		ChangedByID: changedByID,
+		Reason:      reason,
+	}
+
+	return s.petRepository.ChangeStatus(ctx, &change, updates)
+}
+
+// ReactivatePet confirma que la mascota sigue perdida: vuelve a publicar una publicación archivada
+// o responde el recordatorio de vencimiento, y en ambos casos reinicia el plazo
+func (s *PetService) ReactivatePet(ctx context.Context, actor policies.Actor, petID int) error {
+	pet, err := s.GetPetByID(ctx, petID)
 	if err != nil {
 		return err
 	}
 
-	if pet.UserID != userID {
+	if !policies.CanEditPet(actor, pet) {
+		return errors.NewForbiddenError("You can only reactivate your own pets")
+	}
+
+	if pet.IsFound {
+		return errors.NewBadRequestError("Found pets cannot be reactivated")
+	}
+
+	if models.IsActiveStatus(pet.Status) {
+		return s.petRepository.Renew(ctx, petID, time.Now())
+	}
+
+	return s.transition(ctx, pet, models.PetStatusLost, &actor.UserID, "", nil)
+}
+
+func (s *PetService) DeletePet(ctx context.Context, actor policies.Actor, petID int) error {
+	pet, err := s.GetPetByID(ctx, petID)
+	if err != nil {
+		return err
+	}
+
+	if !policies.CanDeletePet(actor, pet) {
 		return errors.NewForbiddenError("You can only delete your own pets")
 	}
 
-	err = s.petRepository.Delete(pet)
+	err = s.petRepository.Delete(ctx, pet)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// RevealContact devuelve el dato de contacto que el dueño eligió compartir.
+// Cada revelación queda auditada y se limita por usuario para evitar el scraping.
+func (s *PetService) RevealContact(ctx context.Context, actor policies.Actor, petID int, ip string) (*PetContactDTO, error) {
+	pet, err := s.GetVisiblePet(ctx, actor, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pet.ContactPrivacy == models.ContactRelayOnly && !actor.Owns(pet) {
+		return nil, ErrContactRelayOnly
+	}
+
+	if !actor.Owns(pet) {
+		allowed, retryAfter := s.revealLimiter.Allow(fmt.Sprintf("user:%d", actor.UserID))
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			return nil, errors.NewTooManyRequestsError("Too many contact requests, try again later", seconds)
+		}
+	}
+
+	contact := PetContactDTO{
+		OwnerName:     pet.User.Name,
+		OwnerLastName: pet.User.LastName,
+	}
+
+	switch {
+	case actor.Owns(pet):
+		contact.OwnerPhone = pet.User.Phone
+		contact.OwnerEmail = pet.User.Email
+	case pet.ContactPrivacy == models.ContactShowEmail:
+		contact.OwnerEmail = pet.User.Email
+	default:
+		contact.OwnerPhone = pet.User.Phone
+	}
+
+	s.auditService.Record(ctx, &models.AuditEvent{
+		Action:  models.AuditActionContactReveal,
+		ActorID: &actor.UserID,
+		Subject: fmt.Sprintf("pet:%d", pet.ID),
+		IP:      ip,
+		Details: pet.ContactPrivacy,
+	})
+
+	return &contact, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/errors"
+)
+
+func TestPetServiceGetVisiblePet(t *testing.T) {
+	db := openTestDB(t)
+
+	owner := &models.User{Name: "Ana", LastName: "García", Email: "ana@example.com", Password: "hash", Role: models.RoleUser}
+	if err := db.Create(owner).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	hiddenAt := time.Now()
+	pets := map[string]*models.Pet{
+		"visible": {Name: "Toby", Type: "dog", Breed: "Caniche", UserID: owner.ID, LastSeenTime: time.Now(), LastSeenPlace: "San Martín", Status: models.PetStatusLost},
+		"hidden":  {Name: "Luna", Type: "dog", Breed: "Labrador", UserID: owner.ID, LastSeenTime: time.Now(), LastSeenPlace: "Córdoba", Status: models.PetStatusLost, HiddenAt: &hiddenAt},
+		"removed": {Name: "Nube", Type: "cat", Breed: "Siamés", UserID: owner.ID, LastSeenTime: time.Now(), LastSeenPlace: "La Plata", Status: models.PetStatusRemoved},
+	}
+	for _, pet := range pets {
+		if err := db.Create(pet).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	petService := NewPetService(
+		repositories.NewPetRepositorySQLServer(db, nil, 5*time.Second, nil, nil),
+		repositories.NewUserRepositorySQLServer(db, 5*time.Second, nil),
+		NewAuditService(repositories.NewAuditRepositorySQLServer(db, 5*time.Second)),
+		config.RateLimitConfig{},
+	)
+
+	ownerActor := policies.NewActor(owner.ID, models.RoleUser)
+	otherActor := policies.NewActor(owner.ID+1, models.RoleUser)
+	moderatorActor := policies.NewActor(owner.ID+2, models.RoleModerator)
+
+	tests := []struct {
+		name  string
+		actor policies.Actor
+		pet   string
+		want  int
+	}{
+		{"another user, visible", otherActor, "visible", http.StatusOK},
+		{"another user, hidden", otherActor, "hidden", http.StatusNotFound},
+		{"another user, removed", otherActor, "removed", http.StatusNotFound},
+		{"owner, hidden", ownerActor, "hidden", http.StatusOK},
+		{"moderator, hidden", moderatorActor, "hidden", http.StatusOK},
+		{"moderator, removed", moderatorActor, "removed", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pet, err := petService.GetVisiblePet(context.Background(), tt.actor, pets[tt.pet].ID)
+
+			status := http.StatusOK
+			if err != nil {
+				appErr, ok := err.(*errors.AppError)
+				if !ok {
+					t.Fatalf("got error %v", err)
+				}
+				status = appErr.Code
+			}
+			if status != tt.want {
+				t.Fatalf("got status %d (%v), want %d", status, err, tt.want)
+			}
+			if status == http.StatusOK && pet.ID != pets[tt.pet].ID {
+				t.Errorf("got pet %d, want %d", pet.ID, pets[tt.pet].ID)
+			}
+		})
+	}
+}
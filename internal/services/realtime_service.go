@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
+	"go-api-find-my-friend/pkg/pagination"
+	"go-api-find-my-friend/pkg/pubsub"
+	"slices"
+	"strings"
+)
+
+const (
+	EventPetCreated      = "pet.created"
+	EventPetFound        = "pet.found"
+	EventSightingCreated = "sighting.created"
+	EventMessageCreated  = "message.created"
+
+	// PetsTopic recibe todas las mascotas nuevas; cada stream filtra las que le interesan
+	PetsTopic = "pets"
+
+	// Atributos de los eventos de PetsTopic por los que filtra Matches
+	attributePetType          = "type"
+	attributePetBreed         = "breed"
+	attributePetLastSeenPlace = "last_seen_place"
+)
+
+func UserTopic(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func PetTopic(petID int) string {
+	return fmt.Sprintf("pet:%d", petID)
+}
+
+type RealtimeService struct {
+	hub        pubsub.Hub
+	petService *PetService
+}
+
+func NewRealtimeService(hub pubsub.Hub, petService *PetService) *RealtimeService {
+	return &RealtimeService{
+		hub:        hub,
+		petService: petService,
+	}
+}
+
+// Subscribe suscribe al usuario a sus propios eventos, a las mascotas indicadas que puede ver y,
+// si se envió algún filtro, a las mascotas nuevas que lo cumplan. Las mascotas que no existen o
+// que el actor no puede ver se descartan sin error, igual que en el detalle
+func (s *RealtimeService) Subscribe(ctx context.Context, actor policies.Actor, petIDs []int, filter *pagination.FilterPet) (*pubsub.Subscription, error) {
+	topics := []string{UserTopic(actor.UserID)}
+
+	for _, petID := range slices.Compact(slices.Sorted(slices.Values(petIDs))) {
+		if _, err := s.petService.GetVisiblePet(ctx, actor, petID); err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		topics = append(topics, PetTopic(petID))
+	}
+
+	if hasPetFilter(filter) {
+		topics = append(topics, PetsTopic)
+	}
+
+	return s.hub.Subscribe(topics...), nil
+}
+
+// Close termina los streams abiertos; se llama al apagar el servidor
+func (s *RealtimeService) Close() {
+	s.hub.Close()
+}
+
+// Matches descarta las mascotas nuevas que no cumplen el filtro del stream. Filtra por el tópico
+// y los atributos del evento, no por el tipo de Data
+func (s *RealtimeService) Matches(event pubsub.Event, filter *pagination.FilterPet) bool {
+	if event.Topic != PetsTopic {
+		return true
+	}
+
+	if filter.Type != nil && !strings.EqualFold(event.Attributes[attributePetType], *filter.Type) {
+		return false
+	}
+	if filter.Breed != nil && !containsFold(event.Attributes[attributePetBreed], *filter.Breed) {
+		return false
+	}
+	if filter.LastSeenPlace != nil && !containsFold(event.Attributes[attributePetLastSeenPlace], *filter.LastSeenPlace) {
+		return false
+	}
+
+	return true
+}
+
+func (s *RealtimeService) PublishPetCreated(ctx context.Context, pet *models.Pet) {
+	s.hub.Publish(PetsTopic, pubsub.Event{
+		Type: EventPetCreated,
+		Attributes: map[string]string{
+			attributePetType:          pet.Type,
+			attributePetBreed:         pet.Breed,
+			attributePetLastSeenPlace: pet.LastSeenPlace,
+		},
+		Data: newPetEventDTO(pet),
+	})
+}
+
+func (s *RealtimeService) PublishPetFound(ctx context.Context, pet *models.Pet) {
+	event := pubsub.Event{Type: EventPetFound, Data: newPetEventDTO(pet)}
+	s.hub.Publish(PetTopic(pet.ID), event)
+	s.hub.Publish(UserTopic(pet.UserID), event)
+}
+
+func (s *RealtimeService) PublishSightingCreated(pet *models.Pet, sighting *SightingDTO) {
+	event := pubsub.Event{Type: EventSightingCreated, Data: sighting}
+	s.hub.Publish(PetTopic(pet.ID), event)
+	s.hub.Publish(UserTopic(pet.UserID), event)
+}
+
+func (s *RealtimeService) PublishMessageCreated(conversation *models.Conversation, message *models.Message) {
+	recipientID := conversation.OwnerID
+	if message.SenderID == conversation.OwnerID {
+		recipientID = conversation.FinderID
+	}
+
+	s.hub.Publish(UserTopic(recipientID), pubsub.Event{Type: EventMessageCreated, Data: message})
+}
+
+func newPetEventDTO(pet *models.Pet) PetEventDTO {
+	return PetEventDTO{
+		ID:            pet.ID,
+		UserID:        pet.UserID,
+		Name:          pet.Name,
+		Type:          pet.Type,
+		Breed:         pet.Breed,
+		LastSeenPlace: pet.LastSeenPlace,
+		PictureURL:    pet.PictureURL,
+		Status:        pet.Status,
+		IsFound:       pet.IsFound,
+	}
+}
+
+func hasPetFilter(filter *pagination.FilterPet) bool {
+	return filter != nil && (filter.Type != nil || filter.Breed != nil || filter.LastSeenPlace != nil)
+}
+
+func containsFold(value string, substring string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(substring))
+}
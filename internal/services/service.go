@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+	"go-api-find-my-friend/pkg/errors"
+	"net/http"
+	"sync"
+)
+
+func isNotFound(err error) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && appErr.Code == http.StatusNotFound
+}
+
+// waitDone espera a que terminen las goroutines de wg o a que venza ctx, lo que pase primero
+func waitDone(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
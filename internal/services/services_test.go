@@ -0,0 +1,26 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/database"
+	_ "go-api-find-my-friend/pkg/database/migrations"
+
+	"gorm.io/gorm"
+)
+
+// openTestDB abre una base SQLite migrada en un archivo temporal que se borra al terminar la prueba
+func openTestDB(t *testing.T) *gorm.DB {
+	db := database.Connect(&config.Config{Database: config.DatabaseConfig{
+		Driver:     database.DriverSQLite,
+		SQLitePath: filepath.Join(t.TempDir(), "test.db"),
+	}})
+	t.Cleanup(func() { database.Close(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
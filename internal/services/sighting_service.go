@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/logger"
+	"strings"
+	"time"
+)
+
+type SightingService struct {
+	sightingRepository  repositories.SightingRepository
+	petService          *PetService
+	userRepository      repositories.UserRepository
+	realtimeService     *RealtimeService
+	notificationService *NotificationService
+}
+
+func NewSightingService(
+	sightingRepository repositories.SightingRepository,
+	petService *PetService,
+	userRepository repositories.UserRepository,
+	realtimeService *RealtimeService,
+	notificationService *NotificationService,
+) *SightingService {
+	return &SightingService{
+		sightingRepository:  sightingRepository,
+		petService:          petService,
+		userRepository:      userRepository,
+		realtimeService:     realtimeService,
+		notificationService: notificationService,
+	}
+}
+
+// CreateSighting y ListSightings solo operan sobre mascotas que el actor puede ver: las ocultas
+// o dadas de baja responden 404 salvo para el dueño y la moderación
+func (s *SightingService) CreateSighting(ctx context.Context, actor policies.Actor, petID int, dto *SightingCreateDTO) (*SightingDTO, error) {
+	pet, err := s.petService.GetVisiblePet(ctx, actor, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter, err := s.userRepository.GetByID(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	seenAt, _ := time.Parse(time.RFC3339, dto.SeenAt)
+
+	sighting := models.Sighting{
+		PetID:      pet.ID,
+		ReporterID: actor.UserID,
+		SeenAt:     seenAt,
+		SeenPlace:  dto.SeenProvince + ", " + dto.SeenCity,
+		Notes:      strings.TrimSpace(dto.Notes),
+	}
+
+	if err := s.sightingRepository.Create(ctx, &sighting); err != nil {
+		return nil, err
+	}
+
+	// El primer avistamiento de una mascota perdida la pasa a sighted
+	if pet.Status == models.PetStatusLost {
+		if err := s.petService.transition(ctx, pet, models.PetStatusSighted, &actor.UserID, "Sighting reported", nil); err != nil {
+			logger.FromContext(ctx).Error("failed to mark pet as sighted", "pet_id", pet.ID, logger.Err(err))
+		}
+	}
+
+	result := newSightingDTO(&sighting, displayName(reporter))
+	s.realtimeService.PublishSightingCreated(pet, &result)
+	s.notificationService.NotifySightingReceived(ctx, pet, &result)
+
+	return &result, nil
+}
+
+func (s *SightingService) ListSightings(ctx context.Context, actor policies.Actor, petID int) ([]SightingDTO, error) {
+	if _, err := s.petService.GetVisiblePet(ctx, actor, petID); err != nil {
+		return nil, err
+	}
+
+	sightings, err := s.sightingRepository.ListByPetID(ctx, petID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SightingDTO, 0, len(sightings))
+	for i := range sightings {
+		result = append(result, newSightingDTO(&sightings[i], displayName(&sightings[i].Reporter)))
+	}
+
+	return result, nil
+}
+
+func newSightingDTO(sighting *models.Sighting, reporterName string) SightingDTO {
+	return SightingDTO{
+		ID:           sighting.ID,
+		PetID:        sighting.PetID,
+		ReporterID:   sighting.ReporterID,
+		ReporterName: reporterName,
+		SeenAt:       sighting.SeenAt,
+		SeenPlace:    sighting.SeenPlace,
+		Notes:        sighting.Notes,
+		CreatedAt:    sighting.CreatedAt,
+	}
+}
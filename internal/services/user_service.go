@@ -1,34 +1,41 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/policies"
 	"go-api-find-my-friend/internal/repositories"
 	"go-api-find-my-friend/pkg/errors"
-	"sync"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidCurrentPassword = errors.NewUnauthorizedError("current password is incorrect")
+)
+
 type UserService struct {
 	userRepository repositories.UserRepository
+	loginGuard     *LoginGuard
 }
 
-var (
-	userServiceInstance *UserService
-	userServiceOnce     sync.Once
-)
+func NewUserService(userRepository repositories.UserRepository, loginGuard *LoginGuard) *UserService {
+	return &UserService{
+		userRepository: userRepository,
+		loginGuard:     loginGuard,
+	}
+}
 
-func NewUserService() *UserService {
-	userServiceOnce.Do(func() {
-		userServiceInstance = &UserService{
-			userRepository: repositories.NewUserRepository(),
-		}
-	})
-	return userServiceInstance
+func (s *UserService) CreateUser(ctx context.Context, dto *UserCreateDTO) (*models.User, error) {
+	return s.CreateUserWithRole(ctx, dto, models.RoleUser)
 }
 
-func (s *UserService) CreateUser(dto *UserCreateDTO) (*models.User, error) {
-	err := s.CheckForDuplicates(dto.Email)
+// CreateUserWithRole crea la cuenta con un rol distinto al de registro; lo usa fmfctl para dar de alta administradores
+func (s *UserService) CreateUserWithRole(ctx context.Context, dto *UserCreateDTO, role string) (*models.User, error) {
+	err := s.CheckForDuplicates(ctx, dto.Email)
 	if err != nil {
 		return nil, err
 	}
@@ -44,9 +51,10 @@ func (s *UserService) CreateUser(dto *UserCreateDTO) (*models.User, error) {
 		Email:    dto.Email,
 		Password: hashedPassword,
 		Phone:    dto.Phone,
+		Role:     role,
 	}
 
-	err = s.userRepository.Create(&user)
+	err = s.userRepository.Create(ctx, &user)
 	if err != nil {
 		return nil, err
 	}
@@ -62,16 +70,16 @@ func hashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
-func (s *UserService) CheckUserExists(ID int) (bool, error) {
-	exists, err := s.userRepository.ExistsByID(ID)
+func (s *UserService) CheckUserExists(ctx context.Context, ID int) (bool, error) {
+	exists, err := s.userRepository.ExistsByID(ctx, ID)
 	if err != nil {
 		return false, err
 	}
 	return exists, nil
 }
 
-func (s *UserService) CheckForDuplicates(email string) error {
-	exists, err := s.userRepository.ExistsByEmail(email)
+func (s *UserService) CheckForDuplicates(ctx context.Context, email string) error {
+	exists, err := s.userRepository.ExistsByEmail(ctx, email)
 	if err != nil {
 		return err
 	}
@@ -83,10 +91,101 @@ func (s *UserService) CheckForDuplicates(email string) error {
 	return nil
 }
 
-func (s *UserService) GetByEmail(email string) (*models.User, error) {
-	user, err := s.userRepository.GetByEmail(email)
+func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepository.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserService) UpdateRole(ctx context.Context, actor policies.Actor, userID int, role string) error {
+	if !policies.CanChangeRoles(actor) {
+		return errors.NewForbiddenError("Only administrators can change user roles")
+	}
+
+	exists, err := s.CheckUserExists(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return errors.NewNotFoundError(fmt.Sprintf("user with id %d not found", userID))
+	}
+
+	return s.userRepository.Update(ctx, userID, map[string]interface{}{"role": role})
+}
+
+func (s *UserService) GetByID(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
+
+func (s *UserService) UpdateProfile(ctx context.Context, userID int, dto *UserUpdateDTO) (*models.User, error) {
+	updates := make(map[string]interface{})
+
+	if dto.Name != nil {
+		updates["name"] = strings.TrimSpace(*dto.Name)
+	}
+	if dto.LastName != nil {
+		updates["last_name"] = strings.TrimSpace(*dto.LastName)
+	}
+	if dto.Phone != nil {
+		updates["phone"] = *dto.Phone
+	}
+
+	err := s.userRepository.Update(ctx, userID, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, userID)
+}
+
+// ChangePassword reemplaza la contraseña y cierra las sesiones abiertas: los tokens emitidos antes
+// dejan de valer. Una contraseña actual incorrecta cuenta como intento fallido de login del
+// usuario y de ip, así este endpoint no sirve para probar contraseñas sin el bloqueo del login
+func (s *UserService) ChangePassword(ctx context.Context, userID int, dto *UserPasswordUpdateDTO, ip string) error {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.loginGuard.Check(user.Email, ip); err != nil {
+		return err
+	}
+
+	if err := checkPassword(dto.CurrentPassword, user.Password); err != nil {
+		if err := s.loginGuard.Fail(ctx, user.Email, ip); err != nil {
+			return err
+		}
+		return ErrInvalidCurrentPassword
+	}
+	s.loginGuard.Succeed(user.Email)
+
+	return s.setPassword(ctx, userID, dto.NewPassword)
+}
+
+// ResetPassword reemplaza la contraseña sin pedir la actual; es una operación de soporte
+func (s *UserService) ResetPassword(ctx context.Context, userID int, newPassword string) error {
+	if len(newPassword) < 6 {
+		return errors.NewBadRequestError("Password must be at least 6 characters long")
+	}
+
+	return s.setPassword(ctx, userID, newPassword)
+}
+
+func (s *UserService) setPassword(ctx context.Context, userID int, newPassword string) error {
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepository.Update(ctx, userID, map[string]interface{}{
+		"password":            hashedPassword,
+		"password_changed_at": time.Now(),
+	})
+}
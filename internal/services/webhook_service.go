@@ -0,0 +1,640 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/errors"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/pagination"
+)
+
+const (
+	WebhookSignatureHeader = "X-FMF-Signature"
+	WebhookTimestampHeader = "X-FMF-Timestamp"
+	WebhookEventHeader     = "X-FMF-Event"
+	WebhookDeliveryHeader  = "X-FMF-Delivery"
+
+	webhookBatchSize        = 50
+	webhookLease            = time.Minute
+	webhookMaxResponseDrain = 2048
+)
+
+var (
+	ErrWebhookDeliveryPending      = errors.NewConflictError("Webhook delivery is still pending")
+	ErrWebhookRedeliveryInProgress = errors.NewConflictError("Webhook delivery is already being redelivered")
+)
+
+// WebhookService guarda un envío por suscripción y evento, y un poller los entrega
+// con reintentos y espera exponencial. Como los envíos viven en la base, los pendientes
+// sobreviven a un reinicio. Cada suscripción se atiende en su propio worker, hasta workers a la
+// vez, así un endpoint lento no demora a los demás.
+type WebhookService struct {
+	webhookRepository repositories.WebhookRepository
+	client            *http.Client
+	maxAttempts       int
+	retryBase         time.Duration
+	pollInterval      time.Duration
+	workers           int
+	mu                sync.Mutex
+	inFlight          map[int]bool
+	wakeup            chan struct{}
+	startOnce         sync.Once
+	stopOnce          sync.Once
+	stop              chan struct{}
+	ctx               context.Context
+	cancel            context.CancelFunc
+	wg                sync.WaitGroup
+}
+
+func NewWebhookService(webhookRepository repositories.WebhookRepository, webhookConfig config.WebhookConfig) *WebhookService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookService{
+		webhookRepository: webhookRepository,
+		client:            newWebhookClient(webhookConfig),
+		maxAttempts:       max(webhookConfig.MaxAttempts, 1),
+		retryBase:         webhookConfig.RetryBase,
+		pollInterval:      webhookConfig.PollInterval,
+		workers:           max(webhookConfig.Workers, 1),
+		inFlight:          make(map[int]bool),
+		wakeup:            make(chan struct{}, 1),
+		stop:              make(chan struct{}),
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+}
+
+// newWebhookClient arma un cliente que no usa el proxy del entorno y, salvo que
+// AllowPrivateNetworks lo permita, se niega a conectarse a direcciones internas. El control se
+// hace al conectar, con la IP ya resuelta, así cubre también los redirects y los DNS que cambian
+// de respuesta entre la validación y el envío
+func newWebhookClient(webhookConfig config.WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: webhookConfig.Timeout}
+	if !webhookConfig.AllowPrivateNetworks {
+		dialer.Control = rejectInternalAddress
+	}
+
+	return &http.Client{
+		Timeout: webhookConfig.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookConfig.Timeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// blockedWebhookPrefixes son los rangos a los que no se envían webhooks: redes internas, de
+// servicio o reservadas, y los prefijos de traducción que permiten llegar a una IPv4 interna
+// escribiéndola dentro de una IPv6
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "esta red"
+	netip.MustParsePrefix("10.0.0.0/8"),      // privada
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local y metadata de los proveedores cloud
+	netip.MustParsePrefix("172.16.0.0/12"),   // privada
+	netip.MustParsePrefix("192.0.0.0/24"),    // asignaciones del IETF
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentación
+	netip.MustParsePrefix("192.88.99.0/24"),  // relay 6to4
+	netip.MustParsePrefix("192.168.0.0/16"),  // privada
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentación
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentación
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reservada y broadcast
+	netip.MustParsePrefix("::/128"),          // sin especificar
+	netip.MustParsePrefix("::1/128"),         // loopback
+	netip.MustParsePrefix("::ffff:0:0/96"),   // IPv4 mapeada
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // NAT64 local
+	netip.MustParsePrefix("100::/64"),        // descarte
+	netip.MustParsePrefix("2001::/23"),       // asignaciones del IETF, incluido Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentación
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fc00::/7"),        // única local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// rejectInternalAddress rechaza las direcciones de blockedWebhookPrefixes. Las IPv4 mapeadas en
+// IPv6 se comparan como IPv4
+func rejectInternalAddress(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook destination %s is not allowed", address)
+	}
+
+	ip := addrPort.Addr().Unmap()
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("webhook destination %s is not allowed", ip)
+		}
+	}
+
+	return nil
+}
+
+// Start lanza el loop que envía los webhooks pendientes. Llamarlo más de una vez no tiene efecto.
+func (s *WebhookService) Start() {
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go s.poll()
+	})
+}
+
+// Stop detiene el loop, corta los envíos en curso y espera a que se registre su resultado o a
+// que venza ctx. Los envíos cortados quedan como un intento fallido y se reintentan; los que no
+// llegaron a intentarse siguen pendientes en la base
+func (s *WebhookService) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.cancel()
+	})
+	return waitDone(ctx, &s.wg)
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID int, dto *WebhookCreateDTO) (*WebhookDTO, error) {
+	secret := dto.Secret
+	if secret == "" {
+		generated, err := newWebhookSecret()
+		if err != nil {
+			return nil, errors.NewInternalServerError("Failed to generate webhook secret")
+		}
+		secret = generated
+	}
+
+	active := true
+	if dto.Active != nil {
+		active = *dto.Active
+	}
+
+	subscription := models.WebhookSubscription{
+		URL:         dto.URL,
+		Secret:      secret,
+		Description: dto.Description,
+		Active:      active,
+		CreatedByID: userID,
+	}
+	subscription.SetEvents(dto.Events)
+
+	if err := s.webhookRepository.Create(ctx, &subscription); err != nil {
+		return nil, err
+	}
+
+	result := newWebhookDTO(&subscription)
+	result.Secret = subscription.Secret
+	return &result, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]WebhookDTO, error) {
+	subscriptions, err := s.webhookRepository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]WebhookDTO, 0, len(subscriptions))
+	for i := range subscriptions {
+		result = append(result, newWebhookDTO(&subscriptions[i]))
+	}
+
+	return result, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id int) (*WebhookDTO, error) {
+	subscription, err := s.webhookRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newWebhookDTO(subscription)
+	return &result, nil
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id int, dto *WebhookUpdateDTO) (*WebhookDTO, error) {
+	if _, err := s.webhookRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if dto.URL != nil {
+		updates["url"] = *dto.URL
+	}
+	if dto.Events != nil {
+		subscription := models.WebhookSubscription{}
+		subscription.SetEvents(*dto.Events)
+		updates["events"] = subscription.Events
+	}
+	if dto.Description != nil {
+		updates["description"] = *dto.Description
+	}
+	if dto.Active != nil {
+		updates["active"] = *dto.Active
+	}
+
+	if len(updates) > 0 {
+		if err := s.webhookRepository.Update(ctx, id, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetWebhook(ctx, id)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if _, err := s.webhookRepository.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	return s.webhookRepository.Delete(ctx, id)
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int, status string, paginationParams *pagination.PaginationParams) (*pagination.PaginationResult, error) {
+	if _, err := s.webhookRepository.GetByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	customConfig := pagination.PaginationConfig{
+		DefaultPage:    1,
+		DefaultSize:    20,
+		MaxSize:        100,
+		DefaultSortBy:  "created_at",
+		DefaultSortDir: "DESC",
+	}
+	pagination.NormalizeParams(paginationParams, customConfig)
+
+	return s.webhookRepository.ListDeliveries(ctx, subscriptionID, status, paginationParams)
+}
+
+// Dispatch registra un envío por cada suscripción activa al evento y despierta al poller.
+// Si el evento ya se había registrado para una suscripción (por ejemplo, porque el outbox
+// lo volvió a publicar) no se duplica el envío.
+func (s *WebhookService) Dispatch(ctx context.Context, eventID string, eventType string, data interface{}) error {
+	subscriptions, err := s.webhookRepository.ListActiveByEvent(ctx, eventType)
+	if err != nil {
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := newWebhookPayload(eventID, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if _, _, err := s.enqueue(ctx, subscription.ID, payload, 0); err != nil {
+			return err
+		}
+	}
+
+	s.wake()
+	return nil
+}
+
+// Redeliver vuelve a enviar el mismo payload como un envío nuevo, conservando el historial
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID int) (*models.WebhookDelivery, error) {
+	original, err := s.webhookRepository.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.Status == models.WebhookDeliveryPending {
+		return nil, ErrWebhookDeliveryPending
+	}
+
+	last, err := s.webhookRepository.LastRedelivery(ctx, original.SubscriptionID, original.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := &webhookEnvelope{id: original.EventID, eventType: original.EventType, body: original.Payload}
+	delivery, created, err := s.enqueue(ctx, original.SubscriptionID, envelope, last+1)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrWebhookRedeliveryInProgress
+	}
+
+	s.wake()
+	return delivery, nil
+}
+
+// Test envía un evento ping en el momento y devuelve el resultado del intento
+func (s *WebhookService) Test(ctx context.Context, subscriptionID int) (*models.WebhookDelivery, error) {
+	subscription, err := s.webhookRepository.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID, err := newEventID()
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to build webhook payload")
+	}
+
+	payload, err := newWebhookPayload(eventID, models.WebhookEventPing, map[string]interface{}{"webhook_id": subscription.ID})
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to build webhook payload")
+	}
+
+	delivery, _, err := s.enqueue(ctx, subscription.ID, payload, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Subscription = *subscription
+	s.attempt(ctx, delivery)
+
+	return s.webhookRepository.GetDelivery(ctx, delivery.ID)
+}
+
+// enqueue registra el envío número redelivery del evento. Devuelve false si ya estaba registrado
+func (s *WebhookService) enqueue(ctx context.Context, subscriptionID int, payload *webhookEnvelope, redelivery int) (*models.WebhookDelivery, bool, error) {
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        payload.id,
+		Redelivery:     redelivery,
+		EventType:      payload.eventType,
+		Payload:        payload.body,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  &now,
+	}
+
+	created, err := s.webhookRepository.CreateDelivery(ctx, &delivery)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &delivery, created, nil
+}
+
+func (s *WebhookService) wake() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) poll() {
+	defer s.wg.Done()
+
+	ctx := s.ctx
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wakeup:
+		case <-s.stop:
+			return
+		}
+
+		s.dispatchDue(ctx)
+	}
+}
+
+// dispatchDue reparte los envíos vencidos en un worker por suscripción. No se consultan los de
+// las suscripciones que ya tienen un worker, y si no quedan workers libres el resto espera a la
+// próxima vuelta
+func (s *WebhookService) dispatchDue(ctx context.Context) {
+	busy := s.busySubscriptions()
+	if len(busy) >= s.workers {
+		return
+	}
+
+	deliveries, err := s.webhookRepository.ListDueDeliveries(ctx, time.Now(), webhookBatchSize, busy)
+	if err != nil {
+		slog.Error("failed to poll webhook deliveries", logger.Err(err))
+		return
+	}
+
+	var order []int
+	bySubscription := make(map[int][]*models.WebhookDelivery)
+	for i := range deliveries {
+		subscriptionID := deliveries[i].SubscriptionID
+		if _, ok := bySubscription[subscriptionID]; !ok {
+			order = append(order, subscriptionID)
+		}
+		bySubscription[subscriptionID] = append(bySubscription[subscriptionID], &deliveries[i])
+	}
+
+	for _, subscriptionID := range order {
+		if !s.acquire(subscriptionID) {
+			return
+		}
+		s.wg.Add(1)
+		go s.deliver(ctx, subscriptionID, bySubscription[subscriptionID])
+	}
+}
+
+// deliver intenta en orden los envíos de una suscripción. Al terminar libera el worker y
+// despierta al poller por si quedaron envíos esperando
+func (s *WebhookService) deliver(ctx context.Context, subscriptionID int, deliveries []*models.WebhookDelivery) {
+	defer s.wg.Done()
+	defer s.release(subscriptionID)
+
+	for _, delivery := range deliveries {
+		if s.stopping() {
+			return
+		}
+		s.attempt(ctx, delivery)
+	}
+}
+
+func (s *WebhookService) busySubscriptions() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	busy := make([]int, 0, len(s.inFlight))
+	for subscriptionID := range s.inFlight {
+		busy = append(busy, subscriptionID)
+	}
+	return busy
+}
+
+// acquire reserva un worker para la suscripción; devuelve false si están todos ocupados
+func (s *WebhookService) acquire(subscriptionID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.inFlight) >= s.workers || s.inFlight[subscriptionID] {
+		return false
+	}
+	s.inFlight[subscriptionID] = true
+	return true
+}
+
+func (s *WebhookService) release(subscriptionID int) {
+	s.mu.Lock()
+	delete(s.inFlight, subscriptionID)
+	s.mu.Unlock()
+
+	s.wake()
+}
+
+func (s *WebhookService) stopping() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// attempt reserva el envío, hace el POST y agenda el próximo reintento si falló
+func (s *WebhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	claimed, err := s.webhookRepository.ClaimDelivery(ctx, delivery.ID, delivery.Attempts, time.Now().Add(webhookLease))
+	if err != nil || !claimed {
+		return
+	}
+	delivery.Attempts++
+
+	statusCode, err := s.send(ctx, &delivery.Subscription, delivery)
+
+	updates := map[string]interface{}{
+		"response_status": statusCode,
+		"error":           "",
+	}
+
+	switch {
+	case err == nil:
+		now := time.Now()
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+	case delivery.Attempts >= s.maxAttempts:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["error"] = err.Error()
+		updates["next_attempt_at"] = nil
+	default:
+		updates["error"] = err.Error()
+		updates["next_attempt_at"] = time.Now().Add(s.backoff(delivery.Attempts))
+	}
+
+	// El resultado se guarda aunque ctx se haya cancelado, si no el envío quedaría reservado
+	// hasta que venza el lease
+	if err := s.webhookRepository.UpdateDelivery(context.WithoutCancel(ctx), delivery.ID, updates); err != nil {
+		slog.Error("failed to update webhook delivery", "delivery_id", delivery.ID, logger.Err(err))
+	}
+}
+
+// send hace el POST y devuelve el estado HTTP. El cuerpo de la respuesta no se guarda, para que
+// los webhooks no sirvan para leer servicios de terceros; solo se descarta para reusar la conexión
+func (s *WebhookService) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "FindMyFriend-Webhooks/1.0")
+	request.Header.Set(WebhookEventHeader, delivery.EventType)
+	request.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	request.Header.Set(WebhookTimestampHeader, timestamp)
+	request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, timestamp, []byte(delivery.Payload)))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, webhookMaxResponseDrain))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("endpoint responded with status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+// backoff duplica la espera en cada intento: 30s, 1m, 2m, 4m...
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	exponent := min(attempts-1, 16)
+	return s.retryBase << exponent
+}
+
+// SignWebhookPayload firma "<timestamp>.<body>" con HMAC-SHA256. El receptor debe recalcular
+// la firma con su secreto y descartar timestamps viejos para evitar replays.
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookEnvelope struct {
+	id        string
+	eventType string
+	body      string
+}
+
+func newWebhookPayload(id string, eventType string, data interface{}) (*webhookEnvelope, error) {
+	body, err := json.Marshal(WebhookPayload{
+		ID:        id,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookEnvelope{id: id, eventType: eventType, body: string(body)}, nil
+}
+
+func newWebhookDTO(subscription *models.WebhookSubscription) WebhookDTO {
+	return WebhookDTO{
+		ID:          subscription.ID,
+		URL:         subscription.URL,
+		Events:      subscription.EventList(),
+		Description: subscription.Description,
+		Active:      subscription.Active,
+		CreatedByID: subscription.CreatedByID,
+		CreatedAt:   subscription.CreatedAt,
+		UpdatedAt:   subscription.UpdatedAt,
+	}
+}
+
+func newWebhookSecret() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buffer), nil
+}
+
+func newEventID() (string, error) {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(buffer), nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/internal/models"
+	"go-api-find-my-friend/internal/repositories"
+	"go-api-find-my-friend/pkg/config"
+)
+
+func TestRejectInternalAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"8.8.8.8:443", true},
+		{"[2606:4700::1111]:443", true},
+		{"127.0.0.1:80", false},
+		{"10.1.2.3:80", false},
+		{"172.20.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"100.64.0.1:80", false},
+		{"0.1.2.3:80", false},
+		{"198.18.0.1:80", false},
+		{"224.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"[::ffff:10.0.0.1]:80", false},
+		{"[64:ff9b::a00:1]:80", false},
+		{"[2002:a00:1::1]:80", false},
+		{"[fd00::1]:80", false},
+		{"[fe80::1]:80", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := rejectInternalAddress("tcp", tt.address, nil)
+			if (err == nil) != tt.allowed {
+				t.Errorf("got %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
+
+// Un endpoint que no responde no tiene que demorar los envíos a las demás suscripciones
+func TestWebhookServiceSlowEndpointDoesNotBlockOthers(t *testing.T) {
+	db := openTestDB(t)
+	repository := repositories.NewWebhookRepositorySQLServer(db, 5*time.Second)
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	service := NewWebhookService(repository, config.WebhookConfig{
+		MaxAttempts:          3,
+		RetryBase:            time.Minute,
+		Timeout:              time.Minute,
+		PollInterval:         50 * time.Millisecond,
+		Workers:              2,
+		AllowPrivateNetworks: true,
+	})
+
+	ctx := context.Background()
+	var subscriptions []*models.WebhookSubscription
+	for _, url := range []string{slow.URL, fast.URL} {
+		subscription := &models.WebhookSubscription{URL: url, Secret: "secret", Events: models.WebhookEventPetCreated, Active: true, CreatedByID: 1}
+		if err := repository.Create(ctx, subscription); err != nil {
+			t.Fatal(err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	// Los envíos al endpoint lento se registran primero, así ocupan el principio del lote
+	for _, eventID := range []string{"1", "2", "3"} {
+		if err := service.Dispatch(ctx, eventID, models.WebhookEventPetCreated, map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service.Start()
+	defer service.Stop(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var count int64
+		err := db.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", subscriptions[1].ID, models.WebhookDeliverySucceeded).
+			Count(&count).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if count == 3 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fast endpoint got %d of 3 deliveries while the slow one was hanging", count)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -11,31 +11,56 @@ import (
 )
 
 type Config struct {
-	Server     ServerConfig
-	Database   DatabaseConfig
-	JWT        JWTConfig
-	Log        LogConfig
-	CORS       CORSConfig
-	RateLimit  RateLimitConfig
-	Upload     UploadConfig
-	Email      EmailConfig
-	Redis      RedisConfig
-	Cloudinary CloudinaryConfig
+	Server        ServerConfig
+	Database      DatabaseConfig
+	JWT           JWTConfig
+	Log           LogConfig
+	Metrics       MetricsConfig
+	CORS          CORSConfig
+	RateLimit     RateLimitConfig
+	Login         LoginConfig
+	ContactReveal RateLimitConfig
+	Upload        UploadConfig
+	Email         EmailConfig
+	Webhook       WebhookConfig
+	Outbox        OutboxConfig
+	Jobs          JobsConfig
+	PostExpiry    PostExpiryConfig
+	Redis         RedisConfig
+	Cloudinary    CloudinaryConfig
 }
 
 type ServerConfig struct {
 	Port        string
 	Host        string
 	Environment string
+	// ShutdownDelay es cuánto se sigue atendiendo con /readyz en 503 antes de cerrar las conexiones,
+	// para que el balanceador lo vea en al menos un chequeo y deje de mandar tráfico
+	ShutdownDelay time.Duration
+	// ShutdownTimeout es cuánto se espera a que terminen los requests y los workers al recibir SIGTERM
+	ShutdownTimeout time.Duration
+	// HealthCheckTimeout acota cada dependencia que verifica /readyz
+	HealthCheckTimeout time.Duration
+	// TrustedProxies son las IPs o rangos CIDR de los proxies (nginx) cuyos X-Forwarded-For y
+	// X-Real-IP se aceptan para obtener la IP del cliente. Vacío no confía en ninguno
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
+	// Driver es el motor de base de datos: sqlserver, postgres o sqlite
+	Driver   string
 	Host     string
 	Port     string
 	Name     string
 	User     string
 	Password string
 	SSLMode  string
+	// SQLitePath es el archivo de la base cuando Driver es sqlite
+	SQLitePath string
+	// QueryTimeout acota cada operación de los repositorios
+	QueryTimeout time.Duration
+	// SlowQueryThreshold es la duración a partir de la cual una consulta se registra como lenta
+	SlowQueryThreshold time.Duration
 }
 
 type JWTConfig struct {
@@ -44,10 +69,19 @@ type JWTConfig struct {
 }
 
 type LogConfig struct {
-	Level  string
+	// Level es debug, info, warn o error; en debug también se registra cada consulta SQL
+	Level string
+	// Format es json o text
 	Format string
 }
 
+// MetricsConfig controla /metrics. Si Token no está vacío el scraper tiene que mandarlo como
+// Authorization: Bearer
+type MetricsConfig struct {
+	Enabled bool
+	Token   string
+}
+
 type CORSConfig struct {
 	AllowedOrigins []string
 }
@@ -57,18 +91,84 @@ type RateLimitConfig struct {
 	Window   time.Duration
 }
 
+type LoginConfig struct {
+	MaxFailuresPerEmail int
+	MaxFailuresPerIP    int
+	LockoutBase         time.Duration
+	LockoutMax          time.Duration
+}
+
 type UploadConfig struct {
 	MaxSize string
 	Path    string
+	// Driver elige dónde se guardan las imágenes: cloudinary o local. Si está vacío se usa
+	// cloudinary en producción y el disco local en desarrollo
+	Driver string
+	// BaseURL es la URL pública desde la que se sirven los archivos guardados en Path
+	BaseURL string
+	// Timeout acota cada subida, descarga o borrado en el proveedor de storage
+	Timeout time.Duration
 }
 
 type EmailConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
+	Driver     string
+	Host       string
+	Port       int
+	User       string
+	Password   string
+	From       string
+	OutputPath string
+	AppURL     string
+	MaxRetries int
+}
+
+type WebhookConfig struct {
+	MaxAttempts  int
+	RetryBase    time.Duration
+	Timeout      time.Duration
+	PollInterval time.Duration
+	// Workers es la cantidad de suscripciones a las que se envía a la vez
+	Workers int
+	// AllowPrivateNetworks permite enviar a loopback y redes privadas, por ejemplo en desarrollo
+	AllowPrivateNetworks bool
+}
+
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	// MaxAttempts es la cantidad de intentos antes de pasar un evento a dead
+	MaxAttempts int
+	// LockTimeout es cuánto tiempo queda tomado un evento por una instancia antes de que otra pueda retomarlo
+	LockTimeout time.Duration
+	// DeadRetention es cuánto se conservan los eventos en dead desde su creación
+	DeadRetention time.Duration
 }
 
+type JobsConfig struct {
+	// Queues indica cuántos workers atienden cada cola, con el formato "cola:workers,cola:workers"
+	Queues           map[string]int
+	PollInterval     time.Duration
+	LockTimeout      time.Duration
+	RetryBase        time.Duration
+	RetryMax         time.Duration
+	MaxAttempts      int
+	Retention        time.Duration
+	SchedulerEnabled bool
+}
+
+// PostExpiryConfig define el ciclo de vida de las publicaciones sin resolver
+type PostExpiryConfig struct {
+	// ExpireAfter es la antigüedad a partir de la cual se le pregunta al dueño si la mascota sigue perdida
+	ExpireAfter time.Duration
+	// ReminderGrace es cuánto se espera la respuesta del dueño antes de archivar la publicación
+	ReminderGrace time.Duration
+	// FoundGrace es cuánto sigue visible una publicación después de marcarse como encontrada
+	FoundGrace time.Duration
+}
+
+// RedisConfig es opcional; sin REDIS_HOST no se usa ni se verifica en /readyz
 type RedisConfig struct {
 	Host     string
 	Port     string
@@ -82,41 +182,61 @@ type CloudinaryConfig struct {
 	APISecret string
 }
 
-var (
-	ConfigInstance *Config
-)
-
+// LoadConfig lee la configuración del entorno. Cada llamada devuelve una copia nueva; se carga una
+// vez al arrancar y se pasa a quien la necesite
 func LoadConfig() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
-		fmt.Println("No .env file found, using system environment variables")
+		fmt.Fprintln(os.Stderr, "No .env file found, using system environment variables")
 	}
 
-	if ConfigInstance != nil {
-		return ConfigInstance, nil
+	environment := getEnv("ENVIRONMENT", "development")
+
+	// Sin DB_DRIVER se usa SQL Server en producción y la base embebida en desarrollo
+	defaultDriver := "sqlite"
+	if environment == "production" {
+		defaultDriver = "sqlserver"
+	}
+
+	// En producción se espera un intervalo del healthcheck antes de cerrar; en desarrollo no hay balanceador
+	defaultShutdownDelay := time.Duration(0)
+	if environment == "production" {
+		defaultShutdownDelay = 10 * time.Second
 	}
 
 	config := &Config{
 		Server: ServerConfig{
-			Port:        getEnv("SERVER_PORT", "8080"),
-			Host:        getEnv("SERVER_HOST", "localhost"),
-			Environment: getEnv("ENVIRONMENT", "development"),
+			Port:               getEnv("SERVER_PORT", "8080"),
+			Host:               getEnv("SERVER_HOST", "localhost"),
+			Environment:        environment,
+			ShutdownDelay:      getEnvAsDuration("SERVER_SHUTDOWN_DELAY", defaultShutdownDelay),
+			ShutdownTimeout:    getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			HealthCheckTimeout: getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			TrustedProxies:     getEnvAsSlice("TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", ""),
-			Port:     getEnv("DB_PORT", ""),
-			Name:     getEnv("DB_NAME", ""),
-			User:     getEnv("DB_USER", ""),
-			Password: getEnv("DB_PASSWORD", ""),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			Driver:             getEnv("DB_DRIVER", defaultDriver),
+			Host:               getEnv("DB_HOST", ""),
+			Port:               getEnv("DB_PORT", ""),
+			Name:               getEnv("DB_NAME", ""),
+			User:               getEnv("DB_USER", ""),
+			Password:           getEnv("DB_PASSWORD", ""),
+			SSLMode:            getEnv("DB_SSL_MODE", "disable"),
+			SQLitePath:         getEnv("DB_SQLITE_PATH", "./data/find-my-friend.db"),
+			QueryTimeout:       getEnvAsDuration("DB_QUERY_TIMEOUT", 10*time.Second),
+			SlowQueryThreshold: getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
 		},
 		JWT: JWTConfig{
 			Secret:          getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-this-in-production"),
 			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
 		},
 		Log: LogConfig{
-			Level:  getEnv("LOG_LEVEL", "debug"),
+			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvAsBool("METRICS_ENABLED", true),
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:8080"}),
 		},
@@ -124,18 +244,68 @@ func LoadConfig() (*Config, error) {
 			Requests: getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
 			Window:   getEnvAsDuration("RATE_LIMIT_WINDOW", time.Minute),
 		},
+		Login: LoginConfig{
+			MaxFailuresPerEmail: getEnvAsInt("LOGIN_MAX_FAILURES_PER_EMAIL", 5),
+			MaxFailuresPerIP:    getEnvAsInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+			LockoutBase:         getEnvAsDuration("LOGIN_LOCKOUT_BASE", 30*time.Second),
+			LockoutMax:          getEnvAsDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+		},
+		ContactReveal: RateLimitConfig{
+			Requests: getEnvAsInt("CONTACT_REVEAL_LIMIT", 10),
+			Window:   getEnvAsDuration("CONTACT_REVEAL_WINDOW", time.Hour),
+		},
 		Upload: UploadConfig{
 			MaxSize: getEnv("UPLOAD_MAX_SIZE", "10MB"),
 			Path:    getEnv("UPLOAD_PATH", "./uploads"),
+			Driver:  getEnv("STORAGE_DRIVER", ""),
+			BaseURL: getEnv("UPLOAD_BASE_URL", fmt.Sprintf("http://localhost:%s/uploads", getEnv("SERVER_PORT", "8080"))),
+			Timeout: getEnvAsDuration("STORAGE_TIMEOUT", time.Minute),
 		},
 		Email: EmailConfig{
-			Host:     getEnv("SMTP_HOST", ""),
-			Port:     getEnvAsInt("SMTP_PORT", 587),
-			User:     getEnv("SMTP_USER", ""),
-			Password: getEnv("SMTP_PASSWORD", ""),
+			Driver:     getEnv("MAIL_DRIVER", "smtp"),
+			Host:       getEnv("SMTP_HOST", ""),
+			Port:       getEnvAsInt("SMTP_PORT", 587),
+			User:       getEnv("SMTP_USER", ""),
+			Password:   getEnv("SMTP_PASSWORD", ""),
+			From:       getEnv("MAIL_FROM", "Find My Friend <no-reply@findmyfriend.local>"),
+			OutputPath: getEnv("MAIL_OUTPUT_PATH", "./mails"),
+			AppURL:     getEnv("APP_URL", "http://localhost:3000"),
+			MaxRetries: getEnvAsInt("MAIL_MAX_RETRIES", 3),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:          getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 6),
+			RetryBase:            getEnvAsDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+			Timeout:              getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			PollInterval:         getEnvAsDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			Workers:              getEnvAsInt("WEBHOOK_WORKERS", 4),
+			AllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		Outbox: OutboxConfig{
+			PollInterval:  getEnvAsDuration("OUTBOX_POLL_INTERVAL", 2*time.Second),
+			BatchSize:     getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			RetryBase:     getEnvAsDuration("OUTBOX_RETRY_BASE", 5*time.Second),
+			RetryMax:      getEnvAsDuration("OUTBOX_RETRY_MAX", 10*time.Minute),
+			MaxAttempts:   getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 20),
+			LockTimeout:   getEnvAsDuration("OUTBOX_LOCK_TIMEOUT", time.Minute),
+			DeadRetention: getEnvAsDuration("OUTBOX_DEAD_RETENTION", 30*24*time.Hour),
+		},
+		Jobs: JobsConfig{
+			Queues:           getEnvAsIntMap("JOBS_QUEUES", map[string]int{"default": 2, "emails": 2, "images": 2}),
+			PollInterval:     getEnvAsDuration("JOBS_POLL_INTERVAL", time.Second),
+			LockTimeout:      getEnvAsDuration("JOBS_LOCK_TIMEOUT", 5*time.Minute),
+			RetryBase:        getEnvAsDuration("JOBS_RETRY_BASE", 10*time.Second),
+			RetryMax:         getEnvAsDuration("JOBS_RETRY_MAX", time.Hour),
+			MaxAttempts:      getEnvAsInt("JOBS_MAX_ATTEMPTS", 5),
+			Retention:        getEnvAsDuration("JOBS_RETENTION", 7*24*time.Hour),
+			SchedulerEnabled: getEnvAsBool("JOBS_SCHEDULER_ENABLED", true),
+		},
+		PostExpiry: PostExpiryConfig{
+			ExpireAfter:   getEnvAsDuration("POST_EXPIRE_AFTER", 30*24*time.Hour),
+			ReminderGrace: getEnvAsDuration("POST_REMINDER_GRACE", 7*24*time.Hour),
+			FoundGrace:    getEnvAsDuration("POST_FOUND_GRACE", 7*24*time.Hour),
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
+			Host:     getEnv("REDIS_HOST", ""),
 			Port:     getEnv("REDIS_PORT", "6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvAsInt("REDIS_DB", 0),
@@ -147,7 +317,6 @@ func LoadConfig() (*Config, error) {
 		},
 	}
 
-	ConfigInstance = config
 	return config, nil
 }
 
@@ -174,6 +343,38 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsIntMap lee valores con el formato "clave:numero,clave:numero"
+func getEnvAsIntMap(key string, defaultValue map[string]int) map[string]int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := map[string]int{}
+	for _, entry := range strings.Split(value, ",") {
+		name, number, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			return defaultValue
+		}
+
+		intValue, err := strconv.Atoi(number)
+		if err != nil {
+			return defaultValue
+		}
+		result[name] = intValue
+	}
+	return result
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
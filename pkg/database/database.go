@@ -1,69 +1,172 @@
 package database
 
 import (
-	"log"
+	"context"
 	"fmt"
-	"go-api-find-my-friend/internal/models"
 	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/logger"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
+const (
+	DriverSQLServer = "sqlserver"
+	DriverPostgres  = "postgres"
+	DriverSQLite    = "sqlite"
+)
+
+// Connect abre la conexión con la base configurada. Cada llamada devuelve un pool nuevo, así que
+// se conecta una sola vez y se pasa el *gorm.DB a quien lo necesite
+func Connect(config *config.Config) *gorm.DB {
+	dialector, err := open(config.Database, config.Database.Name)
+	if err != nil {
+		logger.Fatal("failed to connect to database", logger.Err(err))
+	}
 
-func Connect(config *config.Config) {	
-	dsn := getDSN(config.Database)
-	db, err := gorm.Open(sqlserver.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.NewGormLogger(config.Database.SlowQueryThreshold),
 	})
 
 	if err != nil {
-		log.Fatal("Failed to connect to database. \n", err)
+		logger.Fatal("failed to connect to database", logger.Err(err))
 	}
 
-	DB = db
-	log.Println("Database connected successfully")
+	slog.Info("database connected", "driver", config.Database.Driver)
+	return db
 }
 
-func CreateDB(config *config.Config) {
-	dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%s?database=master&encrypt=disable",
-		config.Database.User,
-		config.Database.Password,
-		config.Database.Host,
-		config.Database.Port,
-	)
+// Ping verifica que la base responda; lo usa el chequeo de readiness
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
 
-	dbMaster, err := gorm.Open(sqlserver.Open(dsn), &gorm.Config{})
+// Close cierra el pool de conexiones al apagar el servidor
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to master database: ", err)
+		return err
 	}
-	
+	return sqlDB.Close()
+}
+
+// dbNameRegex limita los nombres de base a los caracteres que no necesitan escaparse en ningún motor
+var dbNameRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_-]{0,62}$`)
+
+// CreateDB crea la base configurada si todavía no existe. En SQLite alcanza con que exista el
+// directorio del archivo, que se crea al conectarse
+func CreateDB(config *config.Config) {
 	dbName := config.Database.Name
-	query := fmt.Sprintf("IF NOT EXISTS (SELECT name FROM sys.databases WHERE name = N'%s') CREATE DATABASE [%s];", dbName, dbName)
-	
-	if exec := dbMaster.Exec(query); exec.Error != nil {
-		log.Fatal("Failed to create database: ", exec.Error)
+
+	var adminDB string
+	var existsQuery string
+	switch config.Database.Driver {
+	case DriverSQLServer:
+		adminDB = "master"
+		existsQuery = "SELECT COUNT(*) FROM sys.databases WHERE name = ?"
+	case DriverPostgres:
+		adminDB = "postgres"
+		existsQuery = "SELECT COUNT(*) FROM pg_database WHERE datname = ?"
+	default:
+		return
 	}
 
-	log.Println("Database exists or created successfully")
-}
+	// El nombre no puede pasarse como parámetro en CREATE DATABASE, así que se valida y se escapa
+	if !dbNameRegex.MatchString(dbName) {
+		logger.Fatal("invalid database name: use letters, digits, '_' or '-' (max 63 characters)", "name", dbName)
+	}
+
+	dialector, err := open(config.Database, adminDB)
+	if err != nil {
+		logger.Fatal("failed to connect to admin database", logger.Err(err))
+	}
 
-func AutoMigrate() {
-	err := DB.AutoMigrate(&models.User{}, &models.Pet{})
+	dbAdmin, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
-		log.Fatal("Failed to migrate database. \n", err)
+		logger.Fatal("failed to connect to admin database", logger.Err(err))
+	}
+
+	var count int64
+	if err := dbAdmin.Raw(existsQuery, dbName).Scan(&count).Error; err != nil {
+		logger.Fatal("failed to check database", logger.Err(err))
+	}
+
+	if count == 0 {
+		query := "CREATE DATABASE " + quoteIdentifier(config.Database.Driver, dbName)
+		if exec := dbAdmin.Exec(query); exec.Error != nil {
+			logger.Fatal("failed to create database", logger.Err(exec.Error))
+		}
+	}
+
+	slog.Info("database exists or was created", "name", dbName)
+}
+
+func quoteIdentifier(driver string, name string) string {
+	if driver == DriverSQLServer {
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
 	}
-	log.Println("Database migrated successfully")
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
 }
 
-func getDSN(db config.DatabaseConfig) string {
+// open arma el dialector del driver configurado apuntando a la base dbName
+func open(db config.DatabaseConfig, dbName string) (gorm.Dialector, error) {
+	dsn := getDSN(db, dbName)
+
+	switch db.Driver {
+	case DriverSQLServer:
+		return sqlserver.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		if err := registerSQLiteFunctions(); err != nil {
+			return nil, err
+		}
+		if dir := filepath.Dir(db.SQLitePath); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, err
+			}
+		}
+		return sqlite.Open(dsn), nil
+	}
+
+	return nil, fmt.Errorf("unsupported database driver %q", db.Driver)
+}
+
+func getDSN(db config.DatabaseConfig, dbName string) string {
+	switch db.Driver {
+	case DriverPostgres:
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			db.Host,
+			db.Port,
+			db.User,
+			db.Password,
+			dbName,
+			db.SSLMode,
+		)
+	case DriverSQLite:
+		// Se habilitan las claves foráneas para que los borrados en cascada funcionen igual que en
+		// los otros motores y se espera a que se libere el archivo cuando el dispatcher y los
+		// workers escriben a la vez
+		return db.SQLitePath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	}
+
 	dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%s?database=%s&encrypt=disable",
-		db.User,     
+		db.User,
 		db.Password,
 		db.Host,
 		db.Port,
-		db.Name,
+		dbName,
 	)
 	return dsn
-}
\ No newline at end of file
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"unicode"
+
+	sqlitedriver "github.com/glebarez/go-sqlite"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+var (
+	sqliteFunctionsOnce sync.Once
+	sqliteFunctionsErr  error
+)
+
+// LikeInsensitive devuelve una condición LIKE sobre column que no distingue mayúsculas ni acentos
+// en el motor al que está conectado db
+func LikeInsensitive(db *gorm.DB, column string) string {
+	switch db.Dialector.Name() {
+	case DriverSQLServer:
+		return fmt.Sprintf("%s COLLATE SQL_Latin1_General_CP1_CI_AI LIKE ?", column)
+	case DriverPostgres:
+		return fmt.Sprintf("unaccent(%s) ILIKE unaccent(?)", column)
+	}
+	// SQLite solo pasa a minúsculas los caracteres ASCII, por eso se quitan los acentos antes
+	return fmt.Sprintf("LOWER(unaccent(%s)) LIKE LOWER(unaccent(?))", column)
+}
+
+// registerSQLiteFunctions registra en el driver de SQLite las funciones que usan las consultas y
+// que el motor no trae. Tiene que ejecutarse antes de abrir la primera conexión
+func registerSQLiteFunctions() error {
+	sqliteFunctionsOnce.Do(func() {
+		sqliteFunctionsErr = sqlitedriver.RegisterDeterministicScalarFunction("unaccent", 1, func(ctx *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+			value, ok := args[0].(string)
+			if !ok {
+				return args[0], nil
+			}
+			return unaccent(value), nil
+		})
+	})
+	return sqliteFunctionsErr
+}
+
+func unaccent(value string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, value)
+	if err != nil {
+		return value
+	}
+	return result
+}
+
+// Reindex reconstruye los índices de las tablas y actualiza las estadísticas que usa el motor
+// para planificar las búsquedas
+func Reindex(db *gorm.DB, tables ...string) error {
+	driver := db.Dialector.Name()
+
+	for _, table := range tables {
+		quoted := quoteIdentifier(driver, table)
+
+		var statements []string
+		switch driver {
+		case DriverSQLServer:
+			statements = []string{"ALTER INDEX ALL ON " + quoted + " REBUILD", "UPDATE STATISTICS " + quoted}
+		case DriverPostgres:
+			statements = []string{"REINDEX TABLE " + quoted, "ANALYZE " + quoted}
+		default:
+			statements = []string{"REINDEX " + quoted, "ANALYZE " + quoted}
+		}
+
+		for _, statement := range statements {
+			if err := db.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to reindex %s: %w", table, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"fmt"
+	"go-api-find-my-friend/pkg/logger"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const migrationVersionLayout = "20060102150405"
+
+var migrationNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration es un cambio de esquema versionado. Las migraciones se compilan en el binario: cada
+// archivo de pkg/database/migrations se registra con RegisterMigration en su init
+type Migration struct {
+	// Version es la fecha de creación con el formato 20060102150405 y define el orden
+	Version string
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration registra cada migración aplicada
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:14"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus es el estado de una migración registrada; AppliedAt es nulo si está pendiente
+type MigrationStatus struct {
+	Version   string
+	Name      string
+	AppliedAt *time.Time
+}
+
+var migrations = map[string]Migration{}
+
+func RegisterMigration(migration Migration) {
+	if _, exists := migrations[migration.Version]; exists {
+		logger.Fatal("migration version is registered twice", "version", migration.Version)
+	}
+	migrations[migration.Version] = migration
+}
+
+// Migrate aplica en orden las migraciones pendientes, cada una en su propia transacción. Toma el
+// lock de migraciones, así varias réplicas que arrancan a la vez no aplican dos veces la misma
+func Migrate(db *gorm.DB) error {
+	return withMigrationLock(db, migrate)
+}
+
+func migrate(db *gorm.DB) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range sortedMigrations() {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s_%s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	slog.Info("database migrated")
+	return nil
+}
+
+// Rollback revierte las últimas steps migraciones aplicadas, de la más nueva a la más vieja
+func Rollback(db *gorm.DB, steps int) error {
+	return withMigrationLock(db, func(db *gorm.DB) error {
+		return rollback(db, steps)
+	})
+}
+
+func rollback(db *gorm.DB, steps int) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	versions := make([]string, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+
+	for i := 0; i < steps && i < len(versions); i++ {
+		migration, ok := migrations[versions[i]]
+		if !ok {
+			return fmt.Errorf("migration %s is applied but not registered in this binary", versions[i])
+		}
+		if migration.Down == nil {
+			return fmt.Errorf("migration %s_%s cannot be rolled back", migration.Version, migration.Name)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("rollback of %s_%s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		slog.Info("rolled back migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	return nil
+}
+
+// Status devuelve todas las migraciones conocidas, incluidas las aplicadas que este binario no
+// tiene registradas
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range sortedMigrations() {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		result = append(result, status)
+	}
+
+	for version, record := range applied {
+		if _, ok := migrations[version]; !ok {
+			result = append(result, MigrationStatus{Version: version, Name: record.Name, AppliedAt: &record.AppliedAt})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// CreateMigration genera en dir el archivo de una migración nueva con Up y Down vacíos y devuelve su ruta
+func CreateMigration(dir string, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !migrationNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid migration name %q: use lowercase letters, digits and underscores", name)
+	}
+
+	version := time.Now().UTC().Format(migrationVersionLayout)
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.go", version, name))
+
+	content := fmt.Sprintf(migrationTemplate, version, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// migrationLockKey identifica el lock de migraciones: es la clave del advisory lock de Postgres y,
+// como texto, el recurso de sp_getapplock en SQL Server
+const migrationLockKey int64 = 7_265_611_120_190_000
+
+// withMigrationLock ejecuta fn con el lock de migraciones tomado. El lock es de sesión, así que
+// todo corre sobre una misma conexión del pool y se libera solo si el proceso muere. En SQLite no
+// hace falta: el motor ya serializa las escrituras sobre el archivo
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	driver := db.Dialector.Name()
+	if driver != DriverPostgres && driver != DriverSQLServer {
+		return fn(db)
+	}
+
+	return db.Connection(func(conn *gorm.DB) error {
+		slog.Info("waiting for the migration lock")
+		if err := acquireMigrationLock(conn, driver); err != nil {
+			return fmt.Errorf("failed to acquire the migration lock: %w", err)
+		}
+		defer func() {
+			if err := releaseMigrationLock(conn, driver); err != nil {
+				slog.Warn("failed to release the migration lock", logger.Err(err))
+			}
+		}()
+
+		return fn(conn)
+	})
+}
+
+func acquireMigrationLock(conn *gorm.DB, driver string) error {
+	if driver == DriverPostgres {
+		return conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error
+	}
+
+	// sp_getapplock devuelve 0 o 1 si tomó el lock y un valor negativo si no pudo
+	var result int
+	err := conn.Raw(`DECLARE @result int;
+		EXEC @result = sp_getapplock @Resource = ?, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = -1;
+		SELECT @result`, fmt.Sprint(migrationLockKey)).Scan(&result).Error
+	if err != nil {
+		return err
+	}
+	if result < 0 {
+		return fmt.Errorf("sp_getapplock returned %d", result)
+	}
+	return nil
+}
+
+func releaseMigrationLock(conn *gorm.DB, driver string) error {
+	if driver == DriverPostgres {
+		return conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error
+	}
+	return conn.Exec("EXEC sp_releaseapplock @Resource = ?, @LockOwner = 'Session'", fmt.Sprint(migrationLockKey)).Error
+}
+
+func appliedMigrations(db *gorm.DB) (map[string]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[string]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func sortedMigrations() []Migration {
+	result := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		result = append(result, migration)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result
+}
+
+const migrationTemplate = `package migrations
+
+import (
+	"go-api-find-my-friend/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	database.RegisterMigration(database.Migration{
+		Version: "%s",
+		Name:    "%s",
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`
@@ -0,0 +1,278 @@
+package migrations
+
+import (
+	"time"
+
+	"go-api-find-my-friend/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// Las structs de este archivo son una copia congelada de los modelos al momento de la línea base.
+// No usan internal/models a propósito: si un modelo cambia, el cambio va en una migración nueva y
+// esta sigue creando siempre el mismo esquema. Los nombres de los campos de las relaciones se
+// conservan porque GORM arma con ellos los nombres de las foreign keys (fk_pets_user, ...)
+
+type baselineUser struct {
+	ID          int    `gorm:"primaryKey;autoIncrement"`
+	Name        string `gorm:"not null"`
+	LastName    string `gorm:"not null"`
+	Email       string `gorm:"unique;not null"`
+	Password    string `gorm:"not null"`
+	Phone       string
+	Role        string `gorm:"not null;default:'user'"`
+	TOTPSecret  string
+	TOTPEnabled bool `gorm:"default:false"`
+	Warnings    int  `gorm:"not null;default:0"`
+	BannedAt    *time.Time
+	BanReason   string
+	Pets        []baselinePet `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselinePet struct {
+	ID             int    `gorm:"primaryKey;autoIncrement"`
+	Name           string `gorm:"not null"`
+	Description    string `gorm:"not null;default:''"`
+	Type           string `gorm:"not null"`
+	Breed          string
+	UserID         int          `gorm:"type:int;not null;constraint:OnDelete:CASCADE"`
+	User           baselineUser `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	LastSeenTime   time.Time    `gorm:"not null"`
+	LastSeenPlace  string       `gorm:"not null"`
+	Status         string       `gorm:"not null;default:'lost';index"`
+	IsFound        bool         `gorm:"default:false"`
+	PictureURL     string
+	ContactPrivacy string `gorm:"not null;default:'show_phone'"`
+	RenewedAt      *time.Time
+	ReminderSentAt *time.Time
+	FoundAt        *time.Time
+	ArchivedAt     *time.Time `gorm:"index"`
+	HiddenAt       *time.Time `gorm:"index"`
+	HiddenReason   string
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+func (baselinePet) TableName() string { return "pets" }
+
+type baselineAuditEvent struct {
+	ID        int    `gorm:"primaryKey;autoIncrement"`
+	Action    string `gorm:"not null;index"`
+	ActorID   *int   `gorm:"index"`
+	Subject   string
+	IP        string
+	Details   string
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (baselineAuditEvent) TableName() string { return "audit_events" }
+
+type baselineRecoveryCode struct {
+	ID        int    `gorm:"primaryKey;autoIncrement"`
+	UserID    int    `gorm:"type:int;not null;index"`
+	CodeHash  string `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (baselineRecoveryCode) TableName() string { return "recovery_codes" }
+
+type baselineConversation struct {
+	ID                  int          `gorm:"primaryKey;autoIncrement"`
+	PetID               int          `gorm:"type:int;not null;uniqueIndex:idx_conversation_pet_finder"`
+	Pet                 baselinePet  `gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	OwnerID             int          `gorm:"type:int;not null;index"`
+	Owner               baselineUser `gorm:"foreignKey:OwnerID;constraint:-"`
+	FinderID            int          `gorm:"type:int;not null;uniqueIndex:idx_conversation_pet_finder"`
+	Finder              baselineUser `gorm:"foreignKey:FinderID;constraint:-"`
+	OwnerSharesContact  bool         `gorm:"default:false"`
+	FinderSharesContact bool         `gorm:"default:false"`
+	OwnerLastReadAt     *time.Time
+	FinderLastReadAt    *time.Time
+	BlockedByID         *int
+	LastMessageAt       *time.Time
+	Messages            []baselineMessage `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE"`
+	CreatedAt           time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time         `gorm:"autoUpdateTime"`
+}
+
+func (baselineConversation) TableName() string { return "conversations" }
+
+type baselineMessage struct {
+	ID             int       `gorm:"primaryKey;autoIncrement"`
+	ConversationID int       `gorm:"type:int;not null;index"`
+	SenderID       int       `gorm:"type:int;not null"`
+	Body           string    `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (baselineMessage) TableName() string { return "messages" }
+
+type baselineSighting struct {
+	ID         int          `gorm:"primaryKey;autoIncrement"`
+	PetID      int          `gorm:"type:int;not null;index"`
+	Pet        baselinePet  `gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	ReporterID int          `gorm:"type:int;not null;index"`
+	Reporter   baselineUser `gorm:"foreignKey:ReporterID;constraint:-"`
+	SeenAt     time.Time    `gorm:"not null"`
+	SeenPlace  string       `gorm:"not null"`
+	Notes      string
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func (baselineSighting) TableName() string { return "sightings" }
+
+type baselineNotificationPreference struct {
+	UserID           int          `gorm:"primaryKey;autoIncrement:false"`
+	User             baselineUser `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Language         string       `gorm:"type:varchar(5);not null"`
+	PetCreated       bool         `gorm:"not null"`
+	SightingReceived bool         `gorm:"not null"`
+	MessageReceived  bool         `gorm:"not null"`
+	PetFound         bool         `gorm:"not null"`
+	UpdatedAt        time.Time
+}
+
+func (baselineNotificationPreference) TableName() string { return "notification_preferences" }
+
+type baselineWebhookSubscription struct {
+	ID          int    `gorm:"primaryKey;autoIncrement"`
+	URL         string `gorm:"not null"`
+	Secret      string `gorm:"not null"`
+	Events      string `gorm:"not null"`
+	Description string
+	Active      bool      `gorm:"not null"`
+	CreatedByID int       `gorm:"type:int;not null"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+func (baselineWebhookSubscription) TableName() string { return "webhook_subscriptions" }
+
+type baselineWebhookDelivery struct {
+	ID             int                         `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID int                         `gorm:"type:int;not null;index"`
+	Subscription   baselineWebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+	EventID        string                      `gorm:"not null;index"`
+	EventType      string                      `gorm:"not null"`
+	Payload        string                      `gorm:"not null"`
+	Status         string                      `gorm:"not null;index:idx_webhook_delivery_due"`
+	Attempts       int                         `gorm:"not null"`
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	NextAttemptAt  *time.Time `gorm:"index:idx_webhook_delivery_due"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+func (baselineWebhookDelivery) TableName() string { return "webhook_deliveries" }
+
+type baselineOutboxEvent struct {
+	ID            int64  `gorm:"primaryKey;autoIncrement"`
+	AggregateType string `gorm:"not null;index:idx_outbox_aggregate"`
+	AggregateID   int    `gorm:"not null;index:idx_outbox_aggregate"`
+	EventType     string `gorm:"not null"`
+	Payload       string `gorm:"not null"`
+	Attempts      int    `gorm:"not null;default:0"`
+	LastError     string
+	NextAttemptAt *time.Time
+	DispatchedAt  *time.Time `gorm:"index"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`
+}
+
+func (baselineOutboxEvent) TableName() string { return "outbox_events" }
+
+type baselineJob struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement"`
+	Queue       string    `gorm:"not null;index:idx_job_due"`
+	Type        string    `gorm:"not null;index"`
+	Payload     string    `gorm:"not null"`
+	Status      string    `gorm:"not null;index:idx_job_due"`
+	Attempts    int       `gorm:"not null;default:0"`
+	MaxAttempts int       `gorm:"not null"`
+	RunAt       time.Time `gorm:"not null;index:idx_job_due"`
+	LockedUntil *time.Time
+	LockedBy    string
+	LastError   string
+	FinishedAt  *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+func (baselineJob) TableName() string { return "jobs" }
+
+type baselinePetStatusChange struct {
+	ID          int           `gorm:"primaryKey;autoIncrement"`
+	PetID       int           `gorm:"type:int;not null;index"`
+	Pet         baselinePet   `gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	FromStatus  string        `gorm:"not null"`
+	ToStatus    string        `gorm:"not null"`
+	ChangedByID *int          `gorm:"type:int;index"`
+	ChangedBy   *baselineUser `gorm:"foreignKey:ChangedByID;constraint:-"`
+	Reason      string
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+func (baselinePetStatusChange) TableName() string { return "pet_status_changes" }
+
+type baselinePetReport struct {
+	ID           int          `gorm:"primaryKey;autoIncrement"`
+	PetID        int          `gorm:"type:int;not null;index"`
+	Pet          baselinePet  `gorm:"foreignKey:PetID;constraint:OnDelete:CASCADE"`
+	ReporterID   int          `gorm:"type:int;not null;index"`
+	Reporter     baselineUser `gorm:"foreignKey:ReporterID;constraint:-"`
+	Reason       string       `gorm:"not null"`
+	Details      string
+	Status       string `gorm:"not null;index"`
+	Resolution   string
+	ResolvedByID *int `gorm:"type:int"`
+	ResolvedAt   *time.Time
+	CreatedAt    time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (baselinePetReport) TableName() string { return "pet_reports" }
+
+// baselineModels son las tablas que existían antes de las migraciones versionadas
+var baselineModels = []interface{}{
+	&baselineUser{}, &baselinePet{}, &baselineAuditEvent{}, &baselineRecoveryCode{}, &baselineConversation{},
+	&baselineMessage{}, &baselineSighting{}, &baselineNotificationPreference{}, &baselineWebhookSubscription{},
+	&baselineWebhookDelivery{}, &baselineOutboxEvent{}, &baselineJob{}, &baselinePetStatusChange{}, &baselinePetReport{},
+}
+
+// La línea base usa AutoMigrate sobre las structs congeladas, así que en una base creada antes de
+// las migraciones solo agrega lo que falte y se registra como aplicada sin tocar los datos
+func init() {
+	database.RegisterMigration(database.Migration{
+		Version: "20261019000000",
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			// La búsqueda sin acentos de Postgres usa la extensión unaccent
+			if tx.Dialector.Name() == database.DriverPostgres {
+				if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS unaccent").Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.AutoMigrate(baselineModels...); err != nil {
+				return err
+			}
+
+			// Las mascotas creadas antes de la columna status quedan como lost; se completa a partir de is_found y archived_at
+			err := tx.Table("pets").Where("status = ? AND archived_at IS NOT NULL", "lost").Update("status", "archived").Error
+			if err != nil {
+				return err
+			}
+			return tx.Table("pets").Where("status = ? AND is_found = ?", "lost", true).Update("status", "found").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(baselineModels...)
+		},
+	})
+}
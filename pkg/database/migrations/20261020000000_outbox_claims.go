@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"time"
+
+	"go-api-find-my-friend/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// outboxEventClaims son las columnas que agrega esta migración, con el esquema de esta versión
+type outboxEventClaims struct {
+	Status            string `gorm:"not null;default:pending;index"`
+	CompletedHandlers string
+	LockedBy          string
+	LockedUntil       *time.Time
+}
+
+func (outboxEventClaims) TableName() string {
+	return "outbox_events"
+}
+
+var outboxEventClaimColumns = []string{"Status", "CompletedHandlers", "LockedBy", "LockedUntil"}
+
+// Agrega el estado (pending, dispatched, dead), el progreso por handler y el lock con el que
+// cada instancia toma los eventos. Los eventos ya despachados quedan como dispatched
+func init() {
+	database.RegisterMigration(database.Migration{
+		Version: "20261020000000",
+		Name:    "outbox_claims",
+		Up: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			for _, column := range outboxEventClaimColumns {
+				if migrator.HasColumn(&outboxEventClaims{}, column) {
+					continue
+				}
+				if err := migrator.AddColumn(&outboxEventClaims{}, column); err != nil {
+					return err
+				}
+			}
+
+			if !migrator.HasIndex(&outboxEventClaims{}, "Status") {
+				if err := migrator.CreateIndex(&outboxEventClaims{}, "Status"); err != nil {
+					return err
+				}
+			}
+
+			return tx.Table("outbox_events").Where("dispatched_at IS NOT NULL").Update("status", "dispatched").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			if err := migrator.DropIndex(&outboxEventClaims{}, "Status"); err != nil {
+				return err
+			}
+			for _, column := range outboxEventClaimColumns {
+				if err := migrator.DropColumn(&outboxEventClaims{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
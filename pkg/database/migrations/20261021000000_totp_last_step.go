@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"go-api-find-my-friend/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// userTOTPLastStep es la columna que agrega esta migración, con el esquema de esta versión
+type userTOTPLastStep struct {
+	TOTPLastStep int64 `gorm:"not null;default:0"`
+}
+
+func (userTOTPLastStep) TableName() string {
+	return "users"
+}
+
+// Guarda el último paso TOTP aceptado de cada usuario para rechazar códigos repetidos
+func init() {
+	database.RegisterMigration(database.Migration{
+		Version: "20261021000000",
+		Name:    "totp_last_step",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&userTOTPLastStep{}, "TOTPLastStep") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&userTOTPLastStep{}, "TOTPLastStep")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userTOTPLastStep{}, "TOTPLastStep")
+		},
+	})
+}
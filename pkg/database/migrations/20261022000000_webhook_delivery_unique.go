@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"go-api-find-my-friend/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// webhookDeliveryRedelivery son la columna y el índice que agrega esta migración, con el
+// esquema de esta versión
+type webhookDeliveryRedelivery struct {
+	SubscriptionID int    `gorm:"type:int;not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventID        string `gorm:"not null;index;uniqueIndex:idx_webhook_delivery_event"`
+	Redelivery     int    `gorm:"not null;default:0;uniqueIndex:idx_webhook_delivery_event"`
+}
+
+func (webhookDeliveryRedelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Numera los reenvíos de cada evento y agrega el índice único sobre suscripción, evento y número
+// de reenvío. Los envíos existentes de un mismo evento se numeran por orden de creación
+func init() {
+	database.RegisterMigration(database.Migration{
+		Version: "20261022000000",
+		Name:    "webhook_delivery_unique",
+		Up: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			if !migrator.HasColumn(&webhookDeliveryRedelivery{}, "Redelivery") {
+				if err := migrator.AddColumn(&webhookDeliveryRedelivery{}, "Redelivery"); err != nil {
+					return err
+				}
+			}
+
+			err := tx.Exec(`UPDATE webhook_deliveries SET redelivery = (
+				SELECT COUNT(*) FROM webhook_deliveries earlier
+				WHERE earlier.subscription_id = webhook_deliveries.subscription_id
+					AND earlier.event_id = webhook_deliveries.event_id
+					AND earlier.id < webhook_deliveries.id
+			)`).Error
+			if err != nil {
+				return err
+			}
+
+			if migrator.HasIndex(&webhookDeliveryRedelivery{}, "idx_webhook_delivery_event") {
+				return nil
+			}
+			return migrator.CreateIndex(&webhookDeliveryRedelivery{}, "idx_webhook_delivery_event")
+		},
+		Down: func(tx *gorm.DB) error {
+			migrator := tx.Migrator()
+			if err := migrator.DropIndex(&webhookDeliveryRedelivery{}, "idx_webhook_delivery_event"); err != nil {
+				return err
+			}
+			return migrator.DropColumn(&webhookDeliveryRedelivery{}, "Redelivery")
+		},
+	})
+}
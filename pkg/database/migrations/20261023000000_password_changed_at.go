@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"time"
+
+	"go-api-find-my-friend/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// userPasswordChangedAt es la columna que agrega esta migración, con el esquema de esta versión
+type userPasswordChangedAt struct {
+	PasswordChangedAt *time.Time
+}
+
+func (userPasswordChangedAt) TableName() string {
+	return "users"
+}
+
+// Guarda cuándo cambió la contraseña de cada usuario para rechazar los tokens emitidos antes
+func init() {
+	database.RegisterMigration(database.Migration{
+		Version: "20261023000000",
+		Name:    "password_changed_at",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&userPasswordChangedAt{}, "PasswordChangedAt") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&userPasswordChangedAt{}, "PasswordChangedAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userPasswordChangedAt{}, "PasswordChangedAt")
+		},
+	})
+}
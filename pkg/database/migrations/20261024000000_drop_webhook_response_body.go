@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"go-api-find-my-friend/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// webhookDeliveryResponseBody es la columna que quita esta migración, con el esquema de esta versión
+type webhookDeliveryResponseBody struct {
+	ResponseBody string
+}
+
+func (webhookDeliveryResponseBody) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Deja de guardar el cuerpo de la respuesta de los webhooks, que permitía leer servicios de
+// terceros a través del historial de envíos. La columna se quita con ALTER TABLE porque en SQLite
+// el migrator de GORM reconstruye la tabla y pierde los índices
+func init() {
+	database.RegisterMigration(database.Migration{
+		Version: "20261024000000",
+		Name:    "drop_webhook_response_body",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&webhookDeliveryResponseBody{}, "ResponseBody") {
+				return nil
+			}
+			return tx.Exec("ALTER TABLE webhook_deliveries DROP COLUMN response_body").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&webhookDeliveryResponseBody{}, "ResponseBody")
+		},
+	})
+}
@@ -10,6 +10,8 @@ type AppError struct {
 	Message string `json:"message"`
 	Type    string `json:"error"`
 	Details string `json:"details,omitempty"`
+	// RetryAfter indica, en segundos, cuándo puede reintentarse la operación
+	RetryAfter int `json:"retry_after,omitempty"`
 }
 
 func (e *AppError) Error() string {
@@ -83,6 +85,15 @@ func NewUnprocessableEntityError(message string) *AppError {
 	}
 }
 
+func NewTooManyRequestsError(message string, retryAfter int) *AppError {
+	return &AppError{
+		Code:       http.StatusTooManyRequests,
+		Message:    message,
+		Type:       "Too Many Requests",
+		RetryAfter: retryAfter,
+	}
+}
+
 func NewInternalServerError(message string) *AppError {
 	return &AppError{
 		Code:    http.StatusInternalServerError,
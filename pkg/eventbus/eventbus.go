@@ -0,0 +1,96 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event es un evento de dominio tal como lo reciben los suscriptores
+type Event struct {
+	ID            int64
+	Type          string
+	AggregateType string
+	AggregateID   int
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+}
+
+// Decode deserializa el payload del evento en v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler procesa un evento. La entrega es at-least-once: un mismo evento puede llegar
+// más de una vez, así que los handlers deben ser idempotentes o tolerar duplicados.
+type Handler func(ctx context.Context, event Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus reparte los eventos entre los suscriptores del proceso de forma sincrónica
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[string][]subscriber),
+	}
+}
+
+// Subscribe registra handler para eventType. name identifica al handler en el progreso que guarda
+// el outbox, así que tiene que ser único para cada tipo de evento y no cambiar entre versiones
+func (b *Bus) Subscribe(eventType string, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[eventType] = append(b.subscribers[eventType], subscriber{name: name, handler: handler})
+}
+
+// Publish ejecuta todos los handlers del evento, aunque alguno falle, y devuelve los errores juntos
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	_, err := b.Deliver(ctx, event, nil)
+	return err
+}
+
+// Deliver ejecuta los handlers del evento cuyo nombre no figura en done y devuelve los nombres de
+// los que terminaron bien, junto con los errores de los demás. Así quien reintenta un evento no
+// vuelve a ejecutar los handlers que ya lo procesaron
+func (b *Bus) Deliver(ctx context.Context, event Event, done map[string]bool) ([]string, error) {
+	b.mu.RLock()
+	subscribers := b.subscribers[event.Type]
+	b.mu.RUnlock()
+
+	var succeeded []string
+	var errs []error
+	for _, subscriber := range subscribers {
+		if done[subscriber.name] {
+			continue
+		}
+		if err := b.call(ctx, subscriber, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", subscriber.name, err))
+			continue
+		}
+		succeeded = append(succeeded, subscriber.name)
+	}
+
+	return succeeded, errors.Join(errs...)
+}
+
+// call evita que un panic en un handler detenga al dispatcher
+func (b *Bus) call(ctx context.Context, subscriber subscriber, event Event) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return subscriber.handler(ctx, event)
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+// Check verifica una dependencia; devuelve error si no está disponible
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Report es el resultado de una verificación de readiness, con el estado de cada dependencia
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func (r *Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Checker agrupa las dependencias que tienen que responder para que la instancia reciba tráfico.
+// Después de Drain deja de estar lista aunque las dependencias respondan, para que el balanceador
+// la saque antes de que se cierre el servidor
+type Checker struct {
+	checks   []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registra una dependencia; se llama al armar la aplicación, antes de recibir requests
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Run ejecuta los chequeos en paralelo, cada uno acotado al timeout configurado
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{Status: StatusOK, Checks: make(map[string]string, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			result := StatusOK
+			if err := check.check(checkCtx); err != nil {
+				result = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.name] = result
+			if result != StatusOK {
+				report.Status = StatusFailing
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.draining.Load() {
+		report.Status = StatusDraining
+	}
+
+	return report
+}
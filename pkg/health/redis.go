@@ -0,0 +1,85 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisPing verifica Redis hablando RESP directamente sobre TCP, sin depender de un cliente:
+// se autentica si hay contraseña y espera "+PONG" como respuesta a PING
+func RedisPing(addr string, password string) Check {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(5 * time.Second)
+		}
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+
+		reader := bufio.NewReader(conn)
+
+		if password != "" {
+			if err := redisCommand(conn, "AUTH", password); err != nil {
+				return err
+			}
+			if _, err := redisReply(reader); err != nil {
+				return err
+			}
+		}
+
+		if err := redisCommand(conn, "PING"); err != nil {
+			return err
+		}
+		reply, err := redisReply(reader)
+		if err != nil {
+			return err
+		}
+		if reply != "PONG" {
+			return fmt.Errorf("unexpected redis reply %q", reply)
+		}
+		return nil
+	}
+}
+
+// redisCommand escribe el comando como un array de bulk strings
+func redisCommand(conn net.Conn, args ...string) error {
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+
+	_, err := conn.Write([]byte(b.String()))
+	return err
+}
+
+// redisReply lee una respuesta simple ("+OK") o de error ("-ERR ...")
+func redisReply(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return line[1:], nil
+	case strings.HasPrefix(line, "-"):
+		return "", fmt.Errorf("redis: %s", line[1:])
+	default:
+		return "", fmt.Errorf("unexpected redis reply %q", line)
+	}
+}
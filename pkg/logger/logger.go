@@ -0,0 +1,101 @@
+// Package logger configura log/slog a partir de LogConfig y guarda en el contexto el logger de
+// cada request, para que servicios y repositorios registren con el mismo request_id
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"go-api-find-my-friend/pkg/config"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys son fragmentos de nombres de atributo cuyo valor nunca se escribe en los logs
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "api_key", "apikey", "cookie", "recovery_code"}
+
+type contextKey struct{}
+
+// New crea un logger con el nivel (debug, info, warn, error) y el formato (json o text) configurados
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(cfg.Level),
+		ReplaceAttr: redact,
+	}
+
+	if strings.EqualFold(cfg.Format, "text") {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// Setup crea el logger sobre stdout y lo deja como default, así también pasan por él
+// los mensajes que todavía usan el paquete log
+func Setup(cfg config.LogConfig) *slog.Logger {
+	logger := New(cfg, os.Stdout)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// WithContext devuelve una copia de ctx que lleva logger
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext devuelve el logger del request o, si ctx no tiene uno, el default
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// With agrega atributos al logger de ctx, por ejemplo el usuario autenticado
+func With(ctx context.Context, args ...any) context.Context {
+	return WithContext(ctx, FromContext(ctx).With(args...))
+}
+
+// Err es el atributo con el que se registran los errores
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
+
+// Duration registra una duración en milisegundos, más fácil de leer y de agregar que los nanosegundos
+func Duration(d time.Duration) slog.Attr {
+	return slog.Float64("duration_ms", float64(d.Microseconds())/1000)
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	return attr
+}
+
+// Fatal registra el error y termina el proceso; reemplaza a log.Fatal en el arranque
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-api-find-my-friend/pkg/config"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"time"
+)
+
+// Message es un email con versión en texto plano y, opcionalmente, en HTML
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer envía un email. Send tiene que respetar ctx, que en los trabajos vence con el lock
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// NewMailer elige la implementación según MAIL_DRIVER
+func NewMailer(cfg config.EmailConfig) Mailer {
+	switch cfg.Driver {
+	case "memory":
+		return NewMemoryMailer()
+	case "file":
+		return NewFileMailer(cfg.OutputPath, cfg.From)
+	default:
+		return NewSMTPMailer(cfg)
+	}
+}
+
+// buildMIME arma el mensaje completo (headers y cuerpo multipart/alternative)
+func buildMIME(from string, message *Message) ([]byte, error) {
+	boundary, err := newBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buffer, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	if err := writePart(&buffer, boundary, "text/plain", message.Text); err != nil {
+		return nil, err
+	}
+	if message.HTML != "" {
+		if err := writePart(&buffer, boundary, "text/html", message.HTML); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Fprintf(&buffer, "--%s--\r\n", boundary)
+	return buffer.Bytes(), nil
+}
+
+func writePart(buffer *bytes.Buffer, boundary string, contentType string, body string) error {
+	fmt.Fprintf(buffer, "--%s\r\n", boundary)
+	fmt.Fprintf(buffer, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	buffer.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	writer := quotedprintable.NewWriter(buffer)
+	if _, err := writer.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	buffer.WriteString("\r\n")
+	return nil
+}
+
+func newBoundary() (string, error) {
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
+
+// address devuelve solo la dirección de un valor como "Nombre <mail@dominio>"
+func address(value string) (string, error) {
+	parsed, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Address, nil
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer guarda los emails en memoria en lugar de enviarlos. Pensado para desarrollo y pruebas.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(_ context.Context, message *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, *message)
+	return nil
+}
+
+// Messages devuelve una copia de los emails enviados hasta el momento
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
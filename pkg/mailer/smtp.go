@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"go-api-find-my-friend/pkg/config"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// smtpTimeout limita la conversación con el servidor cuando ctx no trae un vencimiento propio
+const smtpTimeout = 30 * time.Second
+
+type SMTPMailer struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(cfg config.EmailConfig) *SMTPMailer {
+	var auth smtp.Auth
+	if cfg.User != "" {
+		auth = smtp.PlainAuth("", cfg.User, cfg.Password, cfg.Host)
+	}
+
+	return &SMTPMailer{
+		addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		host: cfg.Host,
+		auth: auth,
+		from: cfg.From,
+	}
+}
+
+// Send hace lo mismo que smtp.SendMail, pero la conexión vence con ctx: un servidor colgado no
+// retiene al worker después del lock del trabajo, que otro worker retomaría enviando el email
+// dos veces
+func (m *SMTPMailer) Send(ctx context.Context, message *Message) error {
+	from, err := address(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	to, err := address(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	body, err := buildMIME(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Si ctx se cancela antes del vencimiento, cerrar la conexión corta la operación en curso
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := m.deliver(client, from, to, body); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *SMTPMailer) deliver(client *smtp.Client, from string, to string, body []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+
+	if m.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server does not support AUTH")
+		}
+		if err := client.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(body); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
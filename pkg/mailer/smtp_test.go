@@ -0,0 +1,112 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api-find-my-friend/pkg/config"
+)
+
+// startSMTPServer atiende una conexión con handle en un puerto local y devuelve la configuración
+// para conectarse
+func startSMTPServer(t *testing.T, handle func(conn net.Conn)) config.EmailConfig {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return config.EmailConfig{Host: host, Port: portNumber, From: "Find My Friend <no-reply@example.com>"}
+}
+
+func testMessage() *Message {
+	return &Message{To: "ana@example.com", Subject: "Hola", Text: "Texto"}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	received := make(chan string, 1)
+	cfg := startSMTPServer(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("220 test ESMTP\r\n"))
+
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					conn.Write([]byte("250 queued\r\n"))
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"):
+				conn.Write([]byte("250 test\r\n"))
+			case command == "DATA":
+				inData = true
+				conn.Write([]byte("354 go ahead\r\n"))
+			case command == "QUIT":
+				conn.Write([]byte("221 bye\r\n"))
+				received <- data.String()
+				return
+			default:
+				conn.Write([]byte("250 ok\r\n"))
+			}
+		}
+	})
+
+	if err := NewSMTPMailer(cfg).Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send returned %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "To: ana@example.com") {
+			t.Errorf("unexpected message:\n%s", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the server did not receive the message")
+	}
+}
+
+// Un servidor que acepta la conexión y no responde no puede retener al worker después de ctx
+func TestSMTPMailerSendStalledServer(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	cfg := startSMTPServer(t, func(conn net.Conn) { <-done })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := NewSMTPMailer(cfg).Send(ctx, testMessage())
+	if err == nil {
+		t.Fatal("Send to a stalled server returned no error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send took %s with a 200ms deadline", elapsed)
+	}
+}
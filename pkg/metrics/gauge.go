@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+
+	"go-api-find-my-friend/pkg/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GaugeSample es un valor de un gauge de negocio con sus labels en el orden en que se declararon
+type GaugeSample struct {
+	Labels []string
+	Value  float64
+}
+
+// GaugeSource calcula los valores del gauge en cada scrape, por ejemplo con una consulta agregada
+type GaugeSource func(ctx context.Context) ([]GaugeSample, error)
+
+type gaugeCollector struct {
+	name   string
+	desc   *prometheus.Desc
+	source GaugeSource
+}
+
+// RegisterGauge expone un gauge cuyos valores se consultan al momento del scrape, así no hace
+// falta mantenerlos actualizados desde cada servicio que cambia los datos
+func (m *Metrics) RegisterGauge(name string, help string, labels []string, source GaugeSource) {
+	m.registry.MustRegister(&gaugeCollector{
+		name:   name,
+		desc:   prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil),
+		source: source,
+	})
+}
+
+func (c *gaugeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect no corta el scrape si falla la consulta: informa el error en esta métrica y las demás
+// se sirven igual
+func (c *gaugeCollector) Collect(ch chan<- prometheus.Metric) {
+	samples, err := c.source(context.Background())
+	if err != nil {
+		slog.Warn("failed to collect gauge", "gauge", c.name, logger.Err(err))
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for _, sample := range samples {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, sample.Value, sample.Labels...)
+	}
+}
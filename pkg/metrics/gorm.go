@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	queryStartKey = "metrics:query_start"
+	metricsKey    = "metrics:metrics"
+)
+
+// gormPlugin mide cada consulta con callbacks antes y después de los de GORM. Los callbacks
+// quedan en la configuración de la conexión y son los mismos para todas las sesiones, por eso
+// no guardan las métricas: las leen de la sesión que ejecuta la consulta
+type gormPlugin struct{}
+
+// InstrumentDB registra el plugin en db si todavía no lo está y devuelve una sesión cuyas
+// consultas se cuentan en m. Varias Apps pueden compartir db: cada una mide solo lo que pasa
+// por su sesión, y lo que se ejecute directo sobre db no se mide
+func InstrumentDB(db *gorm.DB, m *Metrics) (*gorm.DB, error) {
+	if err := db.Use(&gormPlugin{}); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+		return db, err
+	}
+	return db.Set(metricsKey, m).Session(&gorm.Session{}), nil
+}
+
+func (p *gormPlugin) Name() string {
+	return "metrics"
+}
+
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", start),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", finish("create")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", start),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", finish("query")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", start),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", finish("update")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", start),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", finish("delete")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", start),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", finish("row")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", start),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", finish("raw")),
+	)
+}
+
+func start(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func finish(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.Get(metricsKey)
+		if !ok {
+			return
+		}
+		m, ok := value.(*Metrics)
+		if !ok {
+			return
+		}
+
+		value, ok = db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		begin, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		m.observeQuery(operation, table, time.Since(begin), db.Error)
+	}
+}
@@ -0,0 +1,160 @@
+// Package metrics expone las métricas de Prometheus de la API. Cada Metrics tiene su propio
+// registro, así dos App en el mismo proceso no comparten contadores
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+const namespace = "findmyfriend"
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+
+	dbQueryDuration *prometheus.HistogramVec
+	dbQueryErrors   *prometheus.CounterVec
+
+	storageDuration *prometheus.HistogramVec
+	storageFailures *prometheus.CounterVec
+
+	sagaSteps         *prometheus.CounterVec
+	sagaCompensations *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "GORM query latency by operation and table.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		dbQueryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "GORM queries that failed, by operation and table. Record not found is not counted.",
+		}, []string{"operation", "table"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Storage provider latency by operation.",
+			Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"operation"}),
+		storageFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_failures_total",
+			Help:      "Storage provider operations that failed, by operation.",
+		}, []string{"operation"}),
+		sagaSteps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "saga_step_runs_total",
+			Help:      "Saga step executions by step name and result.",
+		}, []string{"step", "result"}),
+		sagaCompensations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "saga_step_compensations_total",
+			Help:      "Saga step compensations by step name and result.",
+		}, []string{"step", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.dbQueryDuration,
+		m.dbQueryErrors,
+		m.storageDuration,
+		m.storageFailures,
+		m.sagaSteps,
+		m.sagaCompensations,
+	)
+
+	return m
+}
+
+// Handler sirve el registro en el formato de texto de Prometheus. Si falla un collector se
+// exponen igual las demás métricas
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+		Registry:      m.registry,
+	})
+}
+
+// RequestStarted y RequestFinished los llama el middleware HTTP. route es la ruta de Gin, no la
+// URL, para que los IDs no multipliquen las series
+func (m *Metrics) RequestStarted() {
+	m.httpInFlight.Inc()
+}
+
+func (m *Metrics) RequestFinished(method string, route string, status int, elapsed time.Duration) {
+	m.httpInFlight.Dec()
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) observeQuery(operation string, table string, elapsed time.Duration, err error) {
+	m.dbQueryDuration.WithLabelValues(operation, table).Observe(elapsed.Seconds())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		m.dbQueryErrors.WithLabelValues(operation, table).Inc()
+	}
+}
+
+func (m *Metrics) observeStorage(operation string, elapsed time.Duration, err error) {
+	m.storageDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+	if err != nil {
+		m.storageFailures.WithLabelValues(operation).Inc()
+	}
+}
+
+// StepExecuted y StepCompensated cumplen repositories.SagaObserver
+func (m *Metrics) StepExecuted(step string, err error) {
+	m.sagaSteps.WithLabelValues(step, result(err)).Inc()
+}
+
+func (m *Metrics) StepCompensated(step string, err error) {
+	m.sagaCompensations.WithLabelValues(step, result(err)).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailure
+	}
+	return resultSuccess
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"go-api-find-my-friend/pkg/storage_provider"
+)
+
+// instrumentedStorage mide la latencia y los fallos de cada operación del proveedor que envuelve
+type instrumentedStorage struct {
+	provider storage_provider.StorageProvider
+	metrics  *Metrics
+}
+
+// InstrumentStorage envuelve provider sin cambiar su comportamiento. En Download se mide hasta
+// obtener el archivo, no su lectura
+func InstrumentStorage(provider storage_provider.StorageProvider, m *Metrics) storage_provider.StorageProvider {
+	return &instrumentedStorage{provider: provider, metrics: m}
+}
+
+func (s *instrumentedStorage) Upload(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	begin := time.Now()
+	fileURL, err := s.provider.Upload(ctx, file)
+	s.metrics.observeStorage("upload", time.Since(begin), err)
+	return fileURL, err
+}
+
+func (s *instrumentedStorage) Delete(ctx context.Context, fileURL string) error {
+	begin := time.Now()
+	err := s.provider.Delete(ctx, fileURL)
+	s.metrics.observeStorage("delete", time.Since(begin), err)
+	return err
+}
+
+func (s *instrumentedStorage) Download(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	begin := time.Now()
+	file, err := s.provider.Download(ctx, fileURL)
+	s.metrics.observeStorage("download", time.Since(begin), err)
+	return file, err
+}
+
+func (s *instrumentedStorage) List(ctx context.Context) ([]storage_provider.StoredFile, error) {
+	begin := time.Now()
+	files, err := s.provider.List(ctx)
+	s.metrics.observeStorage("list", time.Since(begin), err)
+	return files, err
+}
+
+func (s *instrumentedStorage) Key(fileURL string) (string, error) {
+	return s.provider.Key(fileURL)
+}
+
+func (s *instrumentedStorage) Ping(ctx context.Context) error {
+	begin := time.Now()
+	err := s.provider.Ping(ctx)
+	s.metrics.observeStorage("ping", time.Since(begin), err)
+	return err
+}
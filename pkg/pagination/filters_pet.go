@@ -5,4 +5,10 @@ type FilterPet struct {
 	Type          *string `json:"type"`
 	Breed         *string `json:"breed"`
 	LastSeenPlace *string `json:"last_seen_place"`
+	Status        *string `json:"status"`
+	// IncludeArchived incluye las publicaciones archivadas, que por defecto se excluyen
+	IncludeArchived bool `json:"include_archived"`
+	// Las publicaciones ocultas por moderación solo se incluyen con IncludeHidden o si son de ViewerID
+	IncludeHidden bool `json:"include_hidden"`
+	ViewerID      *int `json:"viewer_id"`
 }
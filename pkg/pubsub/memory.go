@@ -0,0 +1,97 @@
+package pubsub
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const subscriberBufferSize = 64
+
+type MemoryHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewMemoryHub() *MemoryHub {
+	return &MemoryHub{
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish nunca bloquea: si un suscriptor no consume a tiempo, el evento se descarta para él
+func (h *MemoryHub) Publish(topic string, event Event) {
+	event.Topic = topic
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscription := range h.subscribers[topic] {
+		select {
+		case subscription.events <- event:
+		default:
+			slog.Warn("dropping event: subscriber is not keeping up", "event_type", event.Type, "topic", topic)
+		}
+	}
+}
+
+func (h *MemoryHub) Subscribe(topics ...string) *Subscription {
+	subscription := &Subscription{
+		events: make(chan Event, subscriberBufferSize),
+	}
+
+	var once sync.Once
+	subscription.close = func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			for _, topic := range topics {
+				delete(h.subscribers[topic], subscription)
+				if len(h.subscribers[topic]) == 0 {
+					delete(h.subscribers, topic)
+				}
+			}
+			close(subscription.events)
+		})
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(subscription.events)
+		subscription.close = func() {}
+		return subscription
+	}
+
+	for _, topic := range topics {
+		if h.subscribers[topic] == nil {
+			h.subscribers[topic] = make(map[*Subscription]struct{})
+		}
+		h.subscribers[topic][subscription] = struct{}{}
+	}
+
+	return subscription
+}
+
+// Close se llama al apagar el servidor para que los streams SSE no demoren el cierre
+func (h *MemoryHub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	subscriptions := make(map[*Subscription]struct{})
+	for _, topicSubscribers := range h.subscribers {
+		for subscription := range topicSubscribers {
+			subscriptions[subscription] = struct{}{}
+		}
+	}
+	h.mu.Unlock()
+
+	for subscription := range subscriptions {
+		subscription.Close()
+	}
+}
@@ -0,0 +1,37 @@
+package pubsub
+
+import (
+	"time"
+)
+
+// Event lleva en Attributes los metadatos por los que filtran los suscriptores. Son strings para
+// que lleguen iguales con cualquier implementación del Hub, aunque Data se serialice en el camino
+type Event struct {
+	Type       string            `json:"type"`
+	Topic      string            `json:"topic"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Data       interface{}       `json:"data"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Hub distribuye eventos por tópico. La implementación en memoria sirve para una sola
+// instancia de la API; con varias réplicas se reemplaza por una respaldada en Redis.
+type Hub interface {
+	Publish(topic string, event Event)
+	Subscribe(topics ...string) *Subscription
+	// Close cierra todas las suscripciones; los streams abiertos terminan al ver su canal cerrado
+	Close()
+}
+
+type Subscription struct {
+	events chan Event
+	close  func()
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.close()
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter permite hasta limit operaciones por clave dentro de una ventana fija de tiempo
+type Limiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*window
+	now     func() time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func NewLimiter(limit int, windowSize time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  windowSize,
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+// Allow consume una operación para la clave. Si se superó el límite devuelve false
+// y el tiempo restante hasta que se abra la próxima ventana.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.evict(now)
+
+	current, ok := l.windows[key]
+	if !ok || now.Sub(current.start) >= l.window {
+		current = &window{start: now}
+		l.windows[key] = current
+	}
+
+	if current.count >= l.limit {
+		return false, current.start.Add(l.window).Sub(now)
+	}
+
+	current.count++
+	return true, 0
+}
+
+// evict elimina las ventanas vencidas cuando el mapa crece demasiado
+func (l *Limiter) evict(now time.Time) {
+	if len(l.windows) < 10000 {
+		return
+	}
+
+	for key, current := range l.windows {
+		if now.Sub(current.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -4,41 +4,47 @@ import (
 	"context"
 	"fmt"
 	"go-api-find-my-friend/pkg/config"
-	"log"
+	"go-api-find-my-friend/pkg/logger"
+	"go-api-find-my-friend/pkg/utils"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
+const cloudinaryFolder = "find-my-friend"
+
 type CloudinaryClient struct {
-	cld *cloudinary.Cloudinary
+	cld     *cloudinary.Cloudinary
+	client  *http.Client
+	timeout time.Duration
 }
 
-var (
-	cloudinaryClientInstance *CloudinaryClient
-	cloudinaryClientOnce     sync.Once
-)
-
-func NewCloudinary() *CloudinaryClient {
-	cloudinaryClientOnce.Do(func() {
-		cld, err := cloudinary.NewFromParams(config.ConfigInstance.Cloudinary.CloudName, config.ConfigInstance.Cloudinary.APIKey, config.ConfigInstance.Cloudinary.APISecret)
-		if err != nil {
-			log.Fatalf("Error creating Cloudinary client: %v", err)
-		}
-		cloudinaryClientInstance = &CloudinaryClient{
-			cld: cld,
-		}
-	})
-	return cloudinaryClientInstance
+func NewCloudinary(cfg config.CloudinaryConfig, timeout time.Duration) (*CloudinaryClient, error) {
+	cld, err := cloudinary.NewFromParams(cfg.CloudName, cfg.APIKey, cfg.APISecret)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Cloudinary client: %w", err)
+	}
+	return &CloudinaryClient{
+		cld:     cld,
+		client:  &http.Client{Timeout: timeout},
+		timeout: timeout,
+	}, nil
 }
 
-func (c *CloudinaryClient) Upload(file *multipart.FileHeader) (string, error) {
-	uploadResult, err := c.cld.Upload.Upload(context.Background(), file, uploader.UploadParams{
+func (c *CloudinaryClient) Upload(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	uploadResult, err := c.cld.Upload.Upload(ctx, file, uploader.UploadParams{
 		ResourceType: "auto",
-		Folder:       "find-my-friend",
+		Folder:       cloudinaryFolder,
 	})
 	if err != nil {
 		return "", err
@@ -46,21 +52,95 @@ func (c *CloudinaryClient) Upload(file *multipart.FileHeader) (string, error) {
 	return uploadResult.SecureURL, nil
 }
 
-func (c *CloudinaryClient) Delete(fileURL string) error {
+func (c *CloudinaryClient) Delete(ctx context.Context, fileURL string) error {
 	publicID, err := extractPublicIDFromURL(fileURL)
 	if err != nil {
-		log.Printf("Failed to extract public ID from URL %s: %v", fileURL, err)
+		logger.FromContext(ctx).Error("failed to extract Cloudinary public ID", "url", fileURL, logger.Err(err))
 		return err
 	}
 
-	result, err := c.cld.Upload.Destroy(context.Background(), uploader.DestroyParams{PublicID: publicID})
-	fmt.Println(result)
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	result, err := c.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: publicID})
 	if err != nil {
-		log.Printf("Failed to delete file with public ID %s: %v", publicID, err)
+		logger.FromContext(ctx).Error("failed to delete Cloudinary file", "public_id", publicID, logger.Err(err))
 		return err
 	}
 
-	log.Printf("Successfully deleted file with public ID: %s", publicID)
+	logger.FromContext(ctx).Info("deleted Cloudinary file", "public_id", publicID, "result", result.Result)
+	return nil
+}
+
+// Download obtiene el archivo desde la URL pública que devuelve Cloudinary. El timeout del
+// cliente HTTP incluye la lectura del cuerpo, que queda a cargo de quien llama
+func (c *CloudinaryClient) Download(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d downloading %s", resp.StatusCode, fileURL)
+	}
+
+	return resp.Body, nil
+}
+
+// List recorre con la Admin API todas las imágenes de la carpeta de la aplicación
+func (c *CloudinaryClient) List(ctx context.Context) ([]StoredFile, error) {
+	var files []StoredFile
+	params := admin.AssetsParams{
+		AssetType:  api.Image,
+		Prefix:     cloudinaryFolder + "/",
+		MaxResults: 500,
+	}
+
+	for {
+		pageCtx, cancel := utils.WithTimeout(ctx, c.timeout)
+		result, err := c.cld.Admin.Assets(pageCtx, params)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if result.Error.Message != "" {
+			return nil, fmt.Errorf("failed to list Cloudinary assets: %s", result.Error.Message)
+		}
+
+		for _, asset := range result.Assets {
+			files = append(files, StoredFile{URL: asset.SecureURL, Key: asset.PublicID, CreatedAt: asset.CreatedAt})
+		}
+
+		if result.NextCursor == "" {
+			return files, nil
+		}
+		params.NextCursor = result.NextCursor
+	}
+}
+
+// Key es el public ID del archivo
+func (c *CloudinaryClient) Key(fileURL string) (string, error) {
+	return extractPublicIDFromURL(fileURL)
+}
+
+// Ping consulta el endpoint de ping de la Admin API, que además valida las credenciales
+func (c *CloudinaryClient) Ping(ctx context.Context) error {
+	ctx, cancel := utils.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	result, err := c.cld.Admin.Ping(ctx)
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return fmt.Errorf("cloudinary ping failed: %s", result.Error.Message)
+	}
 	return nil
 }
 
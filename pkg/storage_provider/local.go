@@ -0,0 +1,168 @@
+package storage_provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-api-find-my-friend/pkg/config"
+	"go-api-find-my-friend/pkg/logger"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage guarda los archivos en disco; el servidor los publica en BaseURL. Se usa en
+// desarrollo para no depender de Cloudinary
+type LocalStorage struct {
+	path    string
+	baseURL string
+}
+
+func NewLocalStorage(cfg config.UploadConfig) *LocalStorage {
+	return &LocalStorage{
+		path:    cfg.Path,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+	}
+}
+
+// Upload copia el archivo a disco; si ctx se cancela durante la copia se descarta el archivo parcial
+func (s *LocalStorage) Upload(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(s.path, 0o755); err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(suffix) + strings.ToLower(filepath.Ext(file.Filename))
+
+	path := filepath.Join(s.path, name)
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, &contextReader{ctx: ctx, r: src}); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return s.baseURL + "/" + name, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, fileURL string) error {
+	name, err := s.fileName(fileURL)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(s.path, name)); err != nil && !os.IsNotExist(err) {
+		logger.FromContext(ctx).Error("failed to delete local file", "file", name, logger.Err(err))
+		return err
+	}
+
+	logger.FromContext(ctx).Info("deleted local file", "file", name)
+	return nil
+}
+
+func (s *LocalStorage) Download(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	name, err := s.fileName(fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filepath.Join(s.path, name))
+}
+
+func (s *LocalStorage) List(ctx context.Context) ([]StoredFile, error) {
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	files := make([]StoredFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, StoredFile{URL: s.baseURL + "/" + entry.Name(), Key: entry.Name(), CreatedAt: info.ModTime()})
+	}
+
+	return files, nil
+}
+
+// Key es el nombre del archivo, el último segmento de la URL. No exige que la URL empiece con
+// la URL base actual, para reconocer los archivos aunque UPLOAD_BASE_URL haya cambiado
+func (s *LocalStorage) Key(fileURL string) (string, error) {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	name := path.Base(parsed.Path)
+	if strings.HasSuffix(parsed.Path, "/") || name == "." || name == "/" || name == ".." {
+		return "", fmt.Errorf("invalid local storage URL %s", fileURL)
+	}
+	return name, nil
+}
+
+// Ping verifica que se pueda escribir en path creando y borrando un archivo temporal
+func (s *LocalStorage) Ping(ctx context.Context) error {
+	if err := os.MkdirAll(s.path, 0o755); err != nil {
+		return err
+	}
+
+	probe, err := os.CreateTemp(s.path, ".ping-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// fileName obtiene el nombre del archivo a partir de su URL pública, sin permitir rutas fuera de path
+func (s *LocalStorage) fileName(fileURL string) (string, error) {
+	name, found := strings.CutPrefix(fileURL, s.baseURL+"/")
+	if !found || name == "" || strings.ContainsAny(name, `/\`) || name == ".." {
+		return "", fmt.Errorf("invalid local storage URL %s", fileURL)
+	}
+	return name, nil
+}
+
+// contextReader corta la lectura cuando se cancela ctx
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
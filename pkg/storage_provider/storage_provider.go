@@ -1,8 +1,47 @@
 package storage_provider
 
-import "mime/multipart"
+import (
+	"context"
+	"go-api-find-my-friend/pkg/config"
+	"io"
+	"mime/multipart"
+	"time"
+)
 
+// StoredFile es un archivo guardado en el proveedor, con la misma URL que devuelve Upload y la
+// clave que devuelve Key para esa URL
+type StoredFile struct {
+	URL       string
+	Key       string
+	CreatedAt time.Time
+}
+
+// StorageProvider guarda las imágenes. Cada operación recibe el contexto del request o del trabajo
+// que la origina; las implementaciones remotas además la acotan al timeout de storage configurado
 type StorageProvider interface {
-	Upload(file *multipart.FileHeader) (string, error)
-	Delete(fileURL string) error
+	Upload(ctx context.Context, file *multipart.FileHeader) (string, error)
+	Delete(ctx context.Context, fileURL string) error
+	Download(ctx context.Context, fileURL string) (io.ReadCloser, error)
+	List(ctx context.Context) ([]StoredFile, error)
+	// Key identifica el archivo de una URL dentro del proveedor sin depender del dominio ni de
+	// la URL base, así dos URLs del mismo archivo tienen la misma clave
+	Key(fileURL string) (string, error)
+	// Ping verifica que el proveedor esté disponible; lo usa el chequeo de readiness
+	Ping(ctx context.Context) error
+}
+
+// NewStorageProvider devuelve el proveedor configurado en STORAGE_DRIVER; sin configurar usa
+// Cloudinary en producción y el disco local en desarrollo
+func NewStorageProvider(cfg *config.Config) (StorageProvider, error) {
+	switch cfg.Upload.Driver {
+	case "cloudinary":
+		return NewCloudinary(cfg.Cloudinary, cfg.Upload.Timeout)
+	case "local":
+		return NewLocalStorage(cfg.Upload), nil
+	}
+
+	if cfg.IsProduction() {
+		return NewCloudinary(cfg.Cloudinary, cfg.Upload.Timeout)
+	}
+	return NewLocalStorage(cfg.Upload), nil
 }
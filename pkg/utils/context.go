@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout acota ctx a timeout. Con timeout cero o negativo no agrega límite, así una
+// variable de entorno en 0 desactiva el timeout
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}